@@ -1,15 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"rancher-manager/idempotency"
 	"rancher-manager/internal/inventoryservice/grpc"
 	"rancher-manager/internal/inventoryservice/handler"
 	"rancher-manager/internal/inventoryservice/model"
@@ -36,16 +42,35 @@ func main() {
 	}
 
 	// Auto migrate models
-	if err := db.AutoMigrate(&model.Inventory{}); err != nil {
+	if err := db.AutoMigrate(
+		&model.Inventory{},
+		&model.StockMovement{},
+		&model.Reservation{},
+		&model.Location{},
+		&model.LocationStock{},
+		&model.Transfer{},
+		&model.Lot{},
+		&model.CountSession{},
+		&model.CountLine{},
+		&model.Report{},
+		&model.Saga{},
+		&model.OutboxEvent{},
+		&model.ReconciliationRun{},
+		&model.Supplier{},
+		&model.PurchaseOrder{},
+		&model.PurchaseOrderLine{},
+		&model.Receipt{},
+		&model.ReceiptLine{},
+		&model.ReceiptDiscrepancy{},
+		&model.IdempotencyKey{},
+	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
-
-	// Initialize Kafka publisher
-	kafkaBrokers := []string{"localhost:9092"}
-	publisher, err := kafka.NewPublisher(kafkaBrokers)
-	if err != nil {
-		log.Printf("Warning: Failed to connect to Kafka: %v", err)
-		publisher = nil
+	// Reservation idempotency keys used to be unique across users
+	if db.Migrator().HasIndex(&model.Reservation{}, "idx_reservations_idempotency_key") {
+		if err := db.Migrator().DropIndex(&model.Reservation{}, "idx_reservations_idempotency_key"); err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
 	}
 
 	// Initialize gRPC clients
@@ -71,9 +96,63 @@ func main() {
 
 	// Initialize layers
 	inventoryRepo := repository.NewInventoryRepository(db)
-	inventoryService := service.NewInventoryService(inventoryRepo, authClient, itemClient, publisher)
+	inventoryRepo.SetLimits(stockLimits())
+	if err := inventoryRepo.EnsureOpeningBalances(); err != nil {
+		log.Fatal("Failed to record opening stock balances:", err)
+	}
+	reservationRepo := repository.NewReservationRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+	countRepo := repository.NewCountRepository(db, inventoryRepo)
+	reportRepo := repository.NewReportRepository(db)
+	sagaRepo := repository.NewSagaRepository(db, inventoryRepo)
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	purchaseOrderRepo := repository.NewPurchaseOrderRepository(db, inventoryRepo)
+	inventoryService := service.NewInventoryService(inventoryRepo, reservationRepo, locationRepo, countRepo, reportRepo, sagaRepo, reconciliationRepo, purchaseOrderRepo, authClient, itemClient)
+	inventoryService.SetCountVarianceLimit(intEnv("COUNT_VARIANCE_LIMIT", model.DefaultCountVarianceLimit))
 	inventoryHandler := handler.NewInventoryHandler(inventoryService)
 
+	// Release reservations whose checkout was abandoned
+	go inventoryService.RunReservationSweeper(context.Background(), durationEnv("RESERVATION_SWEEP_INTERVAL", 30*time.Second))
+
+	// Catch low stock that the checks after each stock change miss
+	go inventoryService.RunLowStockDetector(context.Background(), durationEnv("LOW_STOCK_CHECK_INTERVAL", time.Minute))
+
+	// Publish the events in the outbox to Kafka, connecting once Kafka is up
+	kafkaBrokers := []string{"localhost:9092"}
+	outboxRelay := service.NewOutboxRelay(repository.NewOutboxRepository(db), func() (service.OutboxPublisher, error) {
+		publisher, err := kafka.NewPublisher(kafkaBrokers)
+		if err != nil {
+			return nil, err
+		}
+		return publisher, nil
+	}, service.NewOutboxMetrics(prometheus.DefaultRegisterer))
+	go outboxRelay.Run(context.Background(), durationEnv("OUTBOX_RELAY_INTERVAL", time.Second))
+
+	// Resume sagas interrupted by a crash or by ItemService being down
+	go inventoryService.RunSagaRecovery(context.Background(), durationEnv("SAGA_RECOVERY_INTERVAL", 30*time.Second))
+
+	// Compare stock with ItemService and repair drift from the source of
+	// truth; ItemService is called as RECONCILIATION_USER_ID
+	reconciliationSource := model.ReconciliationSource(os.Getenv("RECONCILIATION_SOURCE"))
+	if reconciliationSource == "" {
+		reconciliationSource = model.ReconcileFromInventory
+	}
+	if !reconciliationSource.Valid() {
+		log.Fatalf("Invalid RECONCILIATION_SOURCE %q: want inventory, item_service or none", reconciliationSource)
+	}
+	if reconciliationUserID := intEnv("RECONCILIATION_USER_ID", 0); reconciliationUserID > 0 {
+		go inventoryService.RunReconciliation(context.Background(), durationEnv("RECONCILIATION_INTERVAL", time.Hour), reconciliationSource, uint32(reconciliationUserID))
+	} else {
+		log.Println("Warning: RECONCILIATION_USER_ID not set, stock reconciliation disabled")
+	}
+
+	// Idempotency keys of REST requests, kept for IDEMPOTENCY_KEY_TTL
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	go idempotency.RunPurger(context.Background(), idempotencyRepo, durationEnv("IDEMPOTENCY_PURGE_INTERVAL", time.Hour))
+
+	// Generate reports off the request path
+	go inventoryService.RunReportWorker(context.Background(), durationEnv("REPORT_POLL_INTERVAL", 10*time.Second))
+
 	// Setup Gin router
 	r := gin.Default()
 
@@ -81,7 +160,7 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Idempotency-Key")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -96,24 +175,133 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "inventoryservice"})
 	})
 
+	// Prometheus metrics
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// Inventory routes (all require authentication)
 	inventory := r.Group("/inventory")
-	inventory.Use(inventoryHandler.AuthMiddleware())
+	inventory.Use(inventoryHandler.AuthMiddleware(), idempotency.Middleware(idempotencyRepo, durationEnv("IDEMPOTENCY_KEY_TTL", idempotency.DefaultTTL)))
 	{
 		inventory.POST("/stock/:item_id", inventoryHandler.UpdateStock)
 		inventory.DELETE("/item/:item_id", inventoryHandler.DeleteItem)
 		inventory.GET("/stock/:item_id", inventoryHandler.GetStock)
+		inventory.POST("/stock/:item_id/increment", inventoryHandler.IncrementStock)
+		inventory.POST("/stock/:item_id/decrement", inventoryHandler.DecrementStock)
+		inventory.GET("/stock/:item_id/movements", inventoryHandler.GetMovements)
+		inventory.PUT("/stock/:item_id/thresholds", inventoryHandler.UpdateThresholds)
+		inventory.GET("/alerts", inventoryHandler.GetAlerts)
 		inventory.GET("/items", inventoryHandler.GetAllItems)
+		inventory.GET("/sagas", inventoryHandler.GetSagas)
+		inventory.GET("/reconciliation", inventoryHandler.GetReconciliation)
+
+		inventory.GET("/stock/:item_id/locations", inventoryHandler.GetItemLocations)
+		inventory.POST("/locations", inventoryHandler.CreateLocation)
+		inventory.GET("/locations", inventoryHandler.GetLocations)
+		inventory.POST("/transfers", inventoryHandler.CreateTransfer)
+		inventory.GET("/transfers", inventoryHandler.GetTransfers)
+		inventory.GET("/transfers/:id", inventoryHandler.GetTransfer)
+		inventory.POST("/transfers/:id/receive", inventoryHandler.ReceiveTransfer)
+		inventory.POST("/transfers/:id/cancel", inventoryHandler.CancelTransfer)
+
+		inventory.GET("/stock/:item_id/lots", inventoryHandler.GetItemLots)
+		inventory.GET("/lots/expiring", inventoryHandler.GetExpiringLots)
+		inventory.POST("/lots/:id/quarantine", inventoryHandler.QuarantineLot)
+		inventory.POST("/lots/:id/block", inventoryHandler.BlockLot)
+		inventory.POST("/lots/:id/release", inventoryHandler.ReleaseLot)
+
+		inventory.POST("/counts", inventoryHandler.CreateCount)
+		inventory.GET("/counts", inventoryHandler.GetCounts)
+		inventory.GET("/counts/:id", inventoryHandler.GetCount)
+		inventory.POST("/counts/:id/submit", inventoryHandler.SubmitCount)
+		inventory.POST("/counts/:id/approve", inventoryHandler.ApproveCount)
+		inventory.POST("/counts/:id/close", inventoryHandler.CloseCount)
+
+		inventory.POST("/suppliers", inventoryHandler.CreateSupplier)
+		inventory.GET("/suppliers", inventoryHandler.GetSuppliers)
+		inventory.POST("/purchase-orders", inventoryHandler.CreatePurchaseOrder)
+		inventory.GET("/purchase-orders", inventoryHandler.GetPurchaseOrders)
+		inventory.GET("/purchase-orders/:id", inventoryHandler.GetPurchaseOrder)
+		inventory.POST("/purchase-orders/:id/receive", inventoryHandler.ReceivePurchaseOrder)
+		inventory.POST("/purchase-orders/:id/close", inventoryHandler.ClosePurchaseOrder)
+		inventory.POST("/purchase-orders/:id/cancel", inventoryHandler.CancelPurchaseOrder)
+
+		inventory.POST("/reports", inventoryHandler.CreateReport)
+		inventory.GET("/reports", inventoryHandler.GetReports)
+		inventory.GET("/reports/:id", inventoryHandler.GetReport)
+		inventory.GET("/reports/:id/csv", inventoryHandler.GetReportCSV)
+
+		inventory.POST("/reservations", inventoryHandler.CreateReservation)
+		inventory.GET("/reservations/:id", inventoryHandler.GetReservation)
+		inventory.POST("/reservations/:id/confirm", inventoryHandler.ConfirmReservation)
+		inventory.POST("/reservations/:id/release", inventoryHandler.ReleaseReservation)
+	}
+
+	// Start HTTP server in goroutine
+	go func() {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8083"
+		}
+
+		log.Printf("InventoryService HTTP starting on port %s", port)
+		if err := r.Run(":" + port); err != nil {
+			log.Fatal("Failed to start HTTP server:", err)
+		}
+	}()
+
+	// Start gRPC server
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "50053"
 	}
 
-	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8083"
+	log.Printf("InventoryService gRPC starting on port %s", grpcPort)
+	if err := grpc.StartGRPCServer(inventoryService, grpcPort); err != nil {
+		log.Fatal("Failed to start gRPC server:", err)
 	}
+}
+
+// stockLimits reads the thresholds of new inventory records and the MaxStock
+// policy from the environment.
+func stockLimits() repository.StockLimits {
+	limits := repository.DefaultStockLimits
+	limits.DefaultMinStock = intEnv("DEFAULT_MIN_STOCK", limits.DefaultMinStock)
+	limits.DefaultMaxStock = intEnv("DEFAULT_MAX_STOCK", limits.DefaultMaxStock)
+
+	switch policy := os.Getenv("MAX_STOCK_POLICY"); policy {
+	case "", "warn":
+	case "reject":
+		limits.RejectAboveMax = true
+	default:
+		log.Fatalf("Invalid MAX_STOCK_POLICY %q: want warn or reject", policy)
+	}
+	return limits
+}
 
-	log.Printf("InventoryService starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+// intEnv reads a non-negative integer from the environment.
+func intEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("Warning: Invalid %s %q, using %d", name, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// durationEnv reads a duration such as "5s" from the environment.
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: Invalid %s %q, using %s", name, value, fallback)
+		return fallback
 	}
+	return d
 }
@@ -5,18 +5,25 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"rancher-manager/idempotency"
+	"rancher-manager/internal/itemservice/cache"
 	"rancher-manager/internal/itemservice/grpc"
 	"rancher-manager/internal/itemservice/handler"
 	"rancher-manager/internal/itemservice/model"
 	"rancher-manager/internal/itemservice/repository"
 	"rancher-manager/internal/itemservice/service"
+	"rancher-manager/internal/itemservice/storage"
 	"rancher-manager/kafka"
 )
 
@@ -28,12 +35,12 @@ type KafkaEventHandler struct {
 func (h *KafkaEventHandler) HandleStockUpdate(event *kafka.StockUpdateEvent) error {
 	log.Printf("Received stock update event for item %s: new stock %d", event.ItemID, event.NewStock)
 
-	// Update stock in ItemService
+	// Update stock in ItemService; the write also drops the cached item
 	updateReq := &model.UpdateItemRequest{
 		Stock: event.NewStock,
 	}
 
-	_, err := h.itemService.UpdateItem(event.ItemID, updateReq, event.UserID)
+	_, err := h.itemService.UpdateItem(context.Background(), event.ItemID, updateReq, event.UserID)
 	if err != nil {
 		log.Printf("Failed to update stock for item %s: %v", event.ItemID, err)
 		return err
@@ -47,7 +54,7 @@ func (h *KafkaEventHandler) HandleItemDelete(event *kafka.ItemDeleteEvent) error
 	log.Printf("Received item delete event for item %s", event.ItemID)
 
 	// Delete item in ItemService
-	err := h.itemService.DeleteItem(event.ItemID, event.UserID)
+	err := h.itemService.DeleteItem(context.Background(), event.ItemID, event.UserID)
 	if err != nil {
 		log.Printf("Failed to delete item %s: %v", event.ItemID, err)
 		return err
@@ -57,6 +64,61 @@ func (h *KafkaEventHandler) HandleItemDelete(event *kafka.ItemDeleteEvent) error
 	return nil
 }
 
+// newBlobStore selects the attachment storage backend from BLOB_STORE
+// ("local" or "s3").
+func newBlobStore(ctx context.Context) (storage.BlobStore, error) {
+	if os.Getenv("BLOB_STORE") != "s3" {
+		dir := os.Getenv("BLOB_LOCAL_DIR")
+		if dir == "" {
+			dir = "data/attachments"
+		}
+		log.Printf("Storing attachments in %s", dir)
+		return storage.NewLocalBlobStore(dir)
+	}
+
+	bucket := os.Getenv("S3_BUCKET")
+	if bucket == "" {
+		bucket = "item-attachments"
+	}
+
+	log.Printf("Storing attachments in S3 bucket %s at %s", bucket, os.Getenv("S3_ENDPOINT"))
+	return storage.NewS3BlobStore(ctx, storage.S3Config{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		Bucket:    bucket,
+		Region:    os.Getenv("S3_REGION"),
+		UseSSL:    os.Getenv("S3_USE_SSL") == "true",
+	})
+}
+
+func newItemCache(ctx context.Context) (cache.Cache, error) {
+	host := os.Getenv("REDIS_HOST")
+	if host == "" {
+		host = "localhost"
+	}
+	port := os.Getenv("REDIS_PORT")
+	if port == "" {
+		port = "6379"
+	}
+
+	return cache.NewRedisCache(ctx, host+":"+port, os.Getenv("REDIS_PASSWORD"), "itemservice:")
+}
+
+// durationEnv reads a duration such as "5s" from the environment.
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: Invalid %s %q, using %s", name, value, fallback)
+		return fallback
+	}
+	return d
+}
+
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
@@ -93,15 +155,93 @@ func main() {
 		authServiceAddr = "localhost:50051"
 	}
 
-	authClient, err := grpc.NewAuthClient(authServiceAddr)
+	authClient, err := grpc.NewAuthClient(authServiceAddr, durationEnv("AUTH_TIMEOUT", grpc.DefaultAuthTimeout))
 	if err != nil {
 		log.Fatal("Failed to connect to auth service:", err)
 	}
 
+	// Pricing configuration
+	if currency := os.Getenv("DEFAULT_CURRENCY"); currency != "" {
+		model.DefaultCurrency = strings.ToUpper(currency)
+	}
+
+	exchangeRatesFile := os.Getenv("EXCHANGE_RATES_FILE")
+	if exchangeRatesFile == "" {
+		exchangeRatesFile = "exchange_rates.json"
+	}
+
+	exchangeRates, err := service.LoadExchangeRates(exchangeRatesFile)
+	if err != nil {
+		log.Printf("Warning: Failed to load exchange rates from %s: %v", exchangeRatesFile, err)
+		exchangeRates = nil
+	}
+
+	if maxSize := os.Getenv("ATTACHMENT_MAX_BYTES"); maxSize != "" {
+		if size, err := strconv.ParseInt(maxSize, 10, 64); err == nil && size > 0 {
+			service.MaxAttachmentSize = size
+		}
+	}
+
+	blobStore, err := newBlobStore(ctx)
+	if err != nil {
+		log.Fatal("Failed to initialize attachment storage:", err)
+	}
+
+	// Database timeouts, e.g. MONGO_OPERATION_TIMEOUTS=items.find=10s,items.search=2s
+	timeouts, err := repository.ParseTimeouts(
+		durationEnv("MONGO_TIMEOUT", repository.DefaultTimeout),
+		os.Getenv("MONGO_OPERATION_TIMEOUTS"),
+	)
+	if err != nil {
+		log.Fatal("Invalid MONGO_OPERATION_TIMEOUTS:", err)
+	}
+
 	// Initialize layers
-	itemRepo := repository.NewItemRepository(db)
-	itemService := service.NewItemService(itemRepo, authClient)
+	mongoItemRepo := repository.NewMongoItemRepository(db, timeouts)
+	categoryRepo := repository.NewMongoCategoryRepository(db, timeouts)
+	if err := mongoItemRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create item indexes:", err)
+	}
+	if err := categoryRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create category indexes:", err)
+	}
+
+	// Idempotency keys of REST requests and IDs of handled Kafka events,
+	// expired by MongoDB
+	idempotencyStore := repository.NewMongoIdempotencyStore(db, timeouts)
+	if err := idempotencyStore.EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create idempotency key indexes:", err)
+	}
+
+	// Item cache in Redis; without it every lookup goes to MongoDB
+	var itemRepo repository.ItemRepository = mongoItemRepo
+	if itemCache, err := newItemCache(ctx); err != nil {
+		log.Printf("Warning: Item cache disabled, failed to connect to Redis: %v", err)
+	} else {
+		itemRepo = repository.NewCachedItemRepository(
+			mongoItemRepo,
+			itemCache,
+			cache.NewMetrics(prometheus.DefaultRegisterer, "item"),
+			durationEnv("ITEM_CACHE_TTL", 5*time.Minute),
+			durationEnv("ITEM_CACHE_NEGATIVE_TTL", 30*time.Second),
+		)
+		log.Println("Item cache enabled")
+	}
+
+	// Item change feed for WatchItems: MongoDB change streams when the
+	// deployment supports them, otherwise only changes made by this instance
+	var itemFeed service.ItemFeed
+	if feed, err := repository.NewMongoItemFeed(ctx, db); err != nil {
+		log.Printf("Warning: MongoDB change streams unavailable, watching local item changes only: %v", err)
+	} else {
+		itemFeed = feed
+		log.Println("Watching item changes with MongoDB change streams")
+	}
+
+	itemService := service.NewItemService(itemRepo, categoryRepo, authClient, exchangeRates, blobStore, service.NewEventBus(), itemFeed)
+	categoryService := service.NewCategoryService(categoryRepo, itemRepo, authClient)
 	itemHandler := handler.NewItemHandler(itemService)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
 
 	// Initialize Kafka consumer
 	kafkaBrokers := os.Getenv("KAFKA_BROKERS")
@@ -114,6 +254,9 @@ func main() {
 	if err != nil {
 		log.Printf("Warning: Failed to connect to Kafka: %v", err)
 	} else {
+		// The outbox delivers events at least once
+		consumer.DeduplicateEvents(idempotencyStore, durationEnv("EVENT_DEDUP_TTL", 24*time.Hour))
+
 		// Start Kafka consumer in goroutine
 		go func() {
 			topics := []string{"stock_updates", "item_deletes"}
@@ -131,7 +274,7 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Idempotency-Key")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -146,15 +289,34 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "itemservice"})
 	})
 
+	// Prometheus metrics
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// Item routes (all require authentication)
 	items := r.Group("/items")
-	items.Use(itemHandler.AuthMiddleware())
+	items.Use(itemHandler.AuthMiddleware(), idempotency.Middleware(idempotencyStore, durationEnv("IDEMPOTENCY_KEY_TTL", idempotency.DefaultTTL)))
 	{
 		items.POST("/", itemHandler.CreateItem)
 		items.GET("/", itemHandler.GetAllItems)
 		items.GET("/:id", itemHandler.GetItem)
 		items.PUT("/:id", itemHandler.UpdateItem)
 		items.DELETE("/:id", itemHandler.DeleteItem)
+		items.GET("/:id/price", itemHandler.GetPrice)
+		items.POST("/:id/attachments", itemHandler.UploadAttachment)
+		items.GET("/:id/attachments/:attachment_id", itemHandler.DownloadAttachment)
+		items.DELETE("/:id/attachments/:attachment_id", itemHandler.DeleteAttachment)
+		items.POST("/:id/transitions", itemHandler.TransitionItem)
+		items.GET("/:id/transitions", itemHandler.GetTransitions)
+		items.POST("/:id/variants", itemHandler.CreateVariant)
+		items.GET("/:id/variants", itemHandler.GetVariants)
+
+		items.POST("/categories", categoryHandler.CreateCategory)
+		items.GET("/categories", categoryHandler.GetCategoryTree)
+		items.GET("/categories/:slug", categoryHandler.GetCategory)
+		items.PUT("/categories/:slug", categoryHandler.UpdateCategory)
+		items.DELETE("/categories/:slug", categoryHandler.DeleteCategory)
+		items.POST("/categories/:slug/rename", categoryHandler.RenameCategory)
+		items.POST("/categories/:slug/move", categoryHandler.MoveCategory)
 	}
 
 	// Start HTTP server in goroutine
@@ -41,7 +41,7 @@ func main() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
 		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
+		AllowHeaders: "Origin,Content-Type,Accept,Authorization,Idempotency-Key",
 	}))
 
 	// Health check
@@ -123,6 +123,21 @@ func main() {
 						"GET /items/:id",
 						"PUT /items/:id",
 						"DELETE /items/:id",
+						"GET /items/:id/price",
+						"POST /items/:id/attachments",
+						"GET /items/:id/attachments/:attachment_id",
+						"DELETE /items/:id/attachments/:attachment_id",
+						"POST /items/:id/transitions",
+						"GET /items/:id/transitions",
+						"POST /items/:id/variants",
+						"GET /items/:id/variants",
+						"POST /items/categories",
+						"GET /items/categories",
+						"GET /items/categories/:slug",
+						"PUT /items/categories/:slug",
+						"DELETE /items/categories/:slug",
+						"POST /items/categories/:slug/rename",
+						"POST /items/categories/:slug/move",
 						"GET /items/health",
 					},
 				},
@@ -132,7 +147,49 @@ func main() {
 					"endpoints": []string{
 						"POST /inventory/stock/:item_id",
 						"GET /inventory/stock/:item_id",
+						"POST /inventory/stock/:item_id/increment",
+						"POST /inventory/stock/:item_id/decrement",
+						"GET /inventory/stock/:item_id/movements",
+						"PUT /inventory/stock/:item_id/thresholds",
+						"GET /inventory/alerts",
 						"GET /inventory/items",
+						"GET /inventory/sagas",
+						"GET /inventory/reconciliation",
+						"GET /inventory/stock/:item_id/locations",
+						"POST /inventory/locations",
+						"GET /inventory/locations",
+						"POST /inventory/transfers",
+						"GET /inventory/transfers",
+						"GET /inventory/transfers/:id",
+						"POST /inventory/transfers/:id/receive",
+						"POST /inventory/transfers/:id/cancel",
+						"GET /inventory/stock/:item_id/lots",
+						"GET /inventory/lots/expiring",
+						"POST /inventory/lots/:id/quarantine",
+						"POST /inventory/lots/:id/block",
+						"POST /inventory/lots/:id/release",
+						"POST /inventory/counts",
+						"GET /inventory/counts",
+						"GET /inventory/counts/:id",
+						"POST /inventory/counts/:id/submit",
+						"POST /inventory/counts/:id/approve",
+						"POST /inventory/counts/:id/close",
+						"POST /inventory/reports",
+						"GET /inventory/reports",
+						"GET /inventory/reports/:id",
+						"GET /inventory/reports/:id/csv",
+						"POST /inventory/suppliers",
+						"GET /inventory/suppliers",
+						"POST /inventory/purchase-orders",
+						"GET /inventory/purchase-orders",
+						"GET /inventory/purchase-orders/:id",
+						"POST /inventory/purchase-orders/:id/receive",
+						"POST /inventory/purchase-orders/:id/close",
+						"POST /inventory/purchase-orders/:id/cancel",
+						"POST /inventory/reservations",
+						"GET /inventory/reservations/:id",
+						"POST /inventory/reservations/:id/confirm",
+						"POST /inventory/reservations/:id/release",
 						"DELETE /inventory/item/:item_id",
 						"GET /inventory/health",
 					},
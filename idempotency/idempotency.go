@@ -0,0 +1,78 @@
+// Package idempotency lets clients retry mutating requests safely. A request
+// sent with an Idempotency-Key header is handled once; retries with the same
+// key get the first response back instead of applying the change again.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+)
+
+// Record is a stored idempotency key. Status is 0 while the request that
+// claimed the key is still being handled.
+type Record struct {
+	Key         string
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// Completed reports whether the request that claimed the key has finished.
+func (r *Record) Completed() bool {
+	return r.Status != 0
+}
+
+// Store keeps idempotency keys until they expire. Expired keys must be
+// treated as absent.
+type Store interface {
+	// Begin claims key for a request with fingerprint until expiresAt. If
+	// the key is already held, it returns the record holding it instead and
+	// claims nothing; otherwise it returns nil.
+	Begin(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*Record, error)
+	// Complete stores the response to the request that claimed key and
+	// keeps it until expiresAt.
+	Complete(ctx context.Context, key string, status int, contentType string, body []byte, expiresAt time.Time) error
+	// Release gives up a claim, so that the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// Fingerprint identifies a request by a hash of its parts.
+func Fingerprint(parts ...[]byte) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Purger is a Store whose expired keys must be deleted for it.
+type Purger interface {
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+// RunPurger deletes expired keys from purger every interval until ctx is
+// done.
+func RunPurger(ctx context.Context, purger Purger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := purger.DeleteExpired(time.Now())
+			if err != nil {
+				log.Printf("Failed to delete expired idempotency keys: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Deleted %d expired idempotency keys", deleted)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a process-local Store, used in tests and when keys need
+// not be shared between instances.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]*Record),
+	}
+}
+
+func (s *MemoryStore) Begin(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok && time.Now().Before(record.ExpiresAt) {
+		held := *record
+		return &held, nil
+	}
+	s.records[key] = &Record{Key: key, Fingerprint: fingerprint, ExpiresAt: expiresAt}
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, status int, contentType string, body []byte, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		record.Status = status
+		record.ContentType = contentType
+		record.Body = append([]byte(nil), body...)
+		record.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
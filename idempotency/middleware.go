@@ -0,0 +1,141 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Header carries the key a client picks for a request and sends again
+	// with each retry of it.
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set on responses replayed from a stored key.
+	ReplayedHeader = "Idempotent-Replayed"
+	// MaxKeyLength is the longest key accepted.
+	MaxKeyLength = 255
+	// DefaultTTL is how long responses are kept for replay.
+	DefaultTTL = 24 * time.Hour
+)
+
+// claimTimeout is how long a key is held by a request still being handled.
+// A key whose request never finished, because the instance handling it
+// crashed, can be used again after it.
+const claimTimeout = time.Minute
+
+// Middleware handles each POST, PUT, PATCH and DELETE request carrying an
+// Idempotency-Key header once, and answers retries with the stored
+// response for ttl. Keys are scoped to the authenticated user, so it must
+// run after the authentication middleware. A key reused for a different
+// request, or while its first request is still being handled, is refused
+// with 409. Server errors are not stored, so that requests failing with
+// them can be retried.
+func Middleware(store Store, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(Header)
+		if key == "" || !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > MaxKeyLength {
+			abort(c, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", Header, MaxKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abort(c, http.StatusBadRequest, "Failed to read request body: "+err.Error())
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID, _ := c.Get("user_id")
+		key = fmt.Sprintf("%v:%s", userID, key)
+		fingerprint := Fingerprint([]byte(c.Request.Method), []byte(c.Request.URL.RequestURI()), body)
+
+		// Store the outcome even if the client hangs up, since the change
+		// has been made by then
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		held, err := store.Begin(ctx, key, fingerprint, time.Now().Add(claimTimeout))
+		if err != nil {
+			log.Printf("Failed to claim idempotency key: %v", err)
+			abort(c, http.StatusServiceUnavailable, "Idempotency keys are unavailable")
+			return
+		}
+		if held != nil {
+			switch {
+			case held.Fingerprint != fingerprint:
+				abort(c, http.StatusConflict, Header+" was already used for a different request")
+			case !held.Completed():
+				abort(c, http.StatusConflict, "A request with this "+Header+" is already being processed")
+			default:
+				c.Header(ReplayedHeader, "true")
+				c.Data(held.Status, held.ContentType, held.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		// Give the key up if the handler panics
+		stored := false
+		defer func() {
+			if !stored {
+				if err := store.Release(ctx, key); err != nil {
+					log.Printf("Failed to release idempotency key: %v", err)
+				}
+			}
+		}()
+
+		writer := &recorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+		err = store.Complete(ctx, key, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes(), time.Now().Add(ttl))
+		if err != nil {
+			log.Printf("Failed to store response for idempotency key: %v", err)
+			return
+		}
+		stored = true
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func abort(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"message": message,
+		"success": false,
+	})
+}
+
+// recorder keeps a copy of the response body as it is written.
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
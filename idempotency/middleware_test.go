@@ -0,0 +1,111 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter(store Store, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set("user_id", user)
+		}
+		c.Next()
+	})
+	r.Use(Middleware(store, time.Hour))
+	r.POST("/stock/:item_id", func(c *gin.Context) {
+		*calls++
+		if c.Param("item_id") == "broken" {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"success": true, "calls": *calls})
+	})
+	return r
+}
+
+func send(r http.Handler, path, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("X-User", user)
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareReplaysRetries(t *testing.T) {
+	var calls int
+	r := newTestRouter(NewMemoryStore(), &calls)
+
+	first := send(r, "/stock/lamp", "1", "scan-1", `{"new_stock":5}`)
+	retry := send(r, "/stock/lamp", "1", "scan-1", `{"new_stock":5}`)
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry = %d %s, want %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if retry.Header().Get(ReplayedHeader) != "true" || first.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("%s not set on the replay only", ReplayedHeader)
+	}
+
+	// Keys are scoped to the user, and requests without one are not stored
+	send(r, "/stock/lamp", "2", "scan-1", `{"new_stock":5}`)
+	send(r, "/stock/lamp", "1", "", `{"new_stock":5}`)
+	send(r, "/stock/lamp", "1", "", `{"new_stock":5}`)
+	if calls != 4 {
+		t.Errorf("handler called %d times, want 4", calls)
+	}
+}
+
+func TestMiddlewareRefusesKeyReusedForDifferentRequest(t *testing.T) {
+	var calls int
+	r := newTestRouter(NewMemoryStore(), &calls)
+
+	send(r, "/stock/lamp", "1", "scan-1", `{"new_stock":5}`)
+	if w := send(r, "/stock/lamp", "1", "scan-1", `{"new_stock":6}`); w.Code != http.StatusConflict {
+		t.Errorf("different body: status = %d, want 409", w.Code)
+	}
+	if w := send(r, "/stock/desk", "1", "scan-1", `{"new_stock":5}`); w.Code != http.StatusConflict {
+		t.Errorf("different path: status = %d, want 409", w.Code)
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestMiddlewareRefusesKeyInUse(t *testing.T) {
+	var calls int
+	store := NewMemoryStore()
+	r := newTestRouter(store, &calls)
+
+	fingerprint := Fingerprint([]byte(http.MethodPost), []byte("/stock/lamp"), []byte(`{}`))
+	if _, err := store.Begin(context.Background(), "1:scan-1", fingerprint, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if w := send(r, "/stock/lamp", "1", "scan-1", `{}`); w.Code != http.StatusConflict || calls != 0 {
+		t.Errorf("key in use: status = %d after %d calls, want 409 without calling the handler", w.Code, calls)
+	}
+}
+
+func TestMiddlewareLetsServerErrorsBeRetried(t *testing.T) {
+	var calls int
+	r := newTestRouter(NewMemoryStore(), &calls)
+
+	send(r, "/stock/broken", "1", "scan-1", `{}`)
+	send(r, "/stock/broken", "1", "scan-1", `{}`)
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
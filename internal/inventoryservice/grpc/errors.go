@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"rancher-manager/internal/inventoryservice/model"
+	"rancher-manager/internal/inventoryservice/repository"
+)
+
+// statusCodes maps service errors to gRPC codes, checked in order with
+// errors.Is. Errors matching none are server faults.
+var statusCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{model.ErrUnauthorized, codes.Unauthenticated},
+	{repository.ErrSupervisorRequired, codes.PermissionDenied},
+	{model.ErrInvalidRequest, codes.InvalidArgument},
+
+	{repository.ErrInventoryNotFound, codes.NotFound},
+	{repository.ErrReservationNotFound, codes.NotFound},
+	{repository.ErrLotNotFound, codes.NotFound},
+	{model.ErrItemNotFound, codes.NotFound},
+	{gorm.ErrRecordNotFound, codes.NotFound},
+
+	{repository.ErrInsufficientStock, codes.FailedPrecondition},
+	{repository.ErrLotsExpired, codes.FailedPrecondition},
+	{repository.ErrLotCommitted, codes.FailedPrecondition},
+	{repository.ErrLotBlocked, codes.FailedPrecondition},
+	{repository.ErrLotDatesDiffer, codes.FailedPrecondition},
+	{repository.ErrAboveMaxStock, codes.FailedPrecondition},
+	{repository.ErrStockInTransit, codes.FailedPrecondition},
+	{repository.ErrIdempotencyKeyReused, codes.FailedPrecondition},
+	{model.ErrItemArchived, codes.FailedPrecondition},
+
+	{model.ErrItemSync, codes.Unavailable},
+}
+
+// statusError converts a service error into a gRPC status error.
+func statusError(err error) error {
+	for _, mapping := range statusCodes {
+		if errors.Is(err, mapping.err) {
+			return status.Error(mapping.code, err.Error())
+		}
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,43 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"rancher-manager/internal/inventoryservice/model"
+	"rancher-manager/internal/inventoryservice/repository"
+)
+
+func TestStatusErrorMapsSentinels(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{model.ErrUnauthorized, codes.Unauthenticated},
+		{model.InvalidRequest("quantity must not be zero"), codes.InvalidArgument},
+		{repository.ErrInventoryNotFound, codes.NotFound},
+		{repository.ErrReservationNotFound, codes.NotFound},
+		{fmt.Errorf("lookup: %w", gorm.ErrRecordNotFound), codes.NotFound},
+		{fmt.Errorf("%w: cannot remove 3", repository.ErrInsufficientStock), codes.FailedPrecondition},
+		{repository.ErrLotsExpired, codes.FailedPrecondition},
+		{model.ErrItemArchived, codes.FailedPrecondition},
+		{model.Errorf(model.ErrItemSync, "failed to update stock in item service: timeout"), codes.Unavailable},
+		// Server faults are not the client's mistake, whatever they say
+		{errors.New("dial tcp: connection refused"), codes.Internal},
+		{errors.New("item not found in cache"), codes.Internal},
+	}
+	for _, test := range tests {
+		err := statusError(test.err)
+		if got := status.Code(err); got != test.want {
+			t.Errorf("statusError(%q) = %s, want %s", test.err, got, test.want)
+		}
+		if message := status.Convert(err).Message(); message != test.err.Error() {
+			t.Errorf("message = %q, want %q", message, test.err.Error())
+		}
+	}
+}
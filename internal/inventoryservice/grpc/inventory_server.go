@@ -0,0 +1,287 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "rancher-manager/api/proto/inventoryservice"
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+type InventoryServiceInterface interface {
+	GetStock(itemID string, userID uint32) (*model.Inventory, error)
+	BatchGetStock(itemIDs []string, userID uint32) ([]*model.Inventory, []string, error)
+	AdjustStock(itemID string, delta int, req *model.AdjustStockRequest, userID uint32) (*model.Inventory, error)
+	UpdateStock(itemID string, req *model.UpdateStockRequest, userID uint32) (*model.Inventory, error)
+	CreateReservation(req *model.CreateReservationRequest, userID uint32) (*model.Reservation, bool, error)
+	GetAlerts(userID uint32) ([]*model.StockAlert, error)
+}
+
+// maxBatchGetStockIDs is the most IDs BatchGetStock accepts.
+const maxBatchGetStockIDs = 100
+
+type InventoryGRPCServer struct {
+	pb.UnimplementedInventoryServiceServer
+	inventoryService InventoryServiceInterface
+}
+
+func NewInventoryGRPCServer(inventoryService InventoryServiceInterface) *InventoryGRPCServer {
+	return &InventoryGRPCServer{
+		inventoryService: inventoryService,
+	}
+}
+
+// authInterceptor extracts user_id from metadata and adds it to context
+func authInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "metadata not found")
+	}
+
+	userIDs := md.Get("user_id")
+	if len(userIDs) == 0 {
+		return nil, status.Errorf(codes.Unauthenticated, "user_id not found in metadata")
+	}
+
+	var userID uint32
+	if _, err := fmt.Sscanf(userIDs[0], "%d", &userID); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user_id format")
+	}
+
+	return handler(context.WithValue(ctx, "user_id", userID), req)
+}
+
+func contextUserID(ctx context.Context) (uint32, error) {
+	userID, ok := ctx.Value("user_id").(uint32)
+	if !ok {
+		return 0, status.Error(codes.Unauthenticated, "user not authenticated")
+	}
+	return userID, nil
+}
+
+func (s *InventoryGRPCServer) GetStock(ctx context.Context, req *pb.GetStockRequest) (*pb.Stock, error) {
+	userID, err := contextUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory, err := s.inventoryService.GetStock(req.ItemId, userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoStock(inventory), nil
+}
+
+func (s *InventoryGRPCServer) BatchGetStock(ctx context.Context, req *pb.BatchGetStockRequest) (*pb.BatchGetStockResponse, error) {
+	userID, err := contextUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.ItemIds) > maxBatchGetStockIDs {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d item_ids may be requested at once", maxBatchGetStockIDs)
+	}
+
+	inventories, missing, err := s.inventoryService.BatchGetStock(req.ItemIds, userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	response := &pb.BatchGetStockResponse{MissingIds: missing}
+	for _, inventory := range inventories {
+		response.Stocks = append(response.Stocks, toProtoStock(inventory))
+	}
+	return response, nil
+}
+
+func (s *InventoryGRPCServer) IncrementStock(ctx context.Context, req *pb.AdjustStockRequest) (*pb.Stock, error) {
+	return s.adjustStock(ctx, req, 1)
+}
+
+func (s *InventoryGRPCServer) DecrementStock(ctx context.Context, req *pb.AdjustStockRequest) (*pb.Stock, error) {
+	return s.adjustStock(ctx, req, -1)
+}
+
+func (s *InventoryGRPCServer) AdjustStock(ctx context.Context, req *pb.AdjustStockRequest) (*pb.Stock, error) {
+	if req.Quantity == 0 {
+		return nil, status.Error(codes.InvalidArgument, "quantity must not be zero")
+	}
+
+	// The sign moves from the quantity to the direction
+	adjustment := proto.Clone(req).(*pb.AdjustStockRequest)
+	sign := 1
+	if req.Quantity < 0 {
+		adjustment.Quantity = -req.Quantity
+		sign = -1
+	}
+	return s.adjustStock(ctx, adjustment, sign)
+}
+
+func (s *InventoryGRPCServer) adjustStock(ctx context.Context, req *pb.AdjustStockRequest, sign int) (*pb.Stock, error) {
+	userID, err := contextUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ItemId == "" {
+		return nil, status.Error(codes.InvalidArgument, "item_id is required")
+	}
+	if req.Quantity <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "quantity must be positive")
+	}
+
+	adjustment := &model.AdjustStockRequest{
+		Quantity:  int(req.Quantity),
+		Type:      model.MovementType(req.Type),
+		Reason:    req.Reason,
+		Reference: req.Reference,
+		LotNumber: req.LotNumber,
+	}
+	if req.LocationId != 0 {
+		locationID := uint(req.LocationId)
+		adjustment.LocationID = &locationID
+	}
+
+	inventory, err := s.inventoryService.AdjustStock(req.ItemId, sign*int(req.Quantity), adjustment, userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoStock(inventory), nil
+}
+
+func (s *InventoryGRPCServer) SetStock(ctx context.Context, req *pb.SetStockRequest) (*pb.Stock, error) {
+	userID, err := contextUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ItemId == "" {
+		return nil, status.Error(codes.InvalidArgument, "item_id is required")
+	}
+	if req.NewStock < 0 {
+		return nil, status.Error(codes.InvalidArgument, "new_stock must not be negative")
+	}
+
+	inventory, err := s.inventoryService.UpdateStock(req.ItemId, &model.UpdateStockRequest{
+		NewStock:  int(req.NewStock),
+		Type:      model.MovementType(req.Type),
+		Reason:    req.Reason,
+		Reference: req.Reference,
+	}, userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoStock(inventory), nil
+}
+
+func (s *InventoryGRPCServer) Reserve(ctx context.Context, req *pb.ReserveRequest) (*pb.Reservation, error) {
+	userID, err := contextUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case req.ItemId == "":
+		return nil, status.Error(codes.InvalidArgument, "item_id is required")
+	case req.Quantity <= 0:
+		return nil, status.Error(codes.InvalidArgument, "quantity must be positive")
+	case req.TtlSeconds < 0:
+		return nil, status.Error(codes.InvalidArgument, "ttl_seconds must not be negative")
+	case len(req.IdempotencyKey) > 128:
+		return nil, status.Error(codes.InvalidArgument, "idempotency_key must be at most 128 characters")
+	}
+
+	reservation, _, err := s.inventoryService.CreateReservation(&model.CreateReservationRequest{
+		ItemID:         req.ItemId,
+		Quantity:       int(req.Quantity),
+		TTLSeconds:     int(req.TtlSeconds),
+		IdempotencyKey: req.IdempotencyKey,
+		Reference:      req.Reference,
+	}, userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoReservation(reservation), nil
+}
+
+func (s *InventoryGRPCServer) ListLowStock(ctx context.Context, req *pb.ListLowStockRequest) (*pb.ListLowStockResponse, error) {
+	userID, err := contextUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := s.inventoryService.GetAlerts(userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	response := &pb.ListLowStockResponse{}
+	for _, alert := range alerts {
+		if alert.Level == model.AlertLow || req.IncludeReorder {
+			response.Alerts = append(response.Alerts, toProtoAlert(alert))
+		}
+	}
+	return response, nil
+}
+
+func toProtoStock(inventory *model.Inventory) *pb.Stock {
+	return &pb.Stock{
+		ItemId:    inventory.ItemID,
+		Stock:     int32(inventory.Stock),
+		MinStock:  int32(inventory.MinStock),
+		MaxStock:  int32(inventory.MaxStock),
+		UpdatedBy: inventory.UpdatedBy,
+		UpdatedAt: timestamppb.New(inventory.UpdatedAt),
+		Reserved:  int32(inventory.Reserved),
+		InTransit: int32(inventory.InTransit),
+		Held:      int32(inventory.Held),
+		Available: int32(inventory.Available),
+	}
+}
+
+func toProtoReservation(reservation *model.Reservation) *pb.Reservation {
+	return &pb.Reservation{
+		Id:             uint32(reservation.ID),
+		ItemId:         reservation.ItemID,
+		Quantity:       int32(reservation.Quantity),
+		Status:         string(reservation.Status),
+		IdempotencyKey: reservation.IdempotencyKey,
+		Reference:      reservation.Reference,
+		ExpiresAt:      timestamppb.New(reservation.ExpiresAt),
+		CreatedAt:      timestamppb.New(reservation.CreatedAt),
+	}
+}
+
+func toProtoAlert(alert *model.StockAlert) *pb.StockAlert {
+	return &pb.StockAlert{
+		ItemId:                 alert.ItemID,
+		Level:                  string(alert.Level),
+		Stock:                  int32(alert.Stock),
+		Available:              int32(alert.Available),
+		MinStock:               int32(alert.MinStock),
+		MaxStock:               int32(alert.MaxStock),
+		ReorderPoint:           int32(alert.ReorderPoint),
+		SuggestedOrderQuantity: int32(alert.SuggestedOrderQuantity),
+	}
+}
+
+func StartGRPCServer(inventoryService InventoryServiceInterface, port string) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %v", err)
+	}
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(authInterceptor))
+	pb.RegisterInventoryServiceServer(grpcServer, NewInventoryGRPCServer(inventoryService))
+
+	fmt.Printf("gRPC Inventory Server listening on port %s\n", port)
+	return grpcServer.Serve(lis)
+}
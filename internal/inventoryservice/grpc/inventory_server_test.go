@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "rancher-manager/api/proto/inventoryservice"
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// fakeInventoryService records the adjustments it is asked for.
+type fakeInventoryService struct {
+	InventoryServiceInterface
+	deltas []int
+	alerts []*model.StockAlert
+}
+
+func (f *fakeInventoryService) AdjustStock(itemID string, delta int, req *model.AdjustStockRequest, userID uint32) (*model.Inventory, error) {
+	f.deltas = append(f.deltas, delta)
+	return &model.Inventory{ItemID: itemID, Stock: 10 + delta}, nil
+}
+
+func (f *fakeInventoryService) GetAlerts(userID uint32) ([]*model.StockAlert, error) {
+	return f.alerts, nil
+}
+
+func authenticated() context.Context {
+	return context.WithValue(context.Background(), "user_id", uint32(1))
+}
+
+func TestAdjustStockTakesDirectionFromSign(t *testing.T) {
+	service := &fakeInventoryService{}
+	server := NewInventoryGRPCServer(service)
+
+	for _, quantity := range []int32{3, -2} {
+		if _, err := server.AdjustStock(authenticated(), &pb.AdjustStockRequest{ItemId: "lamp", Quantity: quantity}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(service.deltas) != 2 || service.deltas[0] != 3 || service.deltas[1] != -2 {
+		t.Errorf("deltas = %v, want [3 -2]", service.deltas)
+	}
+
+	_, err := server.AdjustStock(authenticated(), &pb.AdjustStockRequest{ItemId: "lamp"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("zero quantity: err = %v, want InvalidArgument", err)
+	}
+	// Decrementing by a negative quantity would be an increment
+	_, err = server.DecrementStock(authenticated(), &pb.AdjustStockRequest{ItemId: "lamp", Quantity: -2})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("negative decrement: err = %v, want InvalidArgument", err)
+	}
+}
+
+func TestListLowStockLeavesOutReorderAlerts(t *testing.T) {
+	service := &fakeInventoryService{alerts: []*model.StockAlert{
+		{ItemID: "lamp", Level: model.AlertLow, Available: 1, MinStock: 5},
+		{ItemID: "desk", Level: model.AlertReorder, Available: 8, MinStock: 5},
+	}}
+	server := NewInventoryGRPCServer(service)
+
+	resp, err := server.ListLowStock(authenticated(), &pb.ListLowStockRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Alerts) != 1 || resp.Alerts[0].ItemId != "lamp" {
+		t.Errorf("alerts = %v, want lamp only", resp.Alerts)
+	}
+
+	resp, err = server.ListLowStock(authenticated(), &pb.ListLowStockRequest{IncludeReorder: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Alerts) != 2 {
+		t.Errorf("alerts with reorder = %v, want both", resp.Alerts)
+	}
+
+	if _, err := server.ListLowStock(context.Background(), &pb.ListLowStockRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("without user: err = %v, want Unauthenticated", err)
+	}
+}
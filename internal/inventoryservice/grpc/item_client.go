@@ -68,3 +68,47 @@ func (c *ItemClient) DeleteItem(itemID string, userID uint32) (*pb.DeleteItemRes
 
 	return c.client.DeleteItem(ctx, req)
 }
+
+// batchGetItemsLimit is the most IDs ItemService accepts per BatchGetItems
+// call.
+const batchGetItemsLimit = 100
+
+// BatchGetItems fetches the given items, splitting the IDs into calls of at
+// most batchGetItemsLimit. IDs ItemService does not know are returned as
+// missing.
+func (c *ItemClient) BatchGetItems(itemIDs []string, userID uint32) (items []*pb.Item, missingIDs []string, err error) {
+	ctx := context.Background()
+	md := metadata.New(map[string]string{
+		"user_id": strconv.FormatUint(uint64(userID), 10),
+	})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	for start := 0; start < len(itemIDs); start += batchGetItemsLimit {
+		end := min(start+batchGetItemsLimit, len(itemIDs))
+		resp, err := c.client.BatchGetItems(ctx, &pb.BatchGetItemsRequest{
+			ItemIds: itemIDs[start:end],
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, resp.Items...)
+		missingIDs = append(missingIDs, resp.MissingIds...)
+	}
+	return items, missingIDs, nil
+}
+
+// ListItems lists a page of items of every status, in ID order. pageToken
+// is the next_page_token of the previous page, empty for the first.
+func (c *ItemClient) ListItems(pageToken string, pageSize int32, userID uint32) (*pb.ListItemsResponse, error) {
+	ctx := context.Background()
+	md := metadata.New(map[string]string{
+		"user_id": strconv.FormatUint(uint64(userID), 10),
+	})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	return c.client.ListItems(ctx, &pb.ListItemsRequest{
+		PageSize:  pageSize,
+		PageToken: pageToken,
+		Statuses:  []string{"all"},
+	})
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// CreateCount godoc
+// @Summary Start a stock count
+// @Description Start a count session with a count sheet of the given items and of everything stocked at the given locations, snapshotting their stock
+// @Tags counts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param count body model.CreateCountRequest true "Items and locations to count"
+// @Success 201 {object} model.CountResponse
+// @Failure 400 {object} model.CountResponse
+// @Failure 401 {object} model.CountResponse
+// @Failure 404 {object} model.CountResponse
+// @Router /inventory/counts [post]
+func (h *InventoryHandler) CreateCount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.CountResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.CreateCountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.CountResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	session, err := h.inventoryService.CreateCount(&req, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.CountResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.CountResponse{
+		Message: "Count started successfully",
+		Success: true,
+		Data:    session,
+	})
+}
+
+// GetCounts godoc
+// @Summary List stock counts
+// @Description List count sessions without their lines, newest first
+// @Tags counts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Only counts in this status (open, closed)"
+// @Success 200 {object} model.CountsResponse
+// @Failure 401 {object} model.CountsResponse
+// @Router /inventory/counts [get]
+func (h *InventoryHandler) GetCounts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.CountsResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	sessions, err := h.inventoryService.GetCounts(model.CountStatus(c.Query("status")), userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.CountsResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CountsResponse{
+		Message: "Counts retrieved successfully",
+		Success: true,
+		Data:    sessions,
+	})
+}
+
+// GetCount godoc
+// @Summary Get stock count
+// @Description Get a count session with its count sheet, counted quantities and variances
+// @Tags counts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Count ID"
+// @Success 200 {object} model.CountResponse
+// @Failure 400 {object} model.CountResponse
+// @Failure 401 {object} model.CountResponse
+// @Failure 404 {object} model.CountResponse
+// @Router /inventory/counts/{id} [get]
+func (h *InventoryHandler) GetCount(c *gin.Context) {
+	h.countAction(c, func(id uint, userID uint32) (*model.CountSession, error) {
+		return h.inventoryService.GetCount(id, userID)
+	}, "Count retrieved successfully")
+}
+
+// SubmitCount godoc
+// @Summary Submit counted quantities
+// @Description Record counted quantities for lines of an open count. Lines may be counted again until they are approved.
+// @Tags counts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Count ID"
+// @Param counts body model.SubmitCountRequest true "Counted quantities"
+// @Success 200 {object} model.CountResponse
+// @Failure 400 {object} model.CountResponse
+// @Failure 401 {object} model.CountResponse
+// @Failure 404 {object} model.CountResponse
+// @Failure 409 {object} model.CountResponse
+// @Router /inventory/counts/{id}/submit [post]
+func (h *InventoryHandler) SubmitCount(c *gin.Context) {
+	var req model.SubmitCountRequest
+	if !h.bindCountRequest(c, &req) {
+		return
+	}
+	h.countAction(c, func(id uint, userID uint32) (*model.CountSession, error) {
+		return h.inventoryService.SubmitCount(id, &req, userID)
+	}, "Counts submitted successfully")
+}
+
+// ApproveCount godoc
+// @Summary Approve count variances
+// @Description Post the variances of counted lines, all of them unless line_ids are given, as count adjustments with a reason code. Variances above the count's limit need a supervisor.
+// @Tags counts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Count ID"
+// @Param approval body model.ApproveCountRequest true "Lines and reason code"
+// @Success 200 {object} model.CountResponse
+// @Failure 400 {object} model.CountResponse
+// @Failure 401 {object} model.CountResponse
+// @Failure 403 {object} model.CountResponse
+// @Failure 404 {object} model.CountResponse
+// @Failure 409 {object} model.CountResponse
+// @Router /inventory/counts/{id}/approve [post]
+func (h *InventoryHandler) ApproveCount(c *gin.Context) {
+	var req model.ApproveCountRequest
+	if !h.bindCountRequest(c, &req) {
+		return
+	}
+	h.countAction(c, func(id uint, userID uint32) (*model.CountSession, error) {
+		return h.inventoryService.ApproveCount(id, &req, userID)
+	}, "Count approved successfully")
+}
+
+// CloseCount godoc
+// @Summary Close stock count
+// @Description End a count session; lines not approved by then are never posted
+// @Tags counts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Count ID"
+// @Success 200 {object} model.CountResponse
+// @Failure 400 {object} model.CountResponse
+// @Failure 401 {object} model.CountResponse
+// @Failure 404 {object} model.CountResponse
+// @Failure 409 {object} model.CountResponse
+// @Router /inventory/counts/{id}/close [post]
+func (h *InventoryHandler) CloseCount(c *gin.Context) {
+	h.countAction(c, func(id uint, userID uint32) (*model.CountSession, error) {
+		return h.inventoryService.CloseCount(id, userID)
+	}, "Count closed successfully")
+}
+
+// bindCountRequest binds the JSON body of a count action, answering with
+// 400 if it is invalid.
+func (h *InventoryHandler) bindCountRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, model.CountResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return false
+	}
+	return true
+}
+
+// countAction applies action to the count in the path.
+func (h *InventoryHandler) countAction(c *gin.Context, action func(id uint, userID uint32) (*model.CountSession, error), message string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.CountResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	id, ok := idParam(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.CountResponse{
+			Message: "Invalid count ID",
+			Success: false,
+		})
+		return
+	}
+
+	session, err := action(id, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.CountResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CountResponse{
+		Message: message,
+		Success: true,
+		Data:    session,
+	})
+}
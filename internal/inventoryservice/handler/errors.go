@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errorStatus maps a service error to an HTTP status by its message.
+func errorStatus(err error) int {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "unauthorized"):
+		return http.StatusUnauthorized
+	case strings.Contains(message, "forbidden"):
+		return http.StatusForbidden
+	case strings.Contains(message, "not found"):
+		return http.StatusNotFound
+	case strings.Contains(message, "insufficient stock"),
+		strings.Contains(message, "exceed the maximum"),
+		strings.Contains(message, "expired"),
+		strings.Contains(message, "already"),
+		strings.Contains(message, "blocked"),
+		strings.Contains(message, "not ready"):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// idParam parses the numeric ID in the path.
+func idParam(value string) (uint, bool) {
+	id, err := strconv.ParseUint(value, 10, 32)
+	return uint(id), err == nil
+}
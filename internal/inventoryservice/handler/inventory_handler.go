@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -65,13 +67,15 @@ func (h *InventoryHandler) UpdateStock(c *gin.Context) {
 		return
 	}
 
-	inventory, err := h.inventoryService.UpdateStock(itemID, req.NewStock, userID.(uint32))
+	inventory, err := h.inventoryService.UpdateStock(itemID, &req, userID.(uint32))
 	if err != nil {
 		status := http.StatusBadRequest
 		if strings.Contains(err.Error(), "unauthorized") {
 			status = http.StatusUnauthorized
 		} else if strings.Contains(err.Error(), "not found") {
 			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "exceed the maximum") {
+			status = http.StatusConflict
 		}
 		c.JSON(status, model.StockResponse{
 			Message: err.Error(),
@@ -81,9 +85,103 @@ func (h *InventoryHandler) UpdateStock(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, model.StockResponse{
-		Message: "Stock updated successfully",
-		Success: true,
-		Data:    inventory,
+		Message:  "Stock updated successfully",
+		Success:  true,
+		Data:     inventory,
+		Warnings: inventory.Warnings(),
+	})
+}
+
+// IncrementStock godoc
+// @Summary Increase item stock
+// @Description Add to the stock of a specific item, e.g. for a receipt. Concurrent adjustments are all applied.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item_id path string true "Item ID"
+// @Param stock body model.AdjustStockRequest true "Quantity to add"
+// @Success 200 {object} model.StockResponse
+// @Failure 400 {object} model.StockResponse
+// @Failure 401 {object} model.StockResponse
+// @Failure 404 {object} model.StockResponse
+// @Router /inventory/stock/{item_id}/increment [post]
+func (h *InventoryHandler) IncrementStock(c *gin.Context) {
+	h.adjustStock(c, 1)
+}
+
+// DecrementStock godoc
+// @Summary Decrease item stock
+// @Description Remove from the stock of a specific item, e.g. for a sale. Fails with 409 rather than taking stock below zero.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item_id path string true "Item ID"
+// @Param stock body model.AdjustStockRequest true "Quantity to remove"
+// @Success 200 {object} model.StockResponse
+// @Failure 400 {object} model.StockResponse
+// @Failure 401 {object} model.StockResponse
+// @Failure 404 {object} model.StockResponse
+// @Failure 409 {object} model.StockResponse
+// @Router /inventory/stock/{item_id}/decrement [post]
+func (h *InventoryHandler) DecrementStock(c *gin.Context) {
+	h.adjustStock(c, -1)
+}
+
+// adjustStock changes stock by the requested quantity in the direction of
+// sign.
+func (h *InventoryHandler) adjustStock(c *gin.Context, sign int) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.StockResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	itemID := c.Param("item_id")
+	if itemID == "" {
+		c.JSON(http.StatusBadRequest, model.StockResponse{
+			Message: "Item ID is required",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.AdjustStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.StockResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	inventory, err := h.inventoryService.AdjustStock(itemID, sign*req.Quantity, &req, userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "insufficient stock") ||
+			strings.Contains(err.Error(), "exceed the maximum") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, model.StockResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.StockResponse{
+		Message:  "Stock adjusted successfully",
+		Success:  true,
+		Data:     inventory,
+		Warnings: inventory.Warnings(),
 	})
 }
 
@@ -188,12 +286,210 @@ func (h *InventoryHandler) GetStock(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, model.StockResponse{
-		Message: "Stock retrieved successfully",
+		Message:  "Stock retrieved successfully",
+		Success:  true,
+		Data:     inventory,
+		Warnings: inventory.Warnings(),
+	})
+}
+
+// UpdateThresholds godoc
+// @Summary Update stock thresholds
+// @Description Set the minimum and maximum stock and replenishment lead time of a specific item. Omitted fields are left unchanged.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item_id path string true "Item ID"
+// @Param thresholds body model.UpdateThresholdsRequest true "Thresholds"
+// @Success 200 {object} model.StockResponse
+// @Failure 400 {object} model.StockResponse
+// @Failure 401 {object} model.StockResponse
+// @Failure 404 {object} model.StockResponse
+// @Router /inventory/stock/{item_id}/thresholds [put]
+func (h *InventoryHandler) UpdateThresholds(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.StockResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	itemID := c.Param("item_id")
+	if itemID == "" {
+		c.JSON(http.StatusBadRequest, model.StockResponse{
+			Message: "Item ID is required",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.UpdateThresholdsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.StockResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	inventory, err := h.inventoryService.UpdateThresholds(itemID, &req, userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.StockResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.StockResponse{
+		Message:  "Thresholds updated successfully",
+		Success:  true,
+		Data:     inventory,
+		Warnings: inventory.Warnings(),
+	})
+}
+
+// GetAlerts godoc
+// @Summary Get stock alerts
+// @Description List items whose available stock is below their minimum (level low) or at their reorder point (level reorder), with reorder suggestions based on the last 30 days of consumption
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.AlertsResponse
+// @Failure 401 {object} model.AlertsResponse
+// @Router /inventory/alerts [get]
+func (h *InventoryHandler) GetAlerts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.AlertsResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	alerts, err := h.inventoryService.GetAlerts(userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, model.AlertsResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.AlertsResponse{
+		Message: "Stock alerts retrieved successfully",
 		Success: true,
-		Data:    inventory,
+		Data:    alerts,
 	})
 }
 
+// GetMovements godoc
+// @Summary Get stock movements
+// @Description Get the stock ledger of a specific item, oldest first. from and to accept RFC 3339 timestamps or dates; a date as to includes that whole day.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item_id path string true "Item ID"
+// @Param from query string false "Earliest movement time"
+// @Param to query string false "Latest movement time"
+// @Param type query string false "Movement type"
+// @Success 200 {object} model.MovementsResponse
+// @Failure 400 {object} model.MovementsResponse
+// @Failure 401 {object} model.MovementsResponse
+// @Failure 404 {object} model.MovementsResponse
+// @Router /inventory/stock/{item_id}/movements [get]
+func (h *InventoryHandler) GetMovements(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.MovementsResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	itemID := c.Param("item_id")
+	if itemID == "" {
+		c.JSON(http.StatusBadRequest, model.MovementsResponse{
+			Message: "Item ID is required",
+			Success: false,
+		})
+		return
+	}
+
+	filter := model.MovementFilter{Type: model.MovementType(c.Query("type"))}
+	var err error
+	if filter.From, err = parseTimeQuery(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, model.MovementsResponse{
+			Message: "Invalid from: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+	if filter.To, err = parseTimeQuery(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, model.MovementsResponse{
+			Message: "Invalid to: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	resp, err := h.inventoryService.GetMovements(itemID, filter, userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.MovementsResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	resp.Message = "Stock movements retrieved successfully"
+	resp.Success = true
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseTimeQuery parses an RFC 3339 timestamp or a YYYY-MM-DD date. When
+// endOfRange is set, a date is taken to mean the end of that day, so that it
+// can be used as an exclusive upper bound.
+func parseTimeQuery(value string, endOfRange bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, errors.New("expected an RFC 3339 timestamp or a YYYY-MM-DD date")
+	}
+	if endOfRange {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
 // GetAllItems godoc
 // @Summary Get all inventory items
 // @Description Get all inventory items with stock information
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeQuery(t *testing.T) {
+	tests := []struct {
+		value      string
+		endOfRange bool
+		want       time.Time
+	}{
+		{"", false, time.Time{}},
+		{"2024-01-31", false, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		// A date as the end of a range covers that whole day
+		{"2024-01-31", true, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-31T12:30:00Z", true, time.Date(2024, 1, 31, 12, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeQuery(tt.value, tt.endOfRange)
+		if err != nil {
+			t.Errorf("parseTimeQuery(%q, %t): %v", tt.value, tt.endOfRange, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTimeQuery(%q, %t) = %s, want %s", tt.value, tt.endOfRange, got, tt.want)
+		}
+	}
+
+	if _, err := parseTimeQuery("31/01/2024", false); err == nil {
+		t.Error("parseTimeQuery accepted 31/01/2024")
+	}
+}
@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// CreateLocation godoc
+// @Summary Create location
+// @Description Register a warehouse, a zone of a warehouse or a bin within a zone
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param location body model.CreateLocationRequest true "Location data"
+// @Success 201 {object} model.LocationResponse
+// @Failure 400 {object} model.LocationResponse
+// @Failure 401 {object} model.LocationResponse
+// @Failure 409 {object} model.LocationResponse
+// @Router /inventory/locations [post]
+func (h *InventoryHandler) CreateLocation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.LocationResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.CreateLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.LocationResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	location, err := h.inventoryService.CreateLocation(&req, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.LocationResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.LocationResponse{
+		Message: "Location created successfully",
+		Success: true,
+		Data:    location,
+	})
+}
+
+// GetLocations godoc
+// @Summary List locations
+// @Description List locations ordered by code
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param warehouse query string false "Only locations of this warehouse"
+// @Success 200 {object} model.LocationsResponse
+// @Failure 401 {object} model.LocationsResponse
+// @Router /inventory/locations [get]
+func (h *InventoryHandler) GetLocations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.LocationsResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	locations, err := h.inventoryService.GetLocations(c.Query("warehouse"), userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.LocationsResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.LocationsResponse{
+		Message: "Locations retrieved successfully",
+		Success: true,
+		Data:    locations,
+	})
+}
+
+// GetItemLocations godoc
+// @Summary Get item stock by location
+// @Description Get an item's total stock and where it is kept, including stock in transit and stock not assigned to a location
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item_id path string true "Item ID"
+// @Success 200 {object} model.ItemLocationsResponse
+// @Failure 401 {object} model.ItemLocationsResponse
+// @Failure 404 {object} model.ItemLocationsResponse
+// @Router /inventory/stock/{item_id}/locations [get]
+func (h *InventoryHandler) GetItemLocations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ItemLocationsResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	locations, err := h.inventoryService.GetItemLocations(c.Param("item_id"), userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.ItemLocationsResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ItemLocationsResponse{
+		Message: "Item locations retrieved successfully",
+		Success: true,
+		Data:    locations,
+	})
+}
+
+// CreateTransfer godoc
+// @Summary Ship a transfer
+// @Description Move stock from one location to another. It is taken from the source now and held in transit until the transfer is received.
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param transfer body model.CreateTransferRequest true "Transfer data"
+// @Success 201 {object} model.TransferResponse
+// @Failure 400 {object} model.TransferResponse
+// @Failure 401 {object} model.TransferResponse
+// @Failure 404 {object} model.TransferResponse
+// @Failure 409 {object} model.TransferResponse
+// @Router /inventory/transfers [post]
+func (h *InventoryHandler) CreateTransfer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.TransferResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.CreateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.TransferResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	transfer, err := h.inventoryService.CreateTransfer(&req, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.TransferResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.TransferResponse{
+		Message: "Transfer shipped successfully",
+		Success: true,
+		Data:    transfer,
+	})
+}
+
+// GetTransfers godoc
+// @Summary List transfers
+// @Description List transfers, newest first
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item_id query string false "Only transfers of this item"
+// @Param status query string false "Only transfers in this status (in_transit, received, cancelled)"
+// @Success 200 {object} model.TransfersResponse
+// @Failure 401 {object} model.TransfersResponse
+// @Router /inventory/transfers [get]
+func (h *InventoryHandler) GetTransfers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.TransfersResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	transfers, err := h.inventoryService.GetTransfers(c.Query("item_id"), model.TransferStatus(c.Query("status")), userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.TransfersResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.TransfersResponse{
+		Message: "Transfers retrieved successfully",
+		Success: true,
+		Data:    transfers,
+	})
+}
+
+// GetTransfer godoc
+// @Summary Get transfer
+// @Description Get a transfer between locations
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} model.TransferResponse
+// @Failure 400 {object} model.TransferResponse
+// @Failure 401 {object} model.TransferResponse
+// @Failure 404 {object} model.TransferResponse
+// @Router /inventory/transfers/{id} [get]
+func (h *InventoryHandler) GetTransfer(c *gin.Context) {
+	h.transferAction(c, h.inventoryService.GetTransfer, "Transfer retrieved successfully")
+}
+
+// ReceiveTransfer godoc
+// @Summary Receive transfer
+// @Description Put the stock of a transfer at its destination. Receiving twice has no further effect.
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} model.TransferResponse
+// @Failure 400 {object} model.TransferResponse
+// @Failure 401 {object} model.TransferResponse
+// @Failure 404 {object} model.TransferResponse
+// @Failure 409 {object} model.TransferResponse
+// @Router /inventory/transfers/{id}/receive [post]
+func (h *InventoryHandler) ReceiveTransfer(c *gin.Context) {
+	h.transferAction(c, h.inventoryService.ReceiveTransfer, "Transfer received successfully")
+}
+
+// CancelTransfer godoc
+// @Summary Cancel transfer
+// @Description Return the stock of a transfer in transit to its source. Cancelling twice has no further effect.
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} model.TransferResponse
+// @Failure 400 {object} model.TransferResponse
+// @Failure 401 {object} model.TransferResponse
+// @Failure 404 {object} model.TransferResponse
+// @Failure 409 {object} model.TransferResponse
+// @Router /inventory/transfers/{id}/cancel [post]
+func (h *InventoryHandler) CancelTransfer(c *gin.Context) {
+	h.transferAction(c, h.inventoryService.CancelTransfer, "Transfer cancelled successfully")
+}
+
+// transferAction applies action to the transfer in the path.
+func (h *InventoryHandler) transferAction(c *gin.Context, action func(id uint, userID uint32) (*model.Transfer, error), message string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.TransferResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	id, ok := idParam(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.TransferResponse{
+			Message: "Invalid transfer ID",
+			Success: false,
+		})
+		return
+	}
+
+	transfer, err := action(id, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.TransferResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.TransferResponse{
+		Message: message,
+		Success: true,
+		Data:    transfer,
+	})
+}
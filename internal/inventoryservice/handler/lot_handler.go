@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// defaultExpiryWindowDays is how far ahead the expiring lots report looks
+// unless told otherwise.
+const defaultExpiryWindowDays = 30
+
+// GetItemLots godoc
+// @Summary Get item lots
+// @Description List an item's lots in the order decrements take from them: first-expired-first-out, lots without an expiry date last
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item_id path string true "Item ID"
+// @Success 200 {object} model.LotsResponse
+// @Failure 401 {object} model.LotsResponse
+// @Router /inventory/stock/{item_id}/lots [get]
+func (h *InventoryHandler) GetItemLots(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.LotsResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	lots, err := h.inventoryService.GetLots(c.Param("item_id"), userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.LotsResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.LotsResponse{
+		Message: "Lots retrieved successfully",
+		Success: true,
+		Data:    lots,
+	})
+}
+
+// GetExpiringLots godoc
+// @Summary Get expiring lots
+// @Description List lots with stock that expire within the given number of days, including those already expired, soonest first
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Days ahead to look (default 30)"
+// @Success 200 {object} model.LotsResponse
+// @Failure 400 {object} model.LotsResponse
+// @Failure 401 {object} model.LotsResponse
+// @Router /inventory/lots/expiring [get]
+func (h *InventoryHandler) GetExpiringLots(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.LotsResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	days := defaultExpiryWindowDays
+	if value := c.Query("days"); value != "" {
+		var err error
+		if days, err = strconv.Atoi(value); err != nil || days < 0 {
+			c.JSON(http.StatusBadRequest, model.LotsResponse{
+				Message: "Invalid days: must be a non-negative number",
+				Success: false,
+			})
+			return
+		}
+	}
+
+	lots, err := h.inventoryService.GetExpiringLots(days, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.LotsResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.LotsResponse{
+		Message: "Expiring lots retrieved successfully",
+		Success: true,
+		Data:    lots,
+	})
+}
+
+// QuarantineLot godoc
+// @Summary Quarantine lot
+// @Description Hold a lot's stock back from sale pending inspection. Fails with 409 if the stock is reserved or in transit.
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Lot ID"
+// @Param hold body model.LotHoldRequest true "Reason for the hold"
+// @Success 200 {object} model.LotResponse
+// @Failure 400 {object} model.LotResponse
+// @Failure 401 {object} model.LotResponse
+// @Failure 404 {object} model.LotResponse
+// @Failure 409 {object} model.LotResponse
+// @Router /inventory/lots/{id}/quarantine [post]
+func (h *InventoryHandler) QuarantineLot(c *gin.Context) {
+	h.holdLot(c, h.inventoryService.QuarantineLot, "Lot quarantined successfully")
+}
+
+// BlockLot godoc
+// @Summary Block lot
+// @Description Hold a lot's stock back from sale for good; it can then only be written off with a decrement naming the lot
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Lot ID"
+// @Param hold body model.LotHoldRequest true "Reason for the block"
+// @Success 200 {object} model.LotResponse
+// @Failure 400 {object} model.LotResponse
+// @Failure 401 {object} model.LotResponse
+// @Failure 404 {object} model.LotResponse
+// @Failure 409 {object} model.LotResponse
+// @Router /inventory/lots/{id}/block [post]
+func (h *InventoryHandler) BlockLot(c *gin.Context) {
+	h.holdLot(c, h.inventoryService.BlockLot, "Lot blocked successfully")
+}
+
+// ReleaseLot godoc
+// @Summary Release lot
+// @Description Make a quarantined lot's stock available again. Blocked lots cannot be released.
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Lot ID"
+// @Success 200 {object} model.LotResponse
+// @Failure 400 {object} model.LotResponse
+// @Failure 401 {object} model.LotResponse
+// @Failure 404 {object} model.LotResponse
+// @Failure 409 {object} model.LotResponse
+// @Router /inventory/lots/{id}/release [post]
+func (h *InventoryHandler) ReleaseLot(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.LotResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	id, ok := idParam(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.LotResponse{
+			Message: "Invalid lot ID",
+			Success: false,
+		})
+		return
+	}
+
+	lot, err := h.inventoryService.ReleaseLot(id, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.LotResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.LotResponse{
+		Message: "Lot released successfully",
+		Success: true,
+		Data:    lot,
+	})
+}
+
+// holdLot applies hold to the lot in the path.
+func (h *InventoryHandler) holdLot(c *gin.Context, hold func(id uint, req *model.LotHoldRequest, userID uint32) (*model.Lot, error), message string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.LotResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	id, ok := idParam(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.LotResponse{
+			Message: "Invalid lot ID",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.LotHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.LotResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	lot, err := hold(id, &req, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.LotResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.LotResponse{
+		Message: message,
+		Success: true,
+		Data:    lot,
+	})
+}
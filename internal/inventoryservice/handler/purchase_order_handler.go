@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// CreateSupplier godoc
+// @Summary Create supplier
+// @Description Create a supplier to place purchase orders with; names are unique
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param supplier body model.CreateSupplierRequest true "Supplier"
+// @Success 201 {object} model.SupplierResponse
+// @Failure 400 {object} model.SupplierResponse
+// @Failure 401 {object} model.SupplierResponse
+// @Failure 409 {object} model.SupplierResponse
+// @Router /inventory/suppliers [post]
+func (h *InventoryHandler) CreateSupplier(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.SupplierResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.CreateSupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.SupplierResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	supplier, err := h.inventoryService.CreateSupplier(&req, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.SupplierResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.SupplierResponse{
+		Message: "Supplier created successfully",
+		Success: true,
+		Data:    supplier,
+	})
+}
+
+// GetSuppliers godoc
+// @Summary List suppliers
+// @Description List suppliers by name
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.SuppliersResponse
+// @Failure 401 {object} model.SuppliersResponse
+// @Router /inventory/suppliers [get]
+func (h *InventoryHandler) GetSuppliers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.SuppliersResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	suppliers, err := h.inventoryService.GetSuppliers(userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.SuppliersResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SuppliersResponse{
+		Message: "Suppliers retrieved successfully",
+		Success: true,
+		Data:    suppliers,
+	})
+}
+
+// CreatePurchaseOrder godoc
+// @Summary Create purchase order
+// @Description Order items from a supplier. Each item may be listed once, and must exist in ItemService and not be archived.
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param order body model.CreatePurchaseOrderRequest true "Supplier and lines"
+// @Success 201 {object} model.PurchaseOrderResponse
+// @Failure 400 {object} model.PurchaseOrderResponse
+// @Failure 401 {object} model.PurchaseOrderResponse
+// @Failure 404 {object} model.PurchaseOrderResponse
+// @Router /inventory/purchase-orders [post]
+func (h *InventoryHandler) CreatePurchaseOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.PurchaseOrderResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.CreatePurchaseOrderRequest
+	if !h.bindPurchaseOrderRequest(c, &req) {
+		return
+	}
+
+	order, err := h.inventoryService.CreatePurchaseOrder(&req, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.PurchaseOrderResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.PurchaseOrderResponse{
+		Message: "Purchase order created successfully",
+		Success: true,
+		Data:    order,
+	})
+}
+
+// GetPurchaseOrders godoc
+// @Summary List purchase orders
+// @Description List purchase orders without their lines, newest first
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Only orders in this status (open, partially_received, received, closed, cancelled)"
+// @Param supplier_id query int false "Only orders with this supplier"
+// @Success 200 {object} model.PurchaseOrdersResponse
+// @Failure 400 {object} model.PurchaseOrdersResponse
+// @Failure 401 {object} model.PurchaseOrdersResponse
+// @Router /inventory/purchase-orders [get]
+func (h *InventoryHandler) GetPurchaseOrders(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.PurchaseOrdersResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var supplierID uint
+	if value := c.Query("supplier_id"); value != "" {
+		id, ok := idParam(value)
+		if !ok {
+			c.JSON(http.StatusBadRequest, model.PurchaseOrdersResponse{
+				Message: "Invalid supplier ID",
+				Success: false,
+			})
+			return
+		}
+		supplierID = id
+	}
+
+	orders, err := h.inventoryService.GetPurchaseOrders(model.PurchaseOrderStatus(c.Query("status")), supplierID, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.PurchaseOrdersResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.PurchaseOrdersResponse{
+		Message: "Purchase orders retrieved successfully",
+		Success: true,
+		Data:    orders,
+	})
+}
+
+// GetPurchaseOrder godoc
+// @Summary Get purchase order
+// @Description Get a purchase order with its lines, the inventory records they were received into, its receipts and its discrepancies
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} model.PurchaseOrderResponse
+// @Failure 400 {object} model.PurchaseOrderResponse
+// @Failure 401 {object} model.PurchaseOrderResponse
+// @Failure 404 {object} model.PurchaseOrderResponse
+// @Router /inventory/purchase-orders/{id} [get]
+func (h *InventoryHandler) GetPurchaseOrder(c *gin.Context) {
+	h.purchaseOrderAction(c, func(id uint, userID uint32) (*model.PurchaseOrder, error) {
+		return h.inventoryService.GetPurchaseOrder(id, userID)
+	}, "Purchase order retrieved successfully")
+}
+
+// ReceivePurchaseOrder godoc
+// @Summary Receive a delivery
+// @Description Receive some or all of the ordered stock, posting it as receipt movements referencing po-{id}, at location_id or unassigned. Stock received beyond a line's order is recorded as an over-receipt.
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Purchase order ID"
+// @Param receipt body model.ReceivePurchaseOrderRequest true "Quantities received"
+// @Success 200 {object} model.PurchaseOrderResponse
+// @Failure 400 {object} model.PurchaseOrderResponse
+// @Failure 401 {object} model.PurchaseOrderResponse
+// @Failure 404 {object} model.PurchaseOrderResponse
+// @Failure 409 {object} model.PurchaseOrderResponse
+// @Router /inventory/purchase-orders/{id}/receive [post]
+func (h *InventoryHandler) ReceivePurchaseOrder(c *gin.Context) {
+	var req model.ReceivePurchaseOrderRequest
+	if !h.bindPurchaseOrderRequest(c, &req) {
+		return
+	}
+	h.purchaseOrderAction(c, func(id uint, userID uint32) (*model.PurchaseOrder, error) {
+		return h.inventoryService.ReceivePurchaseOrder(id, &req, userID)
+	}, "Delivery received successfully")
+}
+
+// ClosePurchaseOrder godoc
+// @Summary Close purchase order
+// @Description Stop expecting stock on an order, recording what was never received as under-receipts
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} model.PurchaseOrderResponse
+// @Failure 400 {object} model.PurchaseOrderResponse
+// @Failure 401 {object} model.PurchaseOrderResponse
+// @Failure 404 {object} model.PurchaseOrderResponse
+// @Failure 409 {object} model.PurchaseOrderResponse
+// @Router /inventory/purchase-orders/{id}/close [post]
+func (h *InventoryHandler) ClosePurchaseOrder(c *gin.Context) {
+	h.purchaseOrderAction(c, func(id uint, userID uint32) (*model.PurchaseOrder, error) {
+		return h.inventoryService.ClosePurchaseOrder(id, userID)
+	}, "Purchase order closed successfully")
+}
+
+// CancelPurchaseOrder godoc
+// @Summary Cancel purchase order
+// @Description Withdraw an order nothing has been received on
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} model.PurchaseOrderResponse
+// @Failure 400 {object} model.PurchaseOrderResponse
+// @Failure 401 {object} model.PurchaseOrderResponse
+// @Failure 404 {object} model.PurchaseOrderResponse
+// @Failure 409 {object} model.PurchaseOrderResponse
+// @Router /inventory/purchase-orders/{id}/cancel [post]
+func (h *InventoryHandler) CancelPurchaseOrder(c *gin.Context) {
+	h.purchaseOrderAction(c, func(id uint, userID uint32) (*model.PurchaseOrder, error) {
+		return h.inventoryService.CancelPurchaseOrder(id, userID)
+	}, "Purchase order cancelled successfully")
+}
+
+// bindPurchaseOrderRequest binds the JSON body of a purchase order request,
+// answering with 400 if it is invalid.
+func (h *InventoryHandler) bindPurchaseOrderRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, model.PurchaseOrderResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return false
+	}
+	return true
+}
+
+// purchaseOrderAction applies action to the purchase order in the path.
+func (h *InventoryHandler) purchaseOrderAction(c *gin.Context, action func(id uint, userID uint32) (*model.PurchaseOrder, error), message string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.PurchaseOrderResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	id, ok := idParam(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.PurchaseOrderResponse{
+			Message: "Invalid purchase order ID",
+			Success: false,
+		})
+		return
+	}
+
+	order, err := action(id, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.PurchaseOrderResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.PurchaseOrderResponse{
+		Message: message,
+		Success: true,
+		Data:    order,
+	})
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// GetReconciliation godoc
+// @Summary Get the last reconciliation
+// @Description Get the report of the last run of the job that compares inventory stock with ItemService: the mismatches, the records missing on either side and the orphaned stock it found, and which of them it repaired from the configured source of truth.
+// @Tags reconciliation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.ReconciliationResponse
+// @Failure 401 {object} model.ReconciliationResponse
+// @Failure 404 {object} model.ReconciliationResponse
+// @Router /inventory/reconciliation [get]
+func (h *InventoryHandler) GetReconciliation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ReconciliationResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	run, err := h.inventoryService.GetReconciliation(userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.ReconciliationResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ReconciliationResponse{
+		Message: "Reconciliation retrieved successfully",
+		Success: true,
+		Data:    run,
+	})
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// CreateReport godoc
+// @Summary Request a report
+// @Description Queue a valuation, aging or turnover report. Reports are generated in the background; poll the report until it has completed.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param report body model.CreateReportRequest true "Report type and, for turnover, the period in days"
+// @Success 202 {object} model.ReportResponse
+// @Failure 400 {object} model.ReportResponse
+// @Failure 401 {object} model.ReportResponse
+// @Router /inventory/reports [post]
+func (h *InventoryHandler) CreateReport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ReportResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ReportResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	report, err := h.inventoryService.CreateReport(&req, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.ReportResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, model.ReportResponse{
+		Message: "Report queued successfully",
+		Success: true,
+		Data:    report,
+	})
+}
+
+// GetReports godoc
+// @Summary List reports
+// @Description List reports without their results, newest first
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.ReportsResponse
+// @Failure 401 {object} model.ReportsResponse
+// @Router /inventory/reports [get]
+func (h *InventoryHandler) GetReports(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ReportsResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	reports, err := h.inventoryService.GetReports(userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.ReportsResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ReportsResponse{
+		Message: "Reports retrieved successfully",
+		Success: true,
+		Data:    reports,
+	})
+}
+
+// GetReport godoc
+// @Summary Get report
+// @Description Get a report's status and, once it has completed, its result
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Report ID"
+// @Success 200 {object} model.ReportResponse
+// @Failure 400 {object} model.ReportResponse
+// @Failure 401 {object} model.ReportResponse
+// @Failure 404 {object} model.ReportResponse
+// @Router /inventory/reports/{id} [get]
+func (h *InventoryHandler) GetReport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ReportResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	id, ok := idParam(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.ReportResponse{
+			Message: "Invalid report ID",
+			Success: false,
+		})
+		return
+	}
+
+	report, err := h.inventoryService.GetReport(id, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.ReportResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ReportResponse{
+		Message: "Report retrieved successfully",
+		Success: true,
+		Data:    report,
+	})
+}
+
+// GetReportCSV godoc
+// @Summary Export report as CSV
+// @Description Download the result of a completed report as CSV. Valuation values are in minor currency units.
+// @Tags reports
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path int true "Report ID"
+// @Success 200 {string} string "CSV"
+// @Failure 400 {object} model.ReportResponse
+// @Failure 401 {object} model.ReportResponse
+// @Failure 404 {object} model.ReportResponse
+// @Failure 409 {object} model.ReportResponse
+// @Router /inventory/reports/{id}/csv [get]
+func (h *InventoryHandler) GetReportCSV(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ReportResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	id, ok := idParam(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.ReportResponse{
+			Message: "Invalid report ID",
+			Success: false,
+		})
+		return
+	}
+
+	report, records, err := h.inventoryService.ReportCSV(id, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.ReportResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-report-%d.csv"`, report.Type, report.ID))
+	c.Status(http.StatusOK)
+	if err := csv.NewWriter(c.Writer).WriteAll(records); err != nil {
+		c.Error(err)
+	}
+}
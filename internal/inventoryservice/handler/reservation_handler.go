@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// CreateReservation godoc
+// @Summary Reserve stock
+// @Description Hold stock of an item for a checkout until the reservation is confirmed, released or expires. Retrying with the same idempotency_key returns the original reservation.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param reservation body model.CreateReservationRequest true "Reservation data"
+// @Success 200 {object} model.ReservationResponse
+// @Success 201 {object} model.ReservationResponse
+// @Failure 400 {object} model.ReservationResponse
+// @Failure 401 {object} model.ReservationResponse
+// @Failure 404 {object} model.ReservationResponse
+// @Failure 409 {object} model.ReservationResponse
+// @Router /inventory/reservations [post]
+func (h *InventoryHandler) CreateReservation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ReservationResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.CreateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ReservationResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	reservation, created, err := h.inventoryService.CreateReservation(&req, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.ReservationResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+	c.JSON(status, model.ReservationResponse{
+		Message: "Stock reserved successfully",
+		Success: true,
+		Data:    reservation,
+	})
+}
+
+// GetReservation godoc
+// @Summary Get reservation
+// @Description Get a stock reservation
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} model.ReservationResponse
+// @Failure 400 {object} model.ReservationResponse
+// @Failure 401 {object} model.ReservationResponse
+// @Failure 404 {object} model.ReservationResponse
+// @Router /inventory/reservations/{id} [get]
+func (h *InventoryHandler) GetReservation(c *gin.Context) {
+	h.reservationAction(c, h.inventoryService.GetReservation, "Reservation retrieved successfully")
+}
+
+// ConfirmReservation godoc
+// @Summary Confirm reservation
+// @Description Turn a reservation into a sale, removing its quantity from stock. Confirming twice has no further effect.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} model.ReservationResponse
+// @Failure 400 {object} model.ReservationResponse
+// @Failure 401 {object} model.ReservationResponse
+// @Failure 404 {object} model.ReservationResponse
+// @Failure 409 {object} model.ReservationResponse
+// @Router /inventory/reservations/{id}/confirm [post]
+func (h *InventoryHandler) ConfirmReservation(c *gin.Context) {
+	h.reservationAction(c, h.inventoryService.ConfirmReservation, "Reservation confirmed successfully")
+}
+
+// ReleaseReservation godoc
+// @Summary Release reservation
+// @Description Give a reservation's stock back. Releasing twice has no further effect.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} model.ReservationResponse
+// @Failure 400 {object} model.ReservationResponse
+// @Failure 401 {object} model.ReservationResponse
+// @Failure 404 {object} model.ReservationResponse
+// @Failure 409 {object} model.ReservationResponse
+// @Router /inventory/reservations/{id}/release [post]
+func (h *InventoryHandler) ReleaseReservation(c *gin.Context) {
+	h.reservationAction(c, h.inventoryService.ReleaseReservation, "Reservation released successfully")
+}
+
+// reservationAction applies action to the reservation in the path.
+func (h *InventoryHandler) reservationAction(c *gin.Context, action func(id uint, userID uint32) (*model.Reservation, error), message string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ReservationResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	id, ok := idParam(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, model.ReservationResponse{
+			Message: "Invalid reservation ID",
+			Success: false,
+		})
+		return
+	}
+
+	reservation, err := action(id, userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.ReservationResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ReservationResponse{
+		Message: message,
+		Success: true,
+		Data:    reservation,
+	})
+}
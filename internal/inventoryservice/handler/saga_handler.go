@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// GetSagas godoc
+// @Summary List sagas
+// @Description List the sagas that keep stock changes and item deletions consistent with ItemService, newest first. Failed sagas need someone to look at them.
+// @Tags sagas
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Only sagas in this status (started, compensating, completed, compensated, failed)"
+// @Success 200 {object} model.SagasResponse
+// @Failure 400 {object} model.SagasResponse
+// @Failure 401 {object} model.SagasResponse
+// @Router /inventory/sagas [get]
+func (h *InventoryHandler) GetSagas(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.SagasResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	sagas, err := h.inventoryService.GetSagas(model.SagaStatus(c.Query("status")), userID.(uint32))
+	if err != nil {
+		c.JSON(errorStatus(err), model.SagasResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.SagasResponse{
+		Message: "Sagas retrieved successfully",
+		Success: true,
+		Data:    sagas,
+	})
+}
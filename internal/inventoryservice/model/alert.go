@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+type AlertLevel string
+
+const (
+	// AlertLow means the stock available is below MinStock.
+	AlertLow AlertLevel = "low"
+	// AlertReorder means the stock available has reached the reorder point
+	// and should be replenished before it runs low.
+	AlertReorder AlertLevel = "reorder"
+)
+
+// StockAlert is an item that needs replenishing. The reorder point is
+// MinStock plus what is expected to be consumed during the lead time at the
+// recent rate, and the suggested quantity refills stock up to MaxStock.
+type StockAlert struct {
+	ItemID                 string     `json:"item_id"`
+	Level                  AlertLevel `json:"level"`
+	Stock                  int        `json:"stock"`
+	Reserved               int        `json:"reserved"`
+	Available              int        `json:"available"`
+	MinStock               int        `json:"min_stock"`
+	MaxStock               int        `json:"max_stock"`
+	LowStockSince          *time.Time `json:"low_stock_since,omitempty"`
+	DailyConsumption       float64    `json:"daily_consumption"`
+	LeadTimeDays           int        `json:"lead_time_days"`
+	ReorderPoint           int        `json:"reorder_point"`
+	SuggestedOrderQuantity int        `json:"suggested_order_quantity"`
+}
+
+type AlertsResponse struct {
+	Message string        `json:"message"`
+	Success bool          `json:"success"`
+	Data    []*StockAlert `json:"data"`
+}
@@ -0,0 +1,120 @@
+package model
+
+import "time"
+
+type CountStatus string
+
+const (
+	CountOpen   CountStatus = "open"
+	CountClosed CountStatus = "closed"
+)
+
+type CountLineStatus string
+
+const (
+	CountLinePending  CountLineStatus = "pending"
+	CountLineCounted  CountLineStatus = "counted"
+	CountLineApproved CountLineStatus = "approved"
+)
+
+// VarianceReason explains why counted stock differed from the system.
+type VarianceReason string
+
+const (
+	VarianceDamaged  VarianceReason = "damaged"
+	VarianceTheft    VarianceReason = "theft"
+	VarianceLost     VarianceReason = "lost"
+	VarianceFound    VarianceReason = "found"
+	VarianceMiscount VarianceReason = "miscount"
+	VarianceOther    VarianceReason = "other"
+)
+
+// Valid reports whether r is a known reason code.
+func (r VarianceReason) Valid() bool {
+	switch r {
+	case VarianceDamaged, VarianceTheft, VarianceLost, VarianceFound, VarianceMiscount, VarianceOther:
+		return true
+	}
+	return false
+}
+
+// DefaultCountVarianceLimit is the largest variance, in units, a count line
+// may be approved with without a supervisor.
+const DefaultCountVarianceLimit = 10
+
+// CountSession is a physical stock count. Its lines are the count sheet:
+// the stock of each item, or of each item at a location, as it was when the
+// session started. Counters record what they find, and approving a line
+// posts its variance to the stock. Variances above VarianceLimit, fixed
+// when the session starts, need a supervisor's approval.
+type CountSession struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	Name          string       `json:"name,omitempty"`
+	Status        CountStatus  `json:"status" gorm:"type:varchar(16);not null;index"`
+	VarianceLimit int          `json:"variance_limit" gorm:"not null"`
+	CreatedBy     uint32       `json:"created_by"`
+	ClosedBy      uint32       `json:"closed_by,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	ClosedAt      *time.Time   `json:"closed_at,omitempty"`
+	Lines         []*CountLine `json:"lines,omitempty" gorm:"foreignKey:SessionID"`
+}
+
+// CountLine is one item, or one item at a location, on a count sheet.
+// SnapshotStock is the system's stock when the session started and
+// Variance the counted quantity minus it.
+type CountLine struct {
+	ID              uint            `json:"id" gorm:"primaryKey"`
+	SessionID       uint            `json:"session_id" gorm:"not null;index"`
+	ItemID          string          `json:"item_id" gorm:"not null;index"`
+	LocationID      *uint           `json:"location_id,omitempty"`
+	SnapshotStock   int             `json:"snapshot_stock" gorm:"not null"`
+	CountedQuantity *int            `json:"counted_quantity,omitempty"`
+	Variance        int             `json:"variance" gorm:"not null;default:0"`
+	NeedsSupervisor bool            `json:"needs_supervisor" gorm:"not null;default:false"`
+	Status          CountLineStatus `json:"status" gorm:"type:varchar(16);not null"`
+	ReasonCode      VarianceReason  `json:"reason_code,omitempty" gorm:"type:varchar(16)"`
+	CountedBy       uint32          `json:"counted_by,omitempty"`
+	CountedAt       *time.Time      `json:"counted_at,omitempty"`
+	ApprovedBy      uint32          `json:"approved_by,omitempty"`
+	ApprovedAt      *time.Time      `json:"approved_at,omitempty"`
+}
+
+// CreateCountRequest starts a count of the given items, each counted as a
+// whole, and of everything stocked at the given locations.
+type CreateCountRequest struct {
+	Name        string   `json:"name"`
+	ItemIDs     []string `json:"item_ids"`
+	LocationIDs []uint   `json:"location_ids"`
+}
+
+// CountEntry is the quantity counted for a line.
+type CountEntry struct {
+	LineID   uint `json:"line_id" binding:"required"`
+	Quantity *int `json:"quantity" binding:"required,min=0"`
+}
+
+// SubmitCountRequest records counted quantities. A line may be counted
+// again until it is approved.
+type SubmitCountRequest struct {
+	Counts []CountEntry `json:"counts" binding:"required,min=1,dive"`
+}
+
+// ApproveCountRequest approves the given counted lines, or all of them if
+// none are given, posting their variances with ReasonCode. A reason code is
+// needed when any of them has a variance.
+type ApproveCountRequest struct {
+	LineIDs    []uint         `json:"line_ids"`
+	ReasonCode VarianceReason `json:"reason_code"`
+}
+
+type CountResponse struct {
+	Message string        `json:"message"`
+	Success bool          `json:"success"`
+	Data    *CountSession `json:"data,omitempty"`
+}
+
+type CountsResponse struct {
+	Message string          `json:"message"`
+	Success bool            `json:"success"`
+	Data    []*CountSession `json:"data"`
+}
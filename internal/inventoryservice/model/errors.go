@@ -0,0 +1,41 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors the service returns, for callers to tell them apart with errors.Is.
+// Repository errors are returned as they are.
+var (
+	// ErrUnauthorized is returned when the auth service does not know the
+	// user.
+	ErrUnauthorized = errors.New("unauthorized: invalid user")
+	// ErrInvalidRequest is matched by errors of requests that fail
+	// validation; see InvalidRequest.
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrItemNotFound   = errors.New("item not found")
+	ErrItemArchived   = errors.New("item is archived; stock can no longer be updated")
+	// ErrItemSync is matched by errors of calls to ItemService that failed
+	// or were refused, after which a change may have been reverted.
+	ErrItemSync = errors.New("failed to sync with the item service")
+)
+
+// markedError is an error with its own message that matches kind.
+type markedError struct {
+	kind    error
+	message string
+}
+
+func (e *markedError) Error() string { return e.message }
+func (e *markedError) Unwrap() error { return e.kind }
+
+// Errorf formats an error that matches kind without taking its message.
+func Errorf(kind error, format string, args ...interface{}) error {
+	return &markedError{kind: kind, message: fmt.Sprintf(format, args...)}
+}
+
+// InvalidRequest formats an error of a request that fails validation.
+func InvalidRequest(format string, args ...interface{}) error {
+	return Errorf(ErrInvalidRequest, format, args...)
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// IdempotencyKey is a key sent in the Idempotency-Key header of a request,
+// scoped to its user, with a fingerprint of the request and, once it has
+// been handled, the response to replay to retries. Status is 0 while the
+// request is still being handled.
+type IdempotencyKey struct {
+	Key         string `gorm:"primaryKey;size:300"`
+	Fingerprint string `gorm:"size:64;not null"`
+	Status      int    `gorm:"not null;default:0"`
+	ContentType string `gorm:"size:128"`
+	Body        []byte
+	ExpiresAt   time.Time `gorm:"not null;index"`
+	CreatedAt   time.Time
+}
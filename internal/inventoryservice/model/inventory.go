@@ -1,31 +1,105 @@
 package model
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Inventory is an item's stock across all locations. Reserved is the part of
+// it held by active reservations, InTransit the part being transferred
+// between locations, Held the part in quarantined or blocked lots, and
+// Available what is left to promise to new orders.
+// Stock available below MinStock raises a low-stock alert, and LowStockSince
+// is set until it recovers. LeadTimeDays is how long replenishment takes, used
+// for reorder point suggestions.
 type Inventory struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	ItemID    string         `json:"item_id" gorm:"uniqueIndex;not null"`
-	Stock     int            `json:"stock" gorm:"not null;default:0"`
-	MinStock  int            `json:"min_stock" gorm:"default:0"`
-	MaxStock  int            `json:"max_stock" gorm:"default:1000"`
-	UpdatedBy uint32         `json:"updated_by"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	ItemID        string         `json:"item_id" gorm:"uniqueIndex;not null"`
+	Stock         int            `json:"stock" gorm:"not null;default:0"`
+	Reserved      int            `json:"reserved" gorm:"not null;default:0"`
+	InTransit     int            `json:"in_transit" gorm:"not null;default:0"`
+	Held          int            `json:"held" gorm:"not null;default:0"`
+	Available     int            `json:"available" gorm:"-"`
+	MinStock      int            `json:"min_stock" gorm:"default:0"`
+	MaxStock      int            `json:"max_stock" gorm:"default:1000"`
+	LeadTimeDays  int            `json:"lead_time_days" gorm:"not null;default:7"`
+	LowStockSince *time.Time     `json:"low_stock_since,omitempty"`
+	UpdatedBy     uint32         `json:"updated_by"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// AfterFind computes Available.
+func (i *Inventory) AfterFind(tx *gorm.DB) error {
+	i.Available = i.Stock - i.Reserved - i.InTransit - i.Held
+	return nil
+}
+
+// AfterSave keeps Available current after a write.
+func (i *Inventory) AfterSave(tx *gorm.DB) error {
+	i.Available = i.Stock - i.Reserved - i.InTransit - i.Held
+	return nil
+}
+
+// IsLow reports whether the stock available is below MinStock.
+func (i *Inventory) IsLow() bool {
+	return i.Available < i.MinStock
+}
+
+// Warnings describes thresholds the stock is outside of without that being
+// an error.
+func (i *Inventory) Warnings() []string {
+	if i.Stock > i.MaxStock {
+		return []string{fmt.Sprintf("stock %d exceeds max_stock %d", i.Stock, i.MaxStock)}
+	}
+	return nil
+}
+
+// UpdateStockRequest sets an item's stock to an absolute number. The
+// difference is recorded in the ledger as a movement of Type, a correction
+// unless given.
 type UpdateStockRequest struct {
-	NewStock int `json:"new_stock" binding:"required,min=0"`
+	NewStock  int          `json:"new_stock" binding:"required,min=0"`
+	Type      MovementType `json:"type"`
+	Reason    string       `json:"reason"`
+	Reference string       `json:"reference"`
+}
+
+// AdjustStockRequest changes an item's stock by Quantity, up or down
+// depending on the endpoint. Type defaults to correction. Without a
+// LocationID, increments add unassigned stock and decrements take unassigned
+// stock first, then stock from locations in the order they were created.
+// Without a LotNumber, increments add stock not tracked by lot and
+// decrements take from lots first-expired-first-out, then untracked stock.
+// An increment with a new LotNumber creates the lot with ManufacturedAt and
+// ExpiresAt.
+type AdjustStockRequest struct {
+	Quantity       int          `json:"quantity" binding:"required,min=1"`
+	LocationID     *uint        `json:"location_id"`
+	LotNumber      string       `json:"lot_number" binding:"max=64"`
+	ManufacturedAt *time.Time   `json:"manufactured_at"`
+	ExpiresAt      *time.Time   `json:"expires_at"`
+	Type           MovementType `json:"type"`
+	Reason         string       `json:"reason"`
+	Reference      string       `json:"reference"`
+}
+
+// UpdateThresholdsRequest changes an item's stock thresholds. Omitted
+// fields keep their current value.
+type UpdateThresholdsRequest struct {
+	MinStock     *int `json:"min_stock" binding:"omitempty,min=0"`
+	MaxStock     *int `json:"max_stock" binding:"omitempty,min=0"`
+	LeadTimeDays *int `json:"lead_time_days" binding:"omitempty,min=0"`
 }
 
 type StockResponse struct {
-	Message string     `json:"message"`
-	Success bool       `json:"success"`
-	Data    *Inventory `json:"data,omitempty"`
+	Message  string     `json:"message"`
+	Success  bool       `json:"success"`
+	Data     *Inventory `json:"data,omitempty"`
+	Warnings []string   `json:"warnings,omitempty"`
 }
 
 type ItemsResponse struct {
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Location is a place stock is kept: a warehouse, a zone of a warehouse or
+// a bin within a zone. Code identifies it as "warehouse/zone/bin".
+type Location struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"size:200;uniqueIndex;not null"`
+	Warehouse string    `json:"warehouse" gorm:"size:64;not null;index"`
+	Zone      string    `json:"zone,omitempty" gorm:"size:64"`
+	Bin       string    `json:"bin,omitempty" gorm:"size:64"`
+	Name      string    `json:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LocationCode joins the levels of a location that are set.
+func LocationCode(warehouse, zone, bin string) string {
+	parts := []string{warehouse}
+	for _, part := range []string{zone, bin} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// LocationStock is the part of an item's stock kept at a location. The
+// quantities at all locations plus the stock in transit between them never
+// exceed Inventory.Stock; the rest is stock not assigned to a location.
+type LocationStock struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ItemID     string    `json:"item_id" gorm:"not null;uniqueIndex:idx_location_stocks_item_location,priority:1"`
+	LocationID uint      `json:"location_id" gorm:"not null;uniqueIndex:idx_location_stocks_item_location,priority:2;index"`
+	Location   *Location `json:"location,omitempty"`
+	Quantity   int       `json:"quantity" gorm:"not null;default:0"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type TransferStatus string
+
+const (
+	TransferInTransit TransferStatus = "in_transit"
+	TransferReceived  TransferStatus = "received"
+	TransferCancelled TransferStatus = "cancelled"
+)
+
+// Transfer moves stock between locations in two phases: shipping takes it
+// from the source location and holds it in transit, receiving puts it at
+// the destination. Cancelling returns it to the source. An item's total
+// stock does not change.
+type Transfer struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	ItemID         string         `json:"item_id" gorm:"not null;index"`
+	FromLocationID uint           `json:"from_location_id" gorm:"not null"`
+	ToLocationID   uint           `json:"to_location_id" gorm:"not null"`
+	Quantity       int            `json:"quantity" gorm:"not null"`
+	Status         TransferStatus `json:"status" gorm:"type:varchar(16);not null;index"`
+	Reference      string         `json:"reference,omitempty"`
+	CreatedBy      uint32         `json:"created_by"`
+	ReceivedBy     uint32         `json:"received_by,omitempty"`
+	ShippedAt      time.Time      `json:"shipped_at"`
+	ReceivedAt     *time.Time     `json:"received_at,omitempty"`
+	CancelledAt    *time.Time     `json:"cancelled_at,omitempty"`
+}
+
+type CreateLocationRequest struct {
+	Warehouse string `json:"warehouse" binding:"required,max=64"`
+	Zone      string `json:"zone" binding:"max=64"`
+	Bin       string `json:"bin" binding:"max=64"`
+	Name      string `json:"name"`
+}
+
+type CreateTransferRequest struct {
+	ItemID         string `json:"item_id" binding:"required"`
+	FromLocationID uint   `json:"from_location_id" binding:"required"`
+	ToLocationID   uint   `json:"to_location_id" binding:"required"`
+	Quantity       int    `json:"quantity" binding:"required,min=1"`
+	Reference      string `json:"reference"`
+}
+
+// ItemLocations is an item's stock broken down by location.
+type ItemLocations struct {
+	ItemID     string           `json:"item_id"`
+	Stock      int              `json:"stock"`
+	Reserved   int              `json:"reserved"`
+	InTransit  int              `json:"in_transit"`
+	Available  int              `json:"available"`
+	Unassigned int              `json:"unassigned"`
+	Locations  []*LocationStock `json:"locations"`
+}
+
+type LocationResponse struct {
+	Message string    `json:"message"`
+	Success bool      `json:"success"`
+	Data    *Location `json:"data,omitempty"`
+}
+
+type LocationsResponse struct {
+	Message string      `json:"message"`
+	Success bool        `json:"success"`
+	Data    []*Location `json:"data"`
+}
+
+type ItemLocationsResponse struct {
+	Message string         `json:"message"`
+	Success bool           `json:"success"`
+	Data    *ItemLocations `json:"data,omitempty"`
+}
+
+type TransferResponse struct {
+	Message string    `json:"message"`
+	Success bool      `json:"success"`
+	Data    *Transfer `json:"data,omitempty"`
+}
+
+type TransfersResponse struct {
+	Message string      `json:"message"`
+	Success bool        `json:"success"`
+	Data    []*Transfer `json:"data"`
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LotStatus string
+
+const (
+	LotAvailable LotStatus = "available"
+	// LotQuarantined lots are held pending inspection and can be released.
+	LotQuarantined LotStatus = "quarantined"
+	// LotBlocked lots may never be sold again, only written off.
+	LotBlocked LotStatus = "blocked"
+)
+
+// Held reports whether the stock of a lot in status s is held back from
+// sale.
+func (s LotStatus) Held() bool {
+	return s == LotQuarantined || s == LotBlocked
+}
+
+// Lot is a batch of an item received under one lot number. The quantities
+// of an item's lots never exceed Inventory.Stock; the rest is stock not
+// tracked by lot. Expired reports whether ExpiresAt has passed.
+type Lot struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	ItemID         string     `json:"item_id" gorm:"not null;uniqueIndex:idx_lots_item_number,priority:1"`
+	LotNumber      string     `json:"lot_number" gorm:"size:64;not null;uniqueIndex:idx_lots_item_number,priority:2"`
+	Quantity       int        `json:"quantity" gorm:"not null;default:0"`
+	ManufacturedAt *time.Time `json:"manufactured_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	Expired        bool       `json:"expired" gorm:"-"`
+	Status         LotStatus  `json:"status" gorm:"type:varchar(16);not null;default:available"`
+	HoldReason     string     `json:"hold_reason,omitempty"`
+	UpdatedBy      uint32     `json:"updated_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// AfterFind computes Expired.
+func (l *Lot) AfterFind(tx *gorm.DB) error {
+	l.Expired = l.ExpiresAt != nil && !l.ExpiresAt.After(time.Now())
+	return nil
+}
+
+// LotHoldRequest quarantines or blocks a lot.
+type LotHoldRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type LotResponse struct {
+	Message string `json:"message"`
+	Success bool   `json:"success"`
+	Data    *Lot   `json:"data,omitempty"`
+}
+
+type LotsResponse struct {
+	Message string `json:"message"`
+	Success bool   `json:"success"`
+	Data    []*Lot `json:"data"`
+}
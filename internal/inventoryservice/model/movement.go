@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+type MovementType string
+
+const (
+	MovementReceipt    MovementType = "receipt"
+	MovementSale       MovementType = "sale"
+	MovementShrinkage  MovementType = "shrinkage"
+	MovementReturn     MovementType = "return"
+	MovementCorrection MovementType = "correction"
+	// MovementOpeningBalance carries over stock that existed before the
+	// ledger was introduced.
+	MovementOpeningBalance MovementType = "opening_balance"
+	// MovementCountAdjustment posts the variance of an approved stock
+	// count.
+	MovementCountAdjustment MovementType = "count_adjustment"
+)
+
+// Valid reports whether t is a movement type clients may record.
+func (t MovementType) Valid() bool {
+	switch t {
+	case MovementReceipt, MovementSale, MovementShrinkage, MovementReturn, MovementCorrection:
+		return true
+	}
+	return false
+}
+
+// Direction is the sign of the stock changes a movement type records: 1 for
+// receipts and returns, -1 for sales and shrinkage and 0 for types that may
+// go either way.
+func (t MovementType) Direction() int {
+	switch t {
+	case MovementReceipt, MovementReturn:
+		return 1
+	case MovementSale, MovementShrinkage:
+		return -1
+	}
+	return 0
+}
+
+// StockMovement is an entry in the append-only stock ledger. Quantity is
+// signed: receipts are positive, sales and write-offs negative. The sum of
+// an item's movements equals its current stock. LocationID is where the
+// stock was added or taken, nil for stock not assigned to a location, and
+// LotID the lot it belongs to, nil for stock not tracked by lot.
+type StockMovement struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	ItemID     string       `json:"item_id" gorm:"not null;index:idx_stock_movements_item_created,priority:1"`
+	Type       MovementType `json:"type" gorm:"type:varchar(32);not null"`
+	LocationID *uint        `json:"location_id,omitempty" gorm:"index"`
+	LotID      *uint        `json:"lot_id,omitempty" gorm:"index"`
+	Quantity   int          `json:"quantity" gorm:"not null"`
+	StockAfter int          `json:"stock_after" gorm:"not null"`
+	Reason     string       `json:"reason,omitempty"`
+	Reference  string       `json:"reference,omitempty" gorm:"index"`
+	UserID     uint32       `json:"user_id"`
+	CreatedAt  time.Time    `json:"created_at" gorm:"not null;index:idx_stock_movements_item_created,priority:2"`
+}
+
+// MovementFilter narrows a ledger listing. Zero times leave that end open;
+// To is exclusive.
+type MovementFilter struct {
+	From time.Time
+	To   time.Time
+	Type MovementType
+}
+
+type MovementsResponse struct {
+	Message string           `json:"message"`
+	Success bool             `json:"success"`
+	Data    []*StockMovement `json:"data"`
+	// Stock is the item's current stock and LedgerStock the sum of all of
+	// its movements; they differ only if the ledger was bypassed.
+	Stock       int  `json:"stock"`
+	LedgerStock int  `json:"ledger_stock"`
+	Reconciled  bool `json:"reconciled"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// OutboxEvent is a Kafka event waiting to be published. It is written in the
+// same transaction as the change it reports, so that no change goes
+// unreported, and the outbox relay publishes events in the order they were
+// written and marks them sent. Attempts and LastError record failed
+// publishes.
+type OutboxEvent struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Topic     string     `json:"topic" gorm:"size:64;not null"`
+	Key       string     `json:"key,omitempty"`
+	Payload   string     `json:"payload" gorm:"type:text;not null"`
+	Attempts  int        `json:"attempts" gorm:"not null;default:0"`
+	LastError string     `json:"last_error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	SentAt    *time.Time `json:"sent_at,omitempty" gorm:"index"`
+}
@@ -0,0 +1,181 @@
+package model
+
+import "time"
+
+// Supplier is who purchase orders are placed with.
+type Supplier struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex"`
+	Email     string    `json:"email,omitempty"`
+	Phone     string    `json:"phone,omitempty"`
+	CreatedBy uint32    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type PurchaseOrderStatus string
+
+const (
+	// PurchaseOrderOpen is an order nothing has been received on yet.
+	PurchaseOrderOpen PurchaseOrderStatus = "open"
+	// PurchaseOrderPartiallyReceived is an order some of whose stock has
+	// been received.
+	PurchaseOrderPartiallyReceived PurchaseOrderStatus = "partially_received"
+	// PurchaseOrderReceived is an order every line of which has been
+	// received in full.
+	PurchaseOrderReceived PurchaseOrderStatus = "received"
+	// PurchaseOrderClosed is an order no more stock is expected on, short
+	// lines having been recorded as under-receipts.
+	PurchaseOrderClosed PurchaseOrderStatus = "closed"
+	// PurchaseOrderCancelled is an order withdrawn before anything was
+	// received.
+	PurchaseOrderCancelled PurchaseOrderStatus = "cancelled"
+)
+
+// Valid reports whether s is a known purchase order status.
+func (s PurchaseOrderStatus) Valid() bool {
+	switch s {
+	case PurchaseOrderOpen, PurchaseOrderPartiallyReceived, PurchaseOrderReceived, PurchaseOrderClosed, PurchaseOrderCancelled:
+		return true
+	}
+	return false
+}
+
+// PurchaseOrder is stock ordered from a supplier. Receiving it posts
+// receipt movements referencing po-{id}, and each line links to the
+// inventory record its stock went to.
+type PurchaseOrder struct {
+	ID            uint                  `json:"id" gorm:"primaryKey"`
+	SupplierID    uint                  `json:"supplier_id" gorm:"not null;index"`
+	Supplier      *Supplier             `json:"supplier,omitempty"`
+	Reference     string                `json:"reference,omitempty"`
+	Status        PurchaseOrderStatus   `json:"status" gorm:"type:varchar(24);not null;index"`
+	ExpectedAt    *time.Time            `json:"expected_at,omitempty"`
+	CreatedBy     uint32                `json:"created_by"`
+	ClosedBy      uint32                `json:"closed_by,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+	ClosedAt      *time.Time            `json:"closed_at,omitempty"`
+	Lines         []*PurchaseOrderLine  `json:"lines,omitempty"`
+	Receipts      []*Receipt            `json:"receipts,omitempty"`
+	Discrepancies []*ReceiptDiscrepancy `json:"discrepancies,omitempty"`
+}
+
+// PurchaseOrderLine is the quantity of an item ordered and how much of it
+// has been received. InventoryID is the inventory record the stock was
+// received into, set by the first receipt.
+type PurchaseOrderLine struct {
+	ID              uint   `json:"id" gorm:"primaryKey"`
+	PurchaseOrderID uint   `json:"purchase_order_id" gorm:"not null;index"`
+	ItemID          string `json:"item_id" gorm:"not null;index"`
+	Ordered         int    `json:"ordered" gorm:"not null"`
+	Received        int    `json:"received" gorm:"not null;default:0"`
+	InventoryID     *uint  `json:"inventory_id,omitempty" gorm:"index"`
+}
+
+// Receipt is one delivery received on a purchase order, at LocationID or
+// as unassigned stock.
+type Receipt struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	PurchaseOrderID uint           `json:"purchase_order_id" gorm:"not null;index"`
+	LocationID      *uint          `json:"location_id,omitempty"`
+	ReceivedBy      uint32         `json:"received_by"`
+	CreatedAt       time.Time      `json:"created_at"`
+	Lines           []*ReceiptLine `json:"lines,omitempty"`
+}
+
+// ReceiptLine is the quantity of an order line received in a delivery,
+// into LotID if the stock is tracked by lot.
+type ReceiptLine struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	ReceiptID uint   `json:"receipt_id" gorm:"not null;index"`
+	LineID    uint   `json:"line_id" gorm:"not null"`
+	ItemID    string `json:"item_id" gorm:"not null"`
+	Quantity  int    `json:"quantity" gorm:"not null"`
+	LotID     *uint  `json:"lot_id,omitempty"`
+}
+
+type DiscrepancyType string
+
+const (
+	// OverReceipt is stock received beyond what a line ordered.
+	OverReceipt DiscrepancyType = "over_receipt"
+	// UnderReceipt is stock a line ordered that was never received.
+	UnderReceipt DiscrepancyType = "under_receipt"
+)
+
+// ReceiptDiscrepancy is a difference between what was ordered and what was
+// received. Over-receipts are raised by the receipt that exceeds the line,
+// under-receipts when the order is closed short.
+type ReceiptDiscrepancy struct {
+	ID              uint            `json:"id" gorm:"primaryKey"`
+	PurchaseOrderID uint            `json:"purchase_order_id" gorm:"not null;index"`
+	LineID          uint            `json:"line_id" gorm:"not null"`
+	ReceiptID       *uint           `json:"receipt_id,omitempty"`
+	ItemID          string          `json:"item_id" gorm:"not null"`
+	Type            DiscrepancyType `json:"type" gorm:"type:varchar(16);not null"`
+	Quantity        int             `json:"quantity" gorm:"not null"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+type CreateSupplierRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+type PurchaseOrderLineRequest struct {
+	ItemID   string `json:"item_id" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+}
+
+// CreatePurchaseOrderRequest orders the given quantities of items, each
+// listed once, from a supplier.
+type CreatePurchaseOrderRequest struct {
+	SupplierID uint                       `json:"supplier_id" binding:"required"`
+	Reference  string                     `json:"reference"`
+	ExpectedAt *time.Time                 `json:"expected_at"`
+	Lines      []PurchaseOrderLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// ReceiptLineRequest receives Quantity of an order line. With a LotNumber
+// the stock goes to that lot, created with the given dates the first time
+// it is received.
+type ReceiptLineRequest struct {
+	LineID         uint       `json:"line_id" binding:"required"`
+	Quantity       int        `json:"quantity" binding:"required,min=1"`
+	LotNumber      string     `json:"lot_number"`
+	ManufacturedAt *time.Time `json:"manufactured_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+}
+
+// ReceivePurchaseOrderRequest records a delivery, at LocationID or as
+// unassigned stock.
+type ReceivePurchaseOrderRequest struct {
+	LocationID *uint                `json:"location_id"`
+	Lines      []ReceiptLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+type SupplierResponse struct {
+	Message string    `json:"message"`
+	Success bool      `json:"success"`
+	Data    *Supplier `json:"data,omitempty"`
+}
+
+type SuppliersResponse struct {
+	Message string      `json:"message"`
+	Success bool        `json:"success"`
+	Data    []*Supplier `json:"data"`
+}
+
+type PurchaseOrderResponse struct {
+	Message string         `json:"message"`
+	Success bool           `json:"success"`
+	Data    *PurchaseOrder `json:"data,omitempty"`
+}
+
+type PurchaseOrdersResponse struct {
+	Message string           `json:"message"`
+	Success bool             `json:"success"`
+	Data    []*PurchaseOrder `json:"data"`
+}
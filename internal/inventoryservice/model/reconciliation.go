@@ -0,0 +1,120 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReconciliationSource is the store whose stock reconciliation takes as
+// correct when repairing a discrepancy.
+type ReconciliationSource string
+
+const (
+	// ReconcileFromInventory repairs ItemService from the inventory records.
+	ReconcileFromInventory ReconciliationSource = "inventory"
+	// ReconcileFromItemService repairs the inventory records from ItemService.
+	ReconcileFromItemService ReconciliationSource = "item_service"
+	// ReconcileReportOnly reports discrepancies without repairing them.
+	ReconcileReportOnly ReconciliationSource = "none"
+)
+
+// Valid reports whether s is a known source of truth.
+func (s ReconciliationSource) Valid() bool {
+	switch s {
+	case ReconcileFromInventory, ReconcileFromItemService, ReconcileReportOnly:
+		return true
+	}
+	return false
+}
+
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyStockMismatch is an item whose stock differs between the
+	// two stores.
+	DiscrepancyStockMismatch DiscrepancyKind = "stock_mismatch"
+	// DiscrepancyMissingInInventory is an item with stock in ItemService
+	// but no inventory record.
+	DiscrepancyMissingInInventory DiscrepancyKind = "missing_in_inventory"
+	// DiscrepancyMissingInItemService is an inventory record of an item
+	// ItemService does not know.
+	DiscrepancyMissingInItemService DiscrepancyKind = "missing_in_item_service"
+	// DiscrepancyOrphanedStock is stock at locations or in lots of an item
+	// that has no inventory record.
+	DiscrepancyOrphanedStock DiscrepancyKind = "orphaned_stock"
+)
+
+// Discrepancy is a disagreement found by reconciliation. A store without
+// the item counts as having no stock of it; for orphaned stock,
+// InventoryStock is the stock left at locations and in lots. Error says
+// why it was not repaired.
+type Discrepancy struct {
+	Kind           DiscrepancyKind `json:"kind"`
+	ItemID         string          `json:"item_id"`
+	InventoryStock int             `json:"inventory_stock"`
+	ItemStock      int             `json:"item_stock"`
+	Repaired       bool            `json:"repaired"`
+	Error          string          `json:"error,omitempty"`
+}
+
+type ReconciliationStatus string
+
+const (
+	ReconciliationRunning   ReconciliationStatus = "running"
+	ReconciliationCompleted ReconciliationStatus = "completed"
+	ReconciliationFailed    ReconciliationStatus = "failed"
+)
+
+// MaxReportedDiscrepancies is how many discrepancies a reconciliation run
+// lists; Found counts them all.
+const MaxReportedDiscrepancies = 1000
+
+// ReconciliationRun is one comparison of the inventory records with
+// ItemService. Slot identifies the schedule period of the run, so that one
+// instance only runs it. Checked counts the inventory records and items
+// compared, Skipped the inventory records left out because they changed
+// while the run was going, as their sync to ItemService may still be on
+// its way.
+type ReconciliationRun struct {
+	ID                uint                 `json:"id" gorm:"primaryKey"`
+	Slot              int64                `json:"-" gorm:"uniqueIndex;not null"`
+	Source            ReconciliationSource `json:"source" gorm:"type:varchar(16);not null"`
+	Status            ReconciliationStatus `json:"status" gorm:"type:varchar(16);not null;index"`
+	Checked           int                  `json:"checked"`
+	Skipped           int                  `json:"skipped"`
+	Found             int                  `json:"found"`
+	Repaired          int                  `json:"repaired"`
+	DiscrepanciesJSON string               `json:"-" gorm:"column:discrepancies;type:text"`
+	Discrepancies     []Discrepancy        `json:"discrepancies" gorm:"-"`
+	Error             string               `json:"error,omitempty"`
+	StartedAt         time.Time            `json:"started_at"`
+	CompletedAt       *time.Time           `json:"completed_at,omitempty"`
+}
+
+// AfterFind reads the stored discrepancies.
+func (r *ReconciliationRun) AfterFind(tx *gorm.DB) error {
+	r.Discrepancies = []Discrepancy{}
+	if r.DiscrepanciesJSON == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(r.DiscrepanciesJSON), &r.Discrepancies)
+}
+
+// Add records a discrepancy, listing it if there is room.
+func (r *ReconciliationRun) Add(d Discrepancy) {
+	r.Found++
+	if d.Repaired {
+		r.Repaired++
+	}
+	if len(r.Discrepancies) < MaxReportedDiscrepancies {
+		r.Discrepancies = append(r.Discrepancies, d)
+	}
+}
+
+type ReconciliationResponse struct {
+	Message string             `json:"message"`
+	Success bool               `json:"success"`
+	Data    *ReconciliationRun `json:"data,omitempty"`
+}
@@ -0,0 +1,221 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReportType string
+
+const (
+	// ReportValuation values the stock on hand at the item prices in
+	// ItemService, by category and currency.
+	ReportValuation ReportType = "valuation"
+	// ReportAging splits each item's stock by how long ago it was received.
+	ReportAging ReportType = "aging"
+	// ReportTurnover relates each item's sales to its average stock over a
+	// period.
+	ReportTurnover ReportType = "turnover"
+)
+
+// Valid reports whether t is a known report type.
+func (t ReportType) Valid() bool {
+	switch t {
+	case ReportValuation, ReportAging, ReportTurnover:
+		return true
+	}
+	return false
+}
+
+type ReportStatus string
+
+const (
+	ReportPending   ReportStatus = "pending"
+	ReportRunning   ReportStatus = "running"
+	ReportCompleted ReportStatus = "completed"
+	ReportFailed    ReportStatus = "failed"
+)
+
+// DefaultTurnoverDays is the period of turnover reports that do not ask for
+// one.
+const DefaultTurnoverDays = 90
+
+// Report is a report job. Reports are generated in the background: a job
+// is pending until a worker claims it, and its Result is set once it has
+// completed. Days is the period of turnover reports.
+type Report struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	Type        ReportType      `json:"type" gorm:"type:varchar(16);not null"`
+	Days        int             `json:"days,omitempty"`
+	Status      ReportStatus    `json:"status" gorm:"type:varchar(16);not null;index"`
+	ResultJSON  string          `json:"-" gorm:"column:result;type:text"`
+	Result      json.RawMessage `json:"result,omitempty" gorm:"-"`
+	Error       string          `json:"error,omitempty"`
+	CreatedBy   uint32          `json:"created_by"`
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+}
+
+// AfterFind exposes the stored result as raw JSON.
+func (r *Report) AfterFind(tx *gorm.DB) error {
+	if r.ResultJSON != "" {
+		r.Result = json.RawMessage(r.ResultJSON)
+	}
+	return nil
+}
+
+// CreateReportRequest asks for a report. Days is the period of a turnover
+// report, DefaultTurnoverDays if omitted.
+type CreateReportRequest struct {
+	Type ReportType `json:"type" binding:"required"`
+	Days int        `json:"days" binding:"min=0"`
+}
+
+// ValuationReport values the stock on hand. Values are in the minor units
+// of their currency, such as cents. Items ItemService does not know are
+// listed as missing and items without a price as unpriced; neither is
+// valued.
+type ValuationReport struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Categories  []*ValuationLine `json:"categories"`
+	Totals      []*ValuationLine `json:"totals"`
+	UnpricedIDs []string         `json:"unpriced_ids,omitempty"`
+	MissingIDs  []string         `json:"missing_ids,omitempty"`
+}
+
+// ValuationLine is the stock of a category, or of all categories in a
+// total, priced in one currency.
+type ValuationLine struct {
+	Category   string `json:"category,omitempty"`
+	Currency   string `json:"currency"`
+	Items      int    `json:"items"`
+	Units      int    `json:"units"`
+	ValueMinor int64  `json:"value_minor"`
+}
+
+// Records returns the report as CSV records, totals last.
+func (r *ValuationReport) Records() [][]string {
+	records := [][]string{{"category", "currency", "items", "units", "value_minor"}}
+	for _, line := range r.Categories {
+		records = append(records, line.record(line.Category))
+	}
+	for _, line := range r.Totals {
+		records = append(records, line.record("TOTAL"))
+	}
+	return records
+}
+
+func (l *ValuationLine) record(category string) []string {
+	return []string{
+		category,
+		l.Currency,
+		strconv.Itoa(l.Items),
+		strconv.Itoa(l.Units),
+		strconv.FormatInt(l.ValueMinor, 10),
+	}
+}
+
+// AgingReport splits each item's stock by age. Stock is assumed to leave
+// first in, first out, so what is on hand is what was received last;
+// stock the ledger does not account for is of unknown age.
+type AgingReport struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Items       []*AgingLine `json:"items"`
+}
+
+type AgingLine struct {
+	ItemID      string `json:"item_id"`
+	Stock       int    `json:"stock"`
+	Days0To30   int    `json:"days_0_30"`
+	Days31To60  int    `json:"days_31_60"`
+	Days61To90  int    `json:"days_61_90"`
+	Days91To180 int    `json:"days_91_180"`
+	Over180     int    `json:"over_180"`
+	Unknown     int    `json:"unknown,omitempty"`
+}
+
+// Add counts quantity as received the given number of days ago.
+func (l *AgingLine) Add(days, quantity int) {
+	switch {
+	case days <= 30:
+		l.Days0To30 += quantity
+	case days <= 60:
+		l.Days31To60 += quantity
+	case days <= 90:
+		l.Days61To90 += quantity
+	case days <= 180:
+		l.Days91To180 += quantity
+	default:
+		l.Over180 += quantity
+	}
+}
+
+func (r *AgingReport) Records() [][]string {
+	records := [][]string{{"item_id", "stock", "days_0_30", "days_31_60", "days_61_90", "days_91_180", "over_180", "unknown"}}
+	for _, line := range r.Items {
+		records = append(records, []string{
+			line.ItemID,
+			strconv.Itoa(line.Stock),
+			strconv.Itoa(line.Days0To30),
+			strconv.Itoa(line.Days31To60),
+			strconv.Itoa(line.Days61To90),
+			strconv.Itoa(line.Days91To180),
+			strconv.Itoa(line.Over180),
+			strconv.Itoa(line.Unknown),
+		})
+	}
+	return records
+}
+
+// TurnoverReport relates each item's sales over a period to its average
+// stock, the mean of its stock at the start and at the end. Turnover is how
+// many times the average stock was sold and DaysOfInventory how many days
+// of sales it lasts at that rate; both are zero for items not sold.
+type TurnoverReport struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	From        time.Time       `json:"from"`
+	Days        int             `json:"days"`
+	Items       []*TurnoverLine `json:"items"`
+}
+
+type TurnoverLine struct {
+	ItemID          string  `json:"item_id"`
+	OpeningStock    int     `json:"opening_stock"`
+	ClosingStock    int     `json:"closing_stock"`
+	AverageStock    float64 `json:"average_stock"`
+	Sold            int     `json:"sold"`
+	Turnover        float64 `json:"turnover"`
+	DaysOfInventory float64 `json:"days_of_inventory"`
+}
+
+func (r *TurnoverReport) Records() [][]string {
+	records := [][]string{{"item_id", "opening_stock", "closing_stock", "average_stock", "sold", "turnover", "days_of_inventory"}}
+	for _, line := range r.Items {
+		records = append(records, []string{
+			line.ItemID,
+			strconv.Itoa(line.OpeningStock),
+			strconv.Itoa(line.ClosingStock),
+			strconv.FormatFloat(line.AverageStock, 'f', 2, 64),
+			strconv.Itoa(line.Sold),
+			strconv.FormatFloat(line.Turnover, 'f', 2, 64),
+			strconv.FormatFloat(line.DaysOfInventory, 'f', 1, 64),
+		})
+	}
+	return records
+}
+
+type ReportResponse struct {
+	Message string  `json:"message"`
+	Success bool    `json:"success"`
+	Data    *Report `json:"data,omitempty"`
+}
+
+type ReportsResponse struct {
+	Message string    `json:"message"`
+	Success bool      `json:"success"`
+	Data    []*Report `json:"data"`
+}
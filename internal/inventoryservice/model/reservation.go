@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "active"
+	ReservationConfirmed ReservationStatus = "confirmed"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+// Limits on how long a reservation may hold stock.
+const (
+	DefaultReservationTTL = 15 * time.Minute
+	MaxReservationTTL     = 24 * time.Hour
+)
+
+// Reservation holds stock for a checkout until it is confirmed, released or
+// expires. Active reservations are counted in Inventory.Reserved; confirming
+// one removes its quantity from stock.
+type Reservation struct {
+	ID       uint              `json:"id" gorm:"primaryKey"`
+	ItemID   string            `json:"item_id" gorm:"not null;index"`
+	Quantity int               `json:"quantity" gorm:"not null"`
+	Status   ReservationStatus `json:"status" gorm:"type:varchar(16);not null;index:idx_reservations_status_expires,priority:1"`
+	// IdempotencyKey identifies the client request that created the
+	// reservation, so that a retried request returns it instead of
+	// reserving again. Keys are scoped to the user sending them.
+	IdempotencyKey string     `json:"idempotency_key,omitempty" gorm:"size:128;uniqueIndex:idx_reservations_user_idempotency_key,priority:2,where:idempotency_key <> ''"`
+	Reference      string     `json:"reference,omitempty"`
+	UserID         uint32     `json:"user_id" gorm:"uniqueIndex:idx_reservations_user_idempotency_key,priority:1"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null;index:idx_reservations_status_expires,priority:2"`
+	ConfirmedAt    *time.Time `json:"confirmed_at,omitempty"`
+	ReleasedAt     *time.Time `json:"released_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// CreateReservationRequest reserves Quantity of an item for TTLSeconds, 15
+// minutes unless given.
+type CreateReservationRequest struct {
+	ItemID         string `json:"item_id" binding:"required"`
+	Quantity       int    `json:"quantity" binding:"required,min=1"`
+	TTLSeconds     int    `json:"ttl_seconds" binding:"min=0"`
+	IdempotencyKey string `json:"idempotency_key" binding:"max=128"`
+	Reference      string `json:"reference"`
+}
+
+type ReservationResponse struct {
+	Message string       `json:"message"`
+	Success bool         `json:"success"`
+	Data    *Reservation `json:"data,omitempty"`
+}
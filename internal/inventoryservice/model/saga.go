@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+type SagaType string
+
+const (
+	// SagaUpdateStock sets an item's stock and then syncs it to ItemService.
+	// It is compensated by reverting the stock change.
+	SagaUpdateStock SagaType = "update_stock"
+	// SagaDeleteItem deletes an item's inventory record, then the item in
+	// ItemService and finally the item's lots and stock at locations. It is
+	// compensated by restoring the inventory record. Deleting the item comes
+	// last as it cannot be undone.
+	SagaDeleteItem SagaType = "delete_item"
+	// SagaSyncStock syncs to ItemService a stock change that stands once
+	// committed, such as a sale, a receipt or a count adjustment. It is not
+	// compensated, as the goods have moved; the sync is retried instead.
+	SagaSyncStock SagaType = "sync_stock"
+)
+
+type SagaStatus string
+
+const (
+	SagaStarted      SagaStatus = "started"
+	SagaCompensating SagaStatus = "compensating"
+	SagaCompleted    SagaStatus = "completed"
+	SagaCompensated  SagaStatus = "compensated"
+	// SagaFailed sagas could neither complete nor be compensated and need
+	// someone to look at them.
+	SagaFailed SagaStatus = "failed"
+)
+
+// Valid reports whether s is a known saga status.
+func (s SagaStatus) Valid() bool {
+	switch s {
+	case SagaStarted, SagaCompensating, SagaCompleted, SagaCompensated, SagaFailed:
+		return true
+	}
+	return false
+}
+
+// SagaStep is the last step a saga has completed.
+type SagaStep string
+
+const (
+	SagaStepStockUpdated     SagaStep = "stock_updated"
+	SagaStepStockReverted    SagaStep = "stock_reverted"
+	SagaStepItemSynced       SagaStep = "item_synced"
+	SagaStepInventoryDeleted SagaStep = "inventory_deleted"
+	SagaStepItemDeleted      SagaStep = "item_deleted"
+	SagaStepPurged           SagaStep = "purged"
+	SagaStepRestored         SagaStep = "restored"
+)
+
+// Saga is an operation spanning the inventory database and ItemService.
+// Each step is recorded as it completes, so that the recovery worker can
+// resume sagas interrupted by a crash, completing or compensating them.
+// PreviousStock and NewStock are the stock before and after the change.
+type Saga struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Type          SagaType   `json:"type" gorm:"type:varchar(16);not null"`
+	ItemID        string     `json:"item_id" gorm:"not null;index"`
+	Status        SagaStatus `json:"status" gorm:"type:varchar(16);not null;index"`
+	Step          SagaStep   `json:"step" gorm:"type:varchar(32);not null"`
+	PreviousStock int        `json:"previous_stock"`
+	NewStock      int        `json:"new_stock"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	LastError     string     `json:"last_error,omitempty"`
+	UserID        uint32     `json:"user_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"index"`
+}
+
+type SagasResponse struct {
+	Message string  `json:"message"`
+	Success bool    `json:"success"`
+	Data    []*Saga `json:"data"`
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCountNotFound     = errors.New("count not found")
+	ErrCountLineNotFound = errors.New("count line not found")
+	ErrCountClosed       = errors.New("count is already closed")
+	ErrCountEmpty        = errors.New("count sheet has no lines")
+	// ErrCountLineApproved is returned when an approved line would be
+	// counted or approved again.
+	ErrCountLineApproved = errors.New("count line is already approved")
+	// ErrCountLineNotCounted is returned when a line would be approved
+	// before it was counted.
+	ErrCountLineNotCounted = errors.New("count line has not been counted")
+	// ErrSupervisorRequired is returned when a line whose variance exceeds
+	// the session's limit would be approved by someone who is not a
+	// supervisor.
+	ErrSupervisorRequired = errors.New("forbidden: variances above the limit need a supervisor's approval")
+	// ErrReasonRequired is returned when a variance would be posted without
+	// a reason code.
+	ErrReasonRequired = errors.New("reason_code is required to post variances")
+)
+
+// CountRepository stores stock count sessions. Approving counts posts their
+// variances through the InventoryRepository.
+type CountRepository struct {
+	db          *gorm.DB
+	inventories *InventoryRepository
+}
+
+func NewCountRepository(db *gorm.DB, inventories *InventoryRepository) *CountRepository {
+	return &CountRepository{db: db, inventories: inventories}
+}
+
+// Create starts session with a count sheet of the given items and of every
+// item stocked at the given locations, snapshotting their stock in one
+// transaction. Items without an inventory record are listed with no stock.
+func (r *CountRepository) Create(session *model.CountSession, itemIDs []string, locationIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		session.Status = model.CountOpen
+		session.Lines = nil
+
+		seen := make(map[string]bool)
+		for _, itemID := range itemIDs {
+			if seen[itemID] {
+				continue
+			}
+			seen[itemID] = true
+
+			var stock int
+			err := tx.Model(&model.Inventory{}).
+				Where("item_id = ?", itemID).
+				Select("COALESCE(SUM(stock), 0)").
+				Scan(&stock).Error
+			if err != nil {
+				return err
+			}
+			session.Lines = append(session.Lines, &model.CountLine{
+				ItemID:        itemID,
+				SnapshotStock: stock,
+				Status:        model.CountLinePending,
+			})
+		}
+
+		for _, locationID := range locationIDs {
+			if err := requireLocation(tx, locationID); err != nil {
+				return err
+			}
+
+			var stocks []*model.LocationStock
+			err := tx.Where("location_id = ? AND quantity <> 0", locationID).Order("item_id").Find(&stocks).Error
+			if err != nil {
+				return err
+			}
+			for _, stock := range stocks {
+				locationID := stock.LocationID
+				session.Lines = append(session.Lines, &model.CountLine{
+					ItemID:        stock.ItemID,
+					LocationID:    &locationID,
+					SnapshotStock: stock.Quantity,
+					Status:        model.CountLinePending,
+				})
+			}
+		}
+
+		if len(session.Lines) == 0 {
+			return ErrCountEmpty
+		}
+		return tx.Create(session).Error
+	})
+}
+
+// GetByID returns a session with its lines.
+func (r *CountRepository) GetByID(id uint) (*model.CountSession, error) {
+	var session model.CountSession
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&session, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetAll lists sessions without their lines, newest first, optionally only
+// those in one status.
+func (r *CountRepository) GetAll(status model.CountStatus) ([]*model.CountSession, error) {
+	query := r.db.Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var sessions []*model.CountSession
+	err := query.Find(&sessions).Error
+	return sessions, err
+}
+
+// Submit records counted quantities, keyed by line ID, and their variances
+// against the snapshot.
+func (r *CountRepository) Submit(id uint, counts map[uint]int, userID uint32) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		session, err := lockOpenCount(tx, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for lineID, quantity := range counts {
+			var line model.CountLine
+			err := tx.Where("id = ? AND session_id = ?", lineID, id).First(&line).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %d", ErrCountLineNotFound, lineID)
+			}
+			if err != nil {
+				return err
+			}
+			if line.Status == model.CountLineApproved {
+				return fmt.Errorf("%w: %d", ErrCountLineApproved, lineID)
+			}
+
+			quantity := quantity
+			line.CountedQuantity = &quantity
+			line.Variance = quantity - line.SnapshotStock
+			line.NeedsSupervisor = line.Variance > session.VarianceLimit || -line.Variance > session.VarianceLimit
+			line.Status = model.CountLineCounted
+			line.CountedBy = userID
+			line.CountedAt = &now
+			if err := tx.Save(&line).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Approve approves counted lines of a session, all of them if lineIDs is
+// empty, and posts their variances as count adjustments with reason, in one
+// transaction. Lines with a variance fail with ErrReasonRequired without a
+// reason, and lines needing a supervisor with ErrSupervisorRequired unless
+// supervisor is set. The session is closed once every line is approved. It
+// returns the stock changes, each with the sync_stock saga started for it.
+func (r *CountRepository) Approve(id uint, lineIDs []uint, reason model.VarianceReason, userID uint32, supervisor bool) ([]*StockChange, error) {
+	var changes stockChanges
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		session, err := lockOpenCount(tx, id)
+		if err != nil {
+			return err
+		}
+
+		// Post in item order so that concurrent approvals lock inventory
+		// rows in the same order
+		query := tx.Where("session_id = ?", id).Order("item_id, id")
+		if len(lineIDs) > 0 {
+			query = query.Where("id IN ?", lineIDs)
+		} else {
+			query = query.Where("status = ?", model.CountLineCounted)
+		}
+		var lines []*model.CountLine
+		if err := query.Find(&lines).Error; err != nil {
+			return err
+		}
+		found := make(map[uint]bool, len(lines))
+		for _, line := range lines {
+			found[line.ID] = true
+		}
+		for _, lineID := range lineIDs {
+			if !found[lineID] {
+				return fmt.Errorf("%w: %d", ErrCountLineNotFound, lineID)
+			}
+		}
+
+		now := time.Now()
+		for _, line := range lines {
+			switch line.Status {
+			case model.CountLineApproved:
+				return fmt.Errorf("%w: %d", ErrCountLineApproved, line.ID)
+			case model.CountLinePending:
+				return fmt.Errorf("%w: %d", ErrCountLineNotCounted, line.ID)
+			}
+			if line.NeedsSupervisor && !supervisor {
+				return fmt.Errorf("%w: line %d is off by %d", ErrSupervisorRequired, line.ID, line.Variance)
+			}
+
+			if line.Variance != 0 {
+				if reason == "" {
+					return fmt.Errorf("%w: line %d is off by %d", ErrReasonRequired, line.ID, line.Variance)
+				}
+				inventory, err := r.inventories.adjustStock(tx, line.ItemID, line.Variance, &model.StockMovement{
+					Type:       model.MovementCountAdjustment,
+					LocationID: line.LocationID,
+					Reason:     string(reason),
+					Reference:  fmt.Sprintf("count-%d", session.ID),
+					UserID:     userID,
+				})
+				if err != nil {
+					return fmt.Errorf("line %d: %w", line.ID, err)
+				}
+				changes.add(inventory, line.Variance, userID)
+				line.ReasonCode = reason
+			}
+
+			line.Status = model.CountLineApproved
+			line.ApprovedBy = userID
+			line.ApprovedAt = &now
+			if err := tx.Save(line).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := changes.start(tx); err != nil {
+			return err
+		}
+
+		var remaining int64
+		err = tx.Model(&model.CountLine{}).
+			Where("session_id = ? AND status <> ?", id, model.CountLineApproved).
+			Count(&remaining).Error
+		if err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return closeCount(tx, session, userID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes.changes, nil
+}
+
+// Close ends a session; lines not approved by then are never posted.
+func (r *CountRepository) Close(id uint, userID uint32) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		session, err := lockOpenCount(tx, id)
+		if err != nil {
+			return err
+		}
+		return closeCount(tx, session, userID)
+	})
+}
+
+// lockOpenCount locks a session that is still open.
+func lockOpenCount(tx *gorm.DB, id uint) (*model.CountSession, error) {
+	var session model.CountSession
+	err := lockForUpdate(tx).First(&session, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != model.CountOpen {
+		return nil, ErrCountClosed
+	}
+	return &session, nil
+}
+
+func closeCount(tx *gorm.DB, session *model.CountSession, userID uint32) error {
+	now := time.Now()
+	return tx.Model(session).Updates(map[string]interface{}{
+		"status":    model.CountClosed,
+		"closed_by": userID,
+		"closed_at": now,
+	}).Error
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+func TestCountPostsVariancesAgainstSnapshot(t *testing.T) {
+	inventories := newTestRepository(t)
+	locations := NewLocationRepository(inventories.db)
+	counts := NewCountRepository(inventories.db, inventories)
+	shelf := createLocation(t, locations, "north", "a", "1")
+
+	for _, receipt := range []struct {
+		itemID   string
+		location *uint
+		quantity int
+	}{{"lamp", nil, 50}, {"desk", &shelf.ID, 8}, {"chair", &shelf.ID, 30}} {
+		movement := &model.StockMovement{Type: model.MovementReceipt, LocationID: receipt.location}
+		if _, err := inventories.AdjustStock(receipt.itemID, receipt.quantity, movement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	session := &model.CountSession{VarianceLimit: 5}
+	if err := counts.Create(session, []string{"lamp", "lamp"}, []uint{shelf.ID}); err != nil {
+		t.Fatal(err)
+	}
+	lines := make(map[string]*model.CountLine)
+	for _, line := range session.Lines {
+		lines[line.ItemID] = line
+	}
+	if len(session.Lines) != 3 || lines["lamp"].SnapshotStock != 50 || lines["desk"].SnapshotStock != 8 {
+		t.Fatalf("count sheet = %+v, want lamp 50, desk 8 and chair", session.Lines)
+	}
+
+	// Sales go on while the count is under way
+	if _, err := inventories.AdjustStock("lamp", -10, &model.StockMovement{Type: model.MovementSale}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := counts.Submit(session.ID, map[uint]int{lines["lamp"].ID: 47, lines["desk"].ID: 8, lines["chair"].ID: 20}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := counts.Approve(session.ID, nil, model.VarianceDamaged, 1, false); !errors.Is(err, ErrSupervisorRequired) {
+		t.Fatalf("approval of a large variance: err = %v, want ErrSupervisorRequired", err)
+	}
+	if _, err := counts.Approve(session.ID, []uint{lines["lamp"].ID}, "", 1, false); !errors.Is(err, ErrReasonRequired) {
+		t.Fatalf("approval without a reason: err = %v, want ErrReasonRequired", err)
+	}
+
+	changed, err := counts.Approve(session.ID, []uint{lines["lamp"].ID, lines["desk"].ID}, model.VarianceMiscount, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 {
+		t.Errorf("%d inventories changed, want 1", len(changed))
+	}
+	assertStock(t, inventories, "lamp", 37)
+	assertStock(t, inventories, "desk", 8)
+
+	if _, err := counts.Approve(session.ID, nil, model.VarianceTheft, 2, true); err != nil {
+		t.Fatal(err)
+	}
+	assertStock(t, inventories, "chair", 20)
+	if got := locationQuantities(t, locations, "chair"); got[shelf.ID] != 20 {
+		t.Errorf("chair on the shelf = %d, want 20", got[shelf.ID])
+	}
+
+	movements, err := inventories.GetMovements("chair", model.MovementFilter{Type: model.MovementCountAdjustment})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movements) != 1 || movements[0].Quantity != -10 || movements[0].Reason != string(model.VarianceTheft) {
+		t.Errorf("count adjustments = %+v, want -10 for theft", movements)
+	}
+
+	session, err = counts.GetByID(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Status != model.CountClosed {
+		t.Errorf("status = %s, want closed once every line is approved", session.Status)
+	}
+	if err := counts.Submit(session.ID, map[uint]int{lines["lamp"].ID: 1}, 1); !errors.Is(err, ErrCountClosed) {
+		t.Errorf("count after close: err = %v, want ErrCountClosed", err)
+	}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"rancher-manager/idempotency"
+	"rancher-manager/internal/inventoryservice/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository stores the idempotency keys of REST requests, shared
+// by all instances.
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Begin claims key, replacing it if it has expired. It returns the record
+// holding key if another request claimed it first.
+func (r *IdempotencyRepository) Begin(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*idempotency.Record, error) {
+	db := r.db.WithContext(ctx)
+
+	// The held key may be released between the insert and the read, so try
+	// to claim it once more before giving up
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := db.Where("expires_at <= ?", time.Now()).Delete(&model.IdempotencyKey{Key: key}).Error; err != nil {
+			return nil, err
+		}
+
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.IdempotencyKey{
+			Key:         key,
+			Fingerprint: fingerprint,
+			ExpiresAt:   expiresAt,
+		})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var held model.IdempotencyKey
+		err := db.Where(&model.IdempotencyKey{Key: key}).First(&held).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &idempotency.Record{
+			Key:         held.Key,
+			Fingerprint: held.Fingerprint,
+			Status:      held.Status,
+			ContentType: held.ContentType,
+			Body:        held.Body,
+			ExpiresAt:   held.ExpiresAt,
+		}, nil
+	}
+	return nil, errors.New("idempotency key is being claimed and released concurrently")
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, status int, contentType string, body []byte, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.IdempotencyKey{Key: key}).Updates(map[string]interface{}{
+		"status":       status,
+		"content_type": contentType,
+		"body":         body,
+		"expires_at":   expiresAt,
+	}).Error
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Delete(&model.IdempotencyKey{Key: key}).Error
+}
+
+// DeleteExpired deletes the keys that expired before the given time.
+func (r *IdempotencyRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&model.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeyIsHeldUntilItExpires(t *testing.T) {
+	keys := NewIdempotencyRepository(newTestRepository(t).db)
+	ctx := context.Background()
+	now := time.Now()
+
+	if held, err := keys.Begin(ctx, "1:scan-1", "a", now.Add(time.Minute)); err != nil || held != nil {
+		t.Fatalf("first claim: held = %v, err = %v, want claimed", held, err)
+	}
+	held, err := keys.Begin(ctx, "1:scan-1", "b", now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if held == nil || held.Fingerprint != "a" || held.Completed() {
+		t.Fatalf("second claim: held = %+v, want the first request in progress", held)
+	}
+
+	if err := keys.Complete(ctx, "1:scan-1", 201, "application/json", []byte(`{"success":true}`), now.Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if held, err := keys.Begin(ctx, "1:scan-2", "c", now.Add(time.Minute)); err != nil || held != nil {
+		t.Fatalf("other key: held = %v, err = %v, want claimed", held, err)
+	}
+	if err := keys.Complete(ctx, "1:scan-2", 201, "application/json", []byte(`{"success":true}`), now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	held, err = keys.Begin(ctx, "1:scan-2", "c", now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if held == nil || held.Status != 201 || string(held.Body) != `{"success":true}` {
+		t.Errorf("completed key: held = %+v, want the stored response", held)
+	}
+
+	// The expired key is claimed afresh
+	if held, err := keys.Begin(ctx, "1:scan-1", "d", now.Add(time.Minute)); err != nil || held != nil {
+		t.Errorf("expired key: held = %v, err = %v, want claimed", held, err)
+	}
+	if err := keys.Release(ctx, "1:scan-1"); err != nil {
+		t.Fatal(err)
+	}
+	if held, err := keys.Begin(ctx, "1:scan-1", "e", now.Add(-time.Second)); err != nil || held != nil {
+		t.Errorf("released key: held = %v, err = %v, want claimed", held, err)
+	}
+
+	deleted, err := keys.DeleteExpired(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Errorf("deleted %d expired keys, want 1", deleted)
+	}
+}
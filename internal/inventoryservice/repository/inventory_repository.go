@@ -1,17 +1,77 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"rancher-manager/internal/inventoryservice/model"
+	"rancher-manager/kafka"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInsufficientStock is returned when an adjustment or reservation
+	// needs more stock than is available.
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrAboveMaxStock is returned when RejectAboveMax is set and a change
+	// would raise stock above the item's MaxStock.
+	ErrAboveMaxStock = errors.New("stock would exceed the maximum")
+	// ErrStockInTransit is returned when stock would be set below what is
+	// in transit between locations.
+	ErrStockInTransit = errors.New("stock cannot be set below the quantity in transit")
+	// ErrInvalidThresholds is returned when MaxStock would be below MinStock.
+	ErrInvalidThresholds = errors.New("max_stock must not be below min_stock")
+	// ErrStockChanged is returned by ReconcileStock when the stock is no
+	// longer what it was expected to be.
+	ErrStockChanged = errors.New("stock has changed since it was read")
 )
 
+// availableStock is the SQL expression for what is left of an item's stock
+// to promise, see model.Inventory.
+const availableStock = "stock - reserved - in_transit - held"
+
+// StockLimits are the thresholds given to new inventory records and how
+// MaxStock is enforced.
+type StockLimits struct {
+	DefaultMinStock int
+	DefaultMaxStock int
+	// RejectAboveMax makes raising stock above MaxStock fail. Otherwise it
+	// is allowed and only reported.
+	RejectAboveMax bool
+}
+
+// defaultLeadTimeDays is the replenishment lead time of new records.
+const defaultLeadTimeDays = 7
+
+// DefaultStockLimits are the limits of a new InventoryRepository.
+var DefaultStockLimits = StockLimits{DefaultMinStock: 0, DefaultMaxStock: 1000}
+
 type InventoryRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	limits StockLimits
 }
 
 func NewInventoryRepository(db *gorm.DB) *InventoryRepository {
-	return &InventoryRepository{db: db}
+	return &InventoryRepository{db: db, limits: DefaultStockLimits}
+}
+
+// SetLimits replaces the stock limits.
+func (r *InventoryRepository) SetLimits(limits StockLimits) {
+	r.limits = limits
+}
+
+// newInventory returns a record with the default thresholds for an item
+// that has none.
+func (r *InventoryRepository) newInventory(itemID string) model.Inventory {
+	return model.Inventory{
+		ItemID:       itemID,
+		MinStock:     r.limits.DefaultMinStock,
+		MaxStock:     r.limits.DefaultMaxStock,
+		LeadTimeDays: defaultLeadTimeDays,
+	}
 }
 
 func (r *InventoryRepository) Create(inventory *model.Inventory) error {
@@ -27,12 +87,39 @@ func (r *InventoryRepository) GetByItemID(itemID string) (*model.Inventory, erro
 	return &inventory, nil
 }
 
+// GetByItemIDs returns the inventory records of the given items that have
+// one.
+func (r *InventoryRepository) GetByItemIDs(itemIDs []string) ([]*model.Inventory, error) {
+	var inventories []*model.Inventory
+	err := r.db.Where("item_id IN ?", itemIDs).Find(&inventories).Error
+	return inventories, err
+}
+
 func (r *InventoryRepository) Update(inventory *model.Inventory) error {
 	return r.db.Save(inventory).Error
 }
 
-func (r *InventoryRepository) Delete(itemID string) error {
-	return r.db.Where("item_id = ?", itemID).Delete(&model.Inventory{}).Error
+// Delete deletes an item's inventory record together with its stock at
+// locations and its lots, reporting the deletion by userID.
+func (r *InventoryRepository) Delete(itemID string, userID uint32) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// The inventory row is locked first, as in every stock change, so
+		// that no stock is moved to a location or lot while they are deleted
+		var inventories []*model.Inventory
+		if err := lockForUpdate(tx).Where("item_id = ?", itemID).Limit(1).Find(&inventories).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("item_id = ?", itemID).Delete(&model.Inventory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("item_id = ?", itemID).Delete(&model.LocationStock{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("item_id = ?", itemID).Delete(&model.Lot{}).Error; err != nil {
+			return err
+		}
+		return enqueue(tx, &kafka.ItemDeleteEvent{ItemID: itemID, UserID: userID})
+	})
 }
 
 func (r *InventoryRepository) GetAll() ([]*model.Inventory, error) {
@@ -41,21 +128,460 @@ func (r *InventoryRepository) GetAll() ([]*model.Inventory, error) {
 	return inventories, err
 }
 
-func (r *InventoryRepository) UpdateStock(itemID string, newStock int, userID uint32) error {
-	var inventory model.Inventory
-	err := r.db.Where("item_id = ?", itemID).First(&inventory).Error
+// Page lists up to limit inventory records in item ID order, starting
+// after the item ID after.
+func (r *InventoryRepository) Page(after string, limit int) ([]*model.Inventory, error) {
+	var inventories []*model.Inventory
+	err := r.db.Where("item_id > ?", after).Order("item_id").Limit(limit).Find(&inventories).Error
+	return inventories, err
+}
+
+// KnownItemIDs returns which of the given items have an inventory record,
+// counting records that are deleted but may still be restored by a saga.
+func (r *InventoryRepository) KnownItemIDs(itemIDs []string) (map[string]bool, error) {
+	var known []string
+	err := r.db.Unscoped().Model(&model.Inventory{}).
+		Where("item_id IN ?", itemIDs).
+		Pluck("item_id", &known).Error
 	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(known))
+	for _, itemID := range known {
+		result[itemID] = true
+	}
+	return result, nil
+}
+
+// orphanedStock selects the item IDs and quantities of table's rows whose
+// item has no inventory record, not even a deleted one.
+const orphanedStock = `
+	SELECT s.item_id, SUM(s.quantity) AS quantity FROM %s s
+	WHERE NOT EXISTS (SELECT 1 FROM inventories i WHERE i.item_id = s.item_id)
+	GROUP BY s.item_id`
+
+// OrphanedStock returns the items with stock at locations or in lots but no
+// inventory record, with the quantity at locations or, if larger, in lots.
+func (r *InventoryRepository) OrphanedStock() (map[string]int, error) {
+	orphans := map[string]int{}
+	for _, table := range []string{"location_stocks", "lots"} {
+		var rows []struct {
+			ItemID   string
+			Quantity int
+		}
+		if err := r.db.Raw(fmt.Sprintf(orphanedStock, table)).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			orphans[row.ItemID] = max(orphans[row.ItemID], row.Quantity)
+		}
+	}
+	return orphans, nil
+}
+
+// DeleteOrphanedStock deletes an item's stock at locations and its lots if
+// the item has no inventory record.
+func (r *InventoryRepository) DeleteOrphanedStock(itemID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&model.Inventory{}).Where("item_id = ?", itemID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrStockChanged
+		}
+
+		if err := tx.Where("item_id = ?", itemID).Delete(&model.LocationStock{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("item_id = ?", itemID).Delete(&model.Lot{}).Error
+	})
+}
+
+// ReconcileStock sets an item's stock like SetStock, provided it is still
+// expected, failing with ErrStockChanged otherwise. An item without an
+// inventory record has no stock.
+func (r *InventoryRepository) ReconcileStock(itemID string, expected, newStock int, movement *model.StockMovement) (*model.Inventory, error) {
+	var inventory *model.Inventory
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var current model.Inventory
+		err := lockForUpdate(tx).Where("item_id = ?", itemID).First(&current).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if current.Stock != expected {
+			return ErrStockChanged
+		}
+
+		inventory, _, err = r.setStock(tx, itemID, newStock, movement)
 		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// SetStock sets an item's stock to newStock and appends the difference to
+// the ledger as movement, in one transaction, allocating it over locations
+// like AdjustStock. The inventory record is created if the item has none.
+// Nothing is recorded when the stock does not change.
+func (r *InventoryRepository) SetStock(itemID string, newStock int, movement *model.StockMovement) (*model.Inventory, error) {
+	var inventory *model.Inventory
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		inventory, _, err = r.setStock(tx, itemID, newStock, movement)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// setStock is SetStock within tx. It also returns the stock before.
+func (r *InventoryRepository) setStock(tx *gorm.DB, itemID string, newStock int, movement *model.StockMovement) (*model.Inventory, int, error) {
+	var inventory model.Inventory
+	err := lockForUpdate(tx).Where("item_id = ?", itemID).First(&inventory).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		inventory = r.newInventory(itemID)
+	case err != nil:
+		return nil, 0, err
+	}
+
+	if r.limits.RejectAboveMax && newStock > inventory.MaxStock && newStock > inventory.Stock {
+		return nil, 0, ErrAboveMaxStock
+	}
+	if newStock < inventory.InTransit {
+		return nil, 0, ErrStockInTransit
 	}
 
+	previous := inventory.Stock
 	inventory.Stock = newStock
+	inventory.UpdatedBy = movement.UserID
+	if err := tx.Save(&inventory).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := allocate(tx, &inventory, newStock-previous, movement); err != nil {
+		return nil, 0, err
+	}
+	if err := enqueueStockUpdate(tx, &inventory, movement.UserID); err != nil {
+		return nil, 0, err
+	}
+	return &inventory, previous, nil
+}
+
+// AdjustStock changes an item's stock by delta and appends the change to
+// the ledger as movement, in one transaction. The stock is changed with a
+// single UPDATE ... SET stock = stock + delta, so concurrent adjustments are
+// never lost. A decrement matches no row when it would take stock below what
+// is reserved, in transit or held, in which case ErrInsufficientStock is
+// returned, and with RejectAboveMax an increment matches none when it would
+// exceed MaxStock. An increment creates the inventory record if the item has
+// none. The change is allocated to the movement's location and lot, see
+// allocate; stock added to or taken from a held lot is held or released
+// with it.
+func (r *InventoryRepository) AdjustStock(itemID string, delta int, movement *model.StockMovement) (*model.Inventory, error) {
+	var inventory *model.Inventory
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		inventory, err = r.adjustStock(tx, itemID, delta, movement)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// adjustStock is AdjustStock within the transaction tx.
+func (r *InventoryRepository) adjustStock(tx *gorm.DB, itemID string, delta int, movement *model.StockMovement) (*model.Inventory, error) {
+	held := false
+	if movement.LotID != nil {
+		// Lock the inventory row before the lot, like every change that
+		// touches lots, so that the lot cannot be held or released
+		// until this transaction ends
+		if err := lockForUpdate(tx).Where("item_id = ?", itemID).Find(&[]model.Inventory{}).Error; err != nil {
+			return nil, err
+		}
+		lot, err := lockLot(tx, itemID, *movement.LotID)
+		if err != nil {
+			return nil, err
+		}
+		held = lot.Status.Held()
+	}
+
+	updates := map[string]interface{}{
+		"stock":      gorm.Expr("stock + ?", delta),
+		"updated_by": movement.UserID,
+	}
+	query := tx.Model(&model.Inventory{}).Where("item_id = ?", itemID)
+	switch {
+	case held:
+		// Held stock is not available but may still be written off
+		updates["held"] = gorm.Expr("held + ?", delta)
+		if delta < 0 {
+			query = query.Where("held + ? >= 0", delta)
+		}
+	case delta < 0:
+		// Reserved stock is spoken for
+		query = query.Where(availableStock+" + ? >= 0", delta)
+	}
+	if delta > 0 && r.limits.RejectAboveMax {
+		query = query.Where("stock + ? <= max_stock", delta)
+	}
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if delta < 0 {
+			return nil, ErrInsufficientStock
+		}
+		if err := r.createForIncrement(tx, itemID, delta, held, movement.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	// The row stays locked by the update until the transaction ends
+	var inventory model.Inventory
+	if err := tx.Where("item_id = ?", itemID).First(&inventory).Error; err != nil {
+		return nil, err
+	}
+
+	if err := allocate(tx, &inventory, delta, movement); err != nil {
+		return nil, err
+	}
+	if err := enqueueStockUpdate(tx, &inventory, movement.UserID); err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
+// createForIncrement creates the inventory record of an item whose first
+// stock arrives with an increment. The increment failed to update an
+// existing record either because there is none or because it would exceed
+// MaxStock; another increment may also create the record first, in which
+// case this one is added to it. held means the increment goes to a held
+// lot.
+func (r *InventoryRepository) createForIncrement(tx *gorm.DB, itemID string, delta int, held bool, userID uint32) error {
+	inventory := r.newInventory(itemID)
+	inventory.Stock = delta
+	if held {
+		inventory.Held = delta
+	}
 	inventory.UpdatedBy = userID
+	if r.limits.RejectAboveMax && delta > inventory.MaxStock {
+		return ErrAboveMaxStock
+	}
+
+	onConflict := clause.OnConflict{
+		Columns: []clause.Column{{Name: "item_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"stock":      gorm.Expr("inventories.stock + excluded.stock"),
+			"held":       gorm.Expr("inventories.held + excluded.held"),
+			"updated_by": gorm.Expr("excluded.updated_by"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}
+	if r.limits.RejectAboveMax {
+		onConflict.Where = clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "inventories.stock + excluded.stock <= inventories.max_stock"},
+		}}
+	}
+
+	result := tx.Clauses(onConflict).Create(&inventory)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAboveMaxStock
+	}
+	return nil
+}
+
+// SetThresholds changes the thresholds given in req, creating the item's
+// inventory record if it has none.
+func (r *InventoryRepository) SetThresholds(itemID string, req *model.UpdateThresholdsRequest, userID uint32) (*model.Inventory, error) {
+	var inventory model.Inventory
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := lockForUpdate(tx).Where("item_id = ?", itemID).First(&inventory).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			inventory = r.newInventory(itemID)
+		case err != nil:
+			return err
+		}
+
+		if req.MinStock != nil {
+			inventory.MinStock = *req.MinStock
+		}
+		if req.MaxStock != nil {
+			inventory.MaxStock = *req.MaxStock
+		}
+		if req.LeadTimeDays != nil {
+			inventory.LeadTimeDays = *req.LeadTimeDays
+		}
+		if inventory.MaxStock < inventory.MinStock {
+			return ErrInvalidThresholds
+		}
+
+		inventory.UpdatedBy = userID
+		return tx.Save(&inventory).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
+// MarkLowStock records that a low-stock alert was raised for an item and
+// writes event to the outbox, in one transaction. It returns false, writing
+// nothing, if one had already been raised, so that each drop below MinStock
+// is alerted once.
+func (r *InventoryRepository) MarkLowStock(event *kafka.StockLowEvent, at time.Time) (bool, error) {
+	marked := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Inventory{}).
+			Where("item_id = ? AND low_stock_since IS NULL", event.ItemID).
+			UpdateColumn("low_stock_since", at)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		marked = true
+		return enqueue(tx, event)
+	})
+	return marked, err
+}
+
+// ClearLowStock forgets the low-stock alert of an item that recovered.
+func (r *InventoryRepository) ClearLowStock(itemID string) error {
+	return r.db.Model(&model.Inventory{}).
+		Where("item_id = ? AND low_stock_since IS NOT NULL", itemID).
+		UpdateColumn("low_stock_since", nil).Error
+}
+
+// Consumption returns how much of each item was sold or written off since
+// the given time, for the given items or all of them.
+func (r *InventoryRepository) Consumption(since time.Time, itemIDs ...string) (map[string]int, error) {
+	query := r.db.Model(&model.StockMovement{}).
+		Select("item_id, -SUM(quantity) AS consumed").
+		Where("type IN ? AND created_at >= ?", []model.MovementType{model.MovementSale, model.MovementShrinkage}, since)
+	if len(itemIDs) > 0 {
+		query = query.Where("item_id IN ?", itemIDs)
+	}
+
+	var rows []struct {
+		ItemID   string
+		Consumed int
+	}
+	err := query.Group("item_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	consumption := make(map[string]int, len(rows))
+	for _, row := range rows {
+		consumption[row.ItemID] = row.Consumed
+	}
+	return consumption, nil
+}
+
+// MovementTotals sums an item's ledger entries over a period.
+type MovementTotals struct {
+	// Net is the change in stock.
+	Net int
+	// Sold is the quantity sold; returns are not deducted.
+	Sold int
+}
+
+// Totals returns the MovementTotals of every item with ledger entries since
+// the given time.
+func (r *InventoryRepository) Totals(since time.Time) (map[string]MovementTotals, error) {
+	var rows []struct {
+		ItemID string
+		Net    int
+		Sold   int
+	}
+	err := r.db.Model(&model.StockMovement{}).
+		Select("item_id, SUM(quantity) AS net, -SUM(CASE WHEN type = ? THEN quantity ELSE 0 END) AS sold", model.MovementSale).
+		Where("created_at >= ?", since).
+		Group("item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
 
-	return r.db.Save(&inventory).Error
+	totals := make(map[string]MovementTotals, len(rows))
+	for _, row := range rows {
+		totals[row.ItemID] = MovementTotals{Net: row.Net, Sold: row.Sold}
+	}
+	return totals, nil
 }
 
-func (r *InventoryRepository) ExistsByItemID(itemID string) (bool, error) {
-	var count int64
-	err := r.db.Model(&model.Inventory{}).Where("item_id = ?", itemID).Count(&count).Error
-	return count > 0, err
+// GetInflows lists the ledger entries that added to an item's stock,
+// newest first.
+func (r *InventoryRepository) GetInflows(itemID string) ([]*model.StockMovement, error) {
+	var movements []*model.StockMovement
+	err := r.db.Where("item_id = ? AND quantity > 0", itemID).
+		Order("created_at DESC, id DESC").
+		Find(&movements).Error
+	return movements, err
+}
+
+// GetMovements lists an item's ledger entries, oldest first.
+func (r *InventoryRepository) GetMovements(itemID string, filter model.MovementFilter) ([]*model.StockMovement, error) {
+	query := r.db.Where("item_id = ?", itemID)
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	var movements []*model.StockMovement
+	err := query.Order("created_at, id").Find(&movements).Error
+	return movements, err
+}
+
+// LedgerStock returns the sum of all of an item's movements, which is what
+// its stock should be.
+func (r *InventoryRepository) LedgerStock(itemID string) (int, error) {
+	var total int
+	err := r.db.Model(&model.StockMovement{}).
+		Where("item_id = ?", itemID).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// EnsureOpeningBalances records the current stock of every item that has no
+// ledger entries yet, so that the ledger accounts for stock that predates
+// it. It is safe to run on every start.
+func (r *InventoryRepository) EnsureOpeningBalances() error {
+	return r.db.Exec(`
+		INSERT INTO stock_movements (item_id, type, quantity, stock_after, reason, user_id, created_at)
+		SELECT i.item_id, ?, i.stock, i.stock, ?, i.updated_by, CURRENT_TIMESTAMP
+		FROM inventories i
+		WHERE i.deleted_at IS NULL AND i.stock <> 0
+		AND NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.item_id = i.item_id)`,
+		model.MovementOpeningBalance, "stock before the ledger was introduced",
+	).Error
+}
+
+// lockForUpdate locks the selected rows until the transaction ends on
+// databases that support SELECT ... FOR UPDATE; SQLite serializes writers
+// anyway.
+func lockForUpdate(tx *gorm.DB) *gorm.DB {
+	if tx.Dialector.Name() == "sqlite" {
+		return tx
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"})
 }
@@ -0,0 +1,362 @@
+package repository
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// newTestRepository opens a SQLite database in a temporary file. Writers
+// take the database lock when their transaction begins and wait for each
+// other, the way row locks make them wait in Postgres.
+func newTestRepository(t *testing.T) *InventoryRepository {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "inventory.db") + "?_busy_timeout=10000&_journal_mode=WAL&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(
+		&model.Inventory{},
+		&model.StockMovement{},
+		&model.Reservation{},
+		&model.Location{},
+		&model.LocationStock{},
+		&model.Transfer{},
+		&model.Lot{},
+		&model.CountSession{},
+		&model.CountLine{},
+		&model.Report{},
+		&model.Saga{},
+		&model.OutboxEvent{},
+		&model.ReconciliationRun{},
+		&model.Supplier{},
+		&model.PurchaseOrder{},
+		&model.PurchaseOrderLine{},
+		&model.Receipt{},
+		&model.ReceiptLine{},
+		&model.ReceiptDiscrepancy{},
+		&model.IdempotencyKey{},
+	); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return NewInventoryRepository(db)
+}
+
+func adjustConcurrently(t *testing.T, repo *InventoryRepository, itemID string, deltas []int) []error {
+	t.Helper()
+
+	errs := make([]error, len(deltas))
+	var wg sync.WaitGroup
+	for i, delta := range deltas {
+		wg.Add(1)
+		go func(i, delta int) {
+			defer wg.Done()
+			_, errs[i] = repo.AdjustStock(itemID, delta, &model.StockMovement{Type: model.MovementCorrection, UserID: 1})
+		}(i, delta)
+	}
+	wg.Wait()
+	return errs
+}
+
+func assertStock(t *testing.T, repo *InventoryRepository, itemID string, want int) {
+	t.Helper()
+
+	inventory, err := repo.GetByItemID(itemID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inventory.Stock != want {
+		t.Errorf("stock = %d, want %d", inventory.Stock, want)
+	}
+
+	ledger, err := repo.LedgerStock(itemID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ledger != want {
+		t.Errorf("ledger stock = %d, want %d", ledger, want)
+	}
+}
+
+func TestAdjustStockAppliesConcurrentIncrements(t *testing.T) {
+	repo := newTestRepository(t)
+
+	// The first increments race to create the inventory record
+	deltas := make([]int, 50)
+	for i := range deltas {
+		deltas[i] = 2
+	}
+	for i, err := range adjustConcurrently(t, repo, "lamp", deltas) {
+		if err != nil {
+			t.Fatalf("adjustment %d: %v", i, err)
+		}
+	}
+
+	assertStock(t, repo, "lamp", 100)
+
+	movements, err := repo.GetMovements("lamp", model.MovementFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movements) != len(deltas) {
+		t.Fatalf("%d movements, want %d", len(movements), len(deltas))
+	}
+	for i, movement := range movements {
+		if want := 2 * (i + 1); movement.StockAfter != want {
+			t.Errorf("movement %d: stock after = %d, want %d", i, movement.StockAfter, want)
+		}
+	}
+}
+
+func TestAdjustStockNeverGoesNegative(t *testing.T) {
+	repo := newTestRepository(t)
+	if _, err := repo.AdjustStock("lamp", 10, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 30 clerks try to sell 10 lamps
+	deltas := make([]int, 30)
+	for i := range deltas {
+		deltas[i] = -1
+	}
+
+	sold := 0
+	for i, err := range adjustConcurrently(t, repo, "lamp", deltas) {
+		switch {
+		case err == nil:
+			sold++
+		case !errors.Is(err, ErrInsufficientStock):
+			t.Fatalf("adjustment %d: %v", i, err)
+		}
+	}
+
+	if sold != 10 {
+		t.Errorf("%d sales succeeded, want 10", sold)
+	}
+	assertStock(t, repo, "lamp", 0)
+}
+
+func TestAdjustStockMixedWithSetStock(t *testing.T) {
+	repo := newTestRepository(t)
+	if _, err := repo.SetStock("lamp", 40, &model.StockMovement{Type: model.MovementCorrection}); err != nil {
+		t.Fatal(err)
+	}
+
+	deltas := []int{5, -3, 7, -9, 1, -1, 4, -4}
+	for i, err := range adjustConcurrently(t, repo, "lamp", deltas) {
+		if err != nil {
+			t.Fatalf("adjustment %d: %v", i, err)
+		}
+	}
+
+	assertStock(t, repo, "lamp", 40)
+}
+
+func TestAdjustStockRejectsDecrementOfUnknownItem(t *testing.T) {
+	repo := newTestRepository(t)
+
+	if _, err := repo.AdjustStock("lamp", -1, &model.StockMovement{Type: model.MovementSale}); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("err = %v, want ErrInsufficientStock", err)
+	}
+	if _, err := repo.GetByItemID("lamp"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Error("decrement created an inventory record")
+	}
+}
+
+func TestAdjustStockRejectsAboveMaxStock(t *testing.T) {
+	repo := newTestRepository(t)
+	repo.SetLimits(StockLimits{DefaultMaxStock: 20, RejectAboveMax: true})
+
+	if _, err := repo.AdjustStock("lamp", 25, &model.StockMovement{Type: model.MovementReceipt}); !errors.Is(err, ErrAboveMaxStock) {
+		t.Fatalf("first increment above max: err = %v, want ErrAboveMaxStock", err)
+	}
+
+	// The first increments race to create the record; only 20 fit
+	deltas := make([]int, 30)
+	for i := range deltas {
+		deltas[i] = 1
+	}
+	accepted := 0
+	for i, err := range adjustConcurrently(t, repo, "lamp", deltas) {
+		switch {
+		case err == nil:
+			accepted++
+		case !errors.Is(err, ErrAboveMaxStock):
+			t.Fatalf("adjustment %d: %v", i, err)
+		}
+	}
+
+	if accepted != 20 {
+		t.Errorf("%d increments accepted, want 20", accepted)
+	}
+	assertStock(t, repo, "lamp", 20)
+
+	if _, err := repo.SetStock("lamp", 21, &model.StockMovement{Type: model.MovementCorrection}); !errors.Is(err, ErrAboveMaxStock) {
+		t.Errorf("set above max: err = %v, want ErrAboveMaxStock", err)
+	}
+}
+
+func TestLedgerAppendsSignedMovements(t *testing.T) {
+	repo := newTestRepository(t)
+
+	if _, err := repo.SetStock("lamp", 10, &model.StockMovement{Type: model.MovementCorrection}); err != nil {
+		t.Fatal(err)
+	}
+	first, err := repo.GetMovements("lamp", model.MovementFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.AdjustStock("lamp", 5, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AdjustStock("lamp", -3, &model.StockMovement{Type: model.MovementSale}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.SetStock("lamp", 4, &model.StockMovement{Type: model.MovementCorrection}); err != nil {
+		t.Fatal(err)
+	}
+	// Setting the stock it already has records nothing
+	if _, err := repo.SetStock("lamp", 4, &model.StockMovement{Type: model.MovementCorrection}); err != nil {
+		t.Fatal(err)
+	}
+
+	movements, err := repo.GetMovements("lamp", model.MovementFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		typ        model.MovementType
+		quantity   int
+		stockAfter int
+	}{
+		{model.MovementCorrection, 10, 10},
+		{model.MovementReceipt, 5, 15},
+		{model.MovementSale, -3, 12},
+		{model.MovementCorrection, -8, 4},
+	}
+	if len(movements) != len(want) {
+		t.Fatalf("got %d movements, want %d", len(movements), len(want))
+	}
+	for i, w := range want {
+		if m := movements[i]; m.Type != w.typ || m.Quantity != w.quantity || m.StockAfter != w.stockAfter {
+			t.Errorf("movement %d = %s %d (stock after %d), want %s %d (stock after %d)", i, m.Type, m.Quantity, m.StockAfter, w.typ, w.quantity, w.stockAfter)
+		}
+	}
+	if m := movements[0]; m.ID != first[0].ID || m.Quantity != first[0].Quantity || !m.CreatedAt.Equal(first[0].CreatedAt) {
+		t.Errorf("first movement changed from %+v to %+v", first[0], m)
+	}
+
+	assertStock(t, repo, "lamp", 4)
+}
+
+func TestEnsureOpeningBalancesRecordsExistingStock(t *testing.T) {
+	repo := newTestRepository(t)
+
+	// Records from before the ledger, written without movements
+	for _, inventory := range []*model.Inventory{
+		{ItemID: "desk", Stock: 7, UpdatedBy: 3},
+		{ItemID: "chair"},
+	} {
+		if err := repo.db.Create(inventory).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.AdjustStock("lamp", 5, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Safe to run on every start
+	for i := 0; i < 2; i++ {
+		if err := repo.EnsureOpeningBalances(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	movements, err := repo.GetMovements("desk", model.MovementFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movements) != 1 || movements[0].Type != model.MovementOpeningBalance || movements[0].Quantity != 7 || movements[0].UserID != 3 {
+		t.Errorf("desk movements = %+v, want one opening balance of 7 by user 3", movements)
+	}
+	assertStock(t, repo, "desk", 7)
+	assertStock(t, repo, "lamp", 5)
+
+	for _, itemID := range []string{"chair", "lamp"} {
+		movements, err := repo.GetMovements(itemID, model.MovementFilter{Type: model.MovementOpeningBalance})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(movements) != 0 {
+			t.Errorf("%s has opening balances %+v, want none", itemID, movements)
+		}
+	}
+}
+
+func TestGetMovementsFiltersByTime(t *testing.T) {
+	repo := newTestRepository(t)
+
+	at := []time.Time{
+		time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 31, 23, 30, 0, 0, time.UTC),
+		time.Date(2024, 2, 1, 0, 30, 0, 0, time.UTC),
+	}
+	for _, createdAt := range at {
+		if _, err := repo.AdjustStock("lamp", 1, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+			t.Fatal(err)
+		}
+		err := repo.db.Model(&model.StockMovement{}).
+			Where("id = (SELECT MAX(id) FROM stock_movements)").
+			Update("created_at", createdAt).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.AdjustStock("lamp", -1, &model.StockMovement{Type: model.MovementSale}); err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(filter model.MovementFilter) int {
+		t.Helper()
+		movements, err := repo.GetMovements("lamp", filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(movements)
+	}
+
+	// to=2024-01-31 is passed on as the start of the next day, so that it
+	// covers all of January 31
+	january := model.MovementFilter{
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if got := count(january); got != 2 {
+		t.Errorf("January: got %d movements, want 2", got)
+	}
+	if got := count(model.MovementFilter{From: at[1]}); got != 3 {
+		t.Errorf("from the second movement: got %d movements, want 3 including the sale", got)
+	}
+	if got := count(model.MovementFilter{To: at[1]}); got != 1 {
+		t.Errorf("to the second movement: got %d movements, want 1 as to is exclusive", got)
+	}
+	if got := count(model.MovementFilter{Type: model.MovementSale}); got != 1 {
+		t.Errorf("sales: got %d movements, want 1", got)
+	}
+}
@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrLocationNotFound     = errors.New("location not found")
+	ErrLocationExists       = errors.New("location already exists")
+	ErrTransferReceived     = errors.New("transfer is already received")
+	ErrTransferCancelled    = errors.New("transfer is already cancelled")
+	ErrTransferSameLocation = errors.New("transfer must be between different locations")
+)
+
+type LocationRepository struct {
+	db *gorm.DB
+}
+
+func NewLocationRepository(db *gorm.DB) *LocationRepository {
+	return &LocationRepository{db: db}
+}
+
+func (r *LocationRepository) Create(location *model.Location) error {
+	var count int64
+	if err := r.db.Model(&model.Location{}).Where("code = ?", location.Code).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrLocationExists
+	}
+	return r.db.Create(location).Error
+}
+
+// GetAll lists locations ordered by code, optionally only those of one
+// warehouse.
+func (r *LocationRepository) GetAll(warehouse string) ([]*model.Location, error) {
+	query := r.db.Order("code")
+	if warehouse != "" {
+		query = query.Where("warehouse = ?", warehouse)
+	}
+
+	var locations []*model.Location
+	err := query.Find(&locations).Error
+	return locations, err
+}
+
+// ItemStock lists where an item's stock is kept.
+func (r *LocationRepository) ItemStock(itemID string) ([]*model.LocationStock, error) {
+	var stocks []*model.LocationStock
+	err := r.db.Preload("Location").
+		Where("item_id = ? AND quantity <> 0", itemID).
+		Order("location_id").
+		Find(&stocks).Error
+	return stocks, err
+}
+
+// Ship starts a transfer: the quantity leaves the source location and is
+// held in transit until the transfer is received or cancelled.
+func (r *LocationRepository) Ship(transfer *model.Transfer) error {
+	if transfer.FromLocationID == transfer.ToLocationID {
+		return ErrTransferSameLocation
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := requireLocation(tx, transfer.ToLocationID); err != nil {
+			return err
+		}
+
+		// Reserved stock stays put; the update also locks the item's
+		// inventory row against concurrent allocations
+		result := tx.Model(&model.Inventory{}).
+			Where("item_id = ? AND "+availableStock+" >= ?", transfer.ItemID, transfer.Quantity).
+			Update("in_transit", gorm.Expr("in_transit + ?", transfer.Quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientStock
+		}
+
+		if err := takeFromLocation(tx, transfer.ItemID, transfer.FromLocationID, transfer.Quantity); err != nil {
+			return err
+		}
+
+		transfer.Status = model.TransferInTransit
+		transfer.ShippedAt = time.Now()
+		return tx.Create(transfer).Error
+	})
+}
+
+// Receive completes a transfer by putting its stock at the destination.
+// Receiving a received transfer returns it unchanged.
+func (r *LocationRepository) Receive(id uint, userID uint32) (*model.Transfer, error) {
+	return r.finishTransfer(id, func(tx *gorm.DB, transfer *model.Transfer, now time.Time) error {
+		if err := addToLocation(tx, transfer.ItemID, transfer.ToLocationID, transfer.Quantity); err != nil {
+			return err
+		}
+		transfer.Status = model.TransferReceived
+		transfer.ReceivedBy = userID
+		transfer.ReceivedAt = &now
+		return nil
+	}, model.TransferReceived)
+}
+
+// Cancel returns the stock of a transfer in transit to its source.
+// Cancelling a cancelled transfer returns it unchanged.
+func (r *LocationRepository) Cancel(id uint) (*model.Transfer, error) {
+	return r.finishTransfer(id, func(tx *gorm.DB, transfer *model.Transfer, now time.Time) error {
+		if err := addToLocation(tx, transfer.ItemID, transfer.FromLocationID, transfer.Quantity); err != nil {
+			return err
+		}
+		transfer.Status = model.TransferCancelled
+		transfer.CancelledAt = &now
+		return nil
+	}, model.TransferCancelled)
+}
+
+// finishTransfer ends a transfer in transit: finish places its stock, and
+// the stock leaves the item's in-transit count. A transfer already in the
+// target status is returned unchanged.
+func (r *LocationRepository) finishTransfer(id uint, finish func(tx *gorm.DB, transfer *model.Transfer, now time.Time) error, target model.TransferStatus) (*model.Transfer, error) {
+	var transfer model.Transfer
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockForUpdate(tx).First(&transfer, id).Error; err != nil {
+			return err
+		}
+
+		switch transfer.Status {
+		case target:
+			return nil
+		case model.TransferReceived:
+			return ErrTransferReceived
+		case model.TransferCancelled:
+			return ErrTransferCancelled
+		}
+
+		err := tx.Model(&model.Inventory{}).
+			Where("item_id = ?", transfer.ItemID).
+			Update("in_transit", gorm.Expr("in_transit - ?", transfer.Quantity)).Error
+		if err != nil {
+			return err
+		}
+
+		if err := finish(tx, &transfer, time.Now()); err != nil {
+			return err
+		}
+		return tx.Save(&transfer).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *LocationRepository) GetTransfer(id uint) (*model.Transfer, error) {
+	var transfer model.Transfer
+	err := r.db.First(&transfer, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// GetTransfers lists transfers, newest first, optionally of one item or in
+// one status.
+func (r *LocationRepository) GetTransfers(itemID string, status model.TransferStatus) ([]*model.Transfer, error) {
+	query := r.db.Order("id DESC")
+	if itemID != "" {
+		query = query.Where("item_id = ?", itemID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var transfers []*model.Transfer
+	err := query.Find(&transfers).Error
+	return transfers, err
+}
+
+func requireLocation(tx *gorm.DB, locationID uint) error {
+	err := tx.Select("id").First(&model.Location{}, locationID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrLocationNotFound
+	}
+	return err
+}
+
+// addToLocation adds stock to an item's quantity at a location.
+func addToLocation(tx *gorm.DB, itemID string, locationID uint, quantity int) error {
+	if err := requireLocation(tx, locationID); err != nil {
+		return err
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "item_id"}, {Name: "location_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"quantity":   gorm.Expr("location_stocks.quantity + excluded.quantity"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&model.LocationStock{
+		ItemID:     itemID,
+		LocationID: locationID,
+		Quantity:   quantity,
+	}).Error
+}
+
+// takeFromLocation removes stock from an item's quantity at a location, or
+// returns ErrInsufficientStock if there is not that much there.
+func takeFromLocation(tx *gorm.DB, itemID string, locationID uint, quantity int) error {
+	result := tx.Model(&model.LocationStock{}).
+		Where("item_id = ? AND location_id = ? AND quantity >= ?", itemID, locationID, quantity).
+		Update("quantity", gorm.Expr("quantity - ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := requireLocation(tx, locationID); err != nil {
+			return err
+		}
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// part is the share of a stock change that falls to one location or lot,
+// nil for unassigned or untracked stock.
+type part struct {
+	id       *uint
+	quantity int
+}
+
+// allocate spreads a change of an item's total stock over its locations and
+// lots and records it in the ledger, one movement per location and lot
+// touched. It must run in the transaction that changed the inventory row,
+// whose lock keeps concurrent allocations of the item apart. inventory is
+// the row after the change and template supplies the movements' location,
+// lot, type, reason, reference and user. See allocateLocations and
+// allocateLots.
+func allocate(tx *gorm.DB, inventory *model.Inventory, delta int, template *model.StockMovement) error {
+	if delta == 0 {
+		return nil
+	}
+
+	locations, err := allocateLocations(tx, inventory, delta, template.LocationID)
+	if err != nil {
+		return err
+	}
+	lots, err := allocateLots(tx, inventory, delta, template.LotID)
+	if err != nil {
+		return err
+	}
+
+	// Both lists add up to delta; walk them together, cutting a movement
+	// wherever either moves on
+	stockAfter := inventory.Stock - delta
+	location, lot := locations[0].quantity, lots[0].quantity
+	for i, j := 0, 0; i < len(locations) && j < len(lots); {
+		// Both have the sign of delta; take the one nearer zero
+		quantity := min(location, lot)
+		if delta < 0 {
+			quantity = max(location, lot)
+		}
+
+		stockAfter += quantity
+		movement := *template
+		movement.ID = 0
+		movement.ItemID = inventory.ItemID
+		movement.LocationID = locations[i].id
+		movement.LotID = lots[j].id
+		movement.Quantity = quantity
+		movement.StockAfter = stockAfter
+		if err := tx.Create(&movement).Error; err != nil {
+			return err
+		}
+
+		if location -= quantity; location == 0 {
+			if i++; i < len(locations) {
+				location = locations[i].quantity
+			}
+		}
+		if lot -= quantity; lot == 0 {
+			if j++; j < len(lots) {
+				lot = lots[j].quantity
+			}
+		}
+	}
+	return nil
+}
+
+// allocateLocations spreads a change of an item's total stock over its
+// locations, returning the part of the change at each.
+//
+// Increments go to locationID, or are left unassigned without one.
+// Decrements are taken from locationID, or without one from unassigned
+// stock first and then from locations in the order they were created.
+func allocateLocations(tx *gorm.DB, inventory *model.Inventory, delta int, locationID *uint) ([]part, error) {
+	switch {
+	case delta > 0 && locationID != nil:
+		if err := addToLocation(tx, inventory.ItemID, *locationID, delta); err != nil {
+			return nil, err
+		}
+		return []part{{locationID, delta}}, nil
+	case delta > 0:
+		return []part{{nil, delta}}, nil
+	case locationID != nil:
+		if err := takeFromLocation(tx, inventory.ItemID, *locationID, -delta); err != nil {
+			return nil, err
+		}
+		return []part{{locationID, delta}}, nil
+	}
+
+	var located int
+	err := tx.Model(&model.LocationStock{}).
+		Where("item_id = ?", inventory.ItemID).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&located).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []part
+	need := -delta
+	unassigned := inventory.Stock - delta - inventory.InTransit - located
+	if take := min(max(unassigned, 0), need); take > 0 {
+		parts = append(parts, part{nil, -take})
+		need -= take
+	}
+
+	if need > 0 {
+		var stocks []*model.LocationStock
+		err := tx.Where("item_id = ? AND quantity > 0", inventory.ItemID).Order("location_id").Find(&stocks).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, stock := range stocks {
+			take := min(stock.Quantity, need)
+			if err := takeFromLocation(tx, inventory.ItemID, stock.LocationID, take); err != nil {
+				return nil, err
+			}
+			locationID := stock.LocationID
+			parts = append(parts, part{&locationID, -take})
+			if need -= take; need == 0 {
+				break
+			}
+		}
+	}
+	if need > 0 {
+		return nil, ErrInsufficientStock
+	}
+	return parts, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+func createLocation(t *testing.T, repo *LocationRepository, warehouse, zone, bin string) *model.Location {
+	t.Helper()
+
+	location := &model.Location{Warehouse: warehouse, Zone: zone, Bin: bin, Code: model.LocationCode(warehouse, zone, bin)}
+	if err := repo.Create(location); err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
+
+func locationQuantities(t *testing.T, repo *LocationRepository, itemID string) map[uint]int {
+	t.Helper()
+
+	stocks, err := repo.ItemStock(itemID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quantities := make(map[uint]int)
+	for _, stock := range stocks {
+		quantities[stock.LocationID] = stock.Quantity
+	}
+	return quantities
+}
+
+func TestAdjustStockAllocatesOverLocations(t *testing.T) {
+	inventories := newTestRepository(t)
+	locations := NewLocationRepository(inventories.db)
+	north := createLocation(t, locations, "north", "a", "1")
+	south := createLocation(t, locations, "south", "", "")
+
+	for _, receipt := range []struct {
+		location *uint
+		quantity int
+	}{{nil, 3}, {&north.ID, 5}, {&south.ID, 4}} {
+		movement := &model.StockMovement{Type: model.MovementReceipt, LocationID: receipt.location}
+		if _, err := inventories.AdjustStock("lamp", receipt.quantity, movement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Unassigned stock goes first, then the oldest location
+	if _, err := inventories.AdjustStock("lamp", -6, &model.StockMovement{Type: model.MovementSale}); err != nil {
+		t.Fatal(err)
+	}
+	got := locationQuantities(t, locations, "lamp")
+	if got[north.ID] != 2 || got[south.ID] != 4 {
+		t.Errorf("quantities = %v, want north 2, south 4", got)
+	}
+	assertStock(t, inventories, "lamp", 6)
+
+	movements, err := inventories.GetMovements("lamp", model.MovementFilter{Type: model.MovementSale})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movements) != 2 || movements[0].LocationID != nil || movements[0].Quantity != -3 ||
+		movements[1].LocationID == nil || *movements[1].LocationID != north.ID || movements[1].Quantity != -3 {
+		t.Errorf("sale movements = %+v, want -3 unassigned and -3 from north", movements)
+	}
+
+	if _, err := inventories.AdjustStock("lamp", -5, &model.StockMovement{Type: model.MovementSale, LocationID: &south.ID}); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("taking more than a location holds: err = %v, want ErrInsufficientStock", err)
+	}
+	assertStock(t, inventories, "lamp", 6)
+}
+
+func TestTransfersAreTwoPhase(t *testing.T) {
+	inventories := newTestRepository(t)
+	locations := NewLocationRepository(inventories.db)
+	north := createLocation(t, locations, "north", "", "")
+	south := createLocation(t, locations, "south", "", "")
+
+	if _, err := inventories.AdjustStock("lamp", 10, &model.StockMovement{Type: model.MovementReceipt, LocationID: &north.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent shipments cannot take more than north holds
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var shipped []*model.Transfer
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			transfer := &model.Transfer{ItemID: "lamp", FromLocationID: north.ID, ToLocationID: south.ID, Quantity: 3}
+			err := locations.Ship(transfer)
+			switch {
+			case err == nil:
+				mu.Lock()
+				shipped = append(shipped, transfer)
+				mu.Unlock()
+			case !errors.Is(err, ErrInsufficientStock):
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(shipped) != 3 {
+		t.Fatalf("%d transfers shipped, want 3", len(shipped))
+	}
+	inventory, err := inventories.GetByItemID("lamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inventory.Stock != 10 || inventory.InTransit != 9 || inventory.Available != 1 {
+		t.Errorf("stock %d, in transit %d, available %d; want 10, 9, 1", inventory.Stock, inventory.InTransit, inventory.Available)
+	}
+
+	if _, err := locations.Receive(shipped[0].ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := locations.Receive(shipped[0].ID, 1); err != nil {
+		t.Errorf("second receive: %v", err)
+	}
+	if _, err := locations.Cancel(shipped[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := locations.Cancel(shipped[0].ID); !errors.Is(err, ErrTransferReceived) {
+		t.Errorf("cancel of received transfer: err = %v, want ErrTransferReceived", err)
+	}
+
+	got := locationQuantities(t, locations, "lamp")
+	if got[north.ID] != 4 || got[south.ID] != 3 {
+		t.Errorf("quantities = %v, want north 4, south 3", got)
+	}
+	inventory, err = inventories.GetByItemID("lamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inventory.Stock != 10 || inventory.InTransit != 3 {
+		t.Errorf("stock %d, in transit %d; want 10, 3", inventory.Stock, inventory.InTransit)
+	}
+}
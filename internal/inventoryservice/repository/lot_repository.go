@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrLotNotFound = errors.New("lot not found")
+	// ErrLotDatesDiffer is returned when stock is received into an existing
+	// lot with different manufacture or expiry dates.
+	ErrLotDatesDiffer = errors.New("lot already exists with different dates")
+	// ErrLotBlocked is returned when a blocked lot would be released or
+	// quarantined.
+	ErrLotBlocked = errors.New("lot is blocked")
+	// ErrLotCommitted is returned when a lot would be held while its stock
+	// is needed for reservations or transfers.
+	ErrLotCommitted = errors.New("insufficient stock: the lot is needed for reservations or transfers")
+	// ErrLotsExpired is returned when a decrement could only be met from
+	// expired lots, which are never allocated automatically.
+	ErrLotsExpired = errors.New("insufficient stock: the remaining lots have expired")
+)
+
+// EnsureLot returns an item's lot with lot's number, creating it with lot's
+// dates if there is none. Dates given for an existing lot must match its
+// own, except that dates it lacks are filled in.
+func (r *InventoryRepository) EnsureLot(lot *model.Lot) (*model.Lot, error) {
+	var existing model.Lot
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		create := *lot
+		create.Status = model.LotAvailable
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&create).Error
+		if err != nil {
+			return err
+		}
+
+		err = lockForUpdate(tx).Where("item_id = ? AND lot_number = ?", lot.ItemID, lot.LotNumber).First(&existing).Error
+		if err != nil {
+			return err
+		}
+
+		if differ(existing.ManufacturedAt, lot.ManufacturedAt) || differ(existing.ExpiresAt, lot.ExpiresAt) {
+			return ErrLotDatesDiffer
+		}
+
+		filled := false
+		if existing.ManufacturedAt == nil && lot.ManufacturedAt != nil {
+			existing.ManufacturedAt = lot.ManufacturedAt
+			filled = true
+		}
+		if existing.ExpiresAt == nil && lot.ExpiresAt != nil {
+			existing.ExpiresAt = lot.ExpiresAt
+			filled = true
+		}
+		if !filled {
+			return nil
+		}
+		return tx.Save(&existing).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (r *InventoryRepository) GetLot(id uint) (*model.Lot, error) {
+	var lot model.Lot
+	err := r.db.First(&lot, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+func (r *InventoryRepository) GetLotByNumber(itemID, lotNumber string) (*model.Lot, error) {
+	var lot model.Lot
+	err := r.db.Where("item_id = ? AND lot_number = ?", itemID, lotNumber).First(&lot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+// GetLots lists an item's lots in the order decrements take from them.
+func (r *InventoryRepository) GetLots(itemID string) ([]*model.Lot, error) {
+	var lots []*model.Lot
+	err := fefo(r.db.Where("item_id = ?", itemID)).Find(&lots).Error
+	return lots, err
+}
+
+// ExpiringLots lists lots with stock that expire before the given time,
+// including those already expired, soonest first.
+func (r *InventoryRepository) ExpiringLots(before time.Time) ([]*model.Lot, error) {
+	var lots []*model.Lot
+	err := r.db.Where("quantity > 0 AND expires_at < ?", before).
+		Order("expires_at, id").
+		Find(&lots).Error
+	return lots, err
+}
+
+// SetLotStatus quarantines, blocks or releases a lot. Holding a lot takes
+// its stock out of what is available, which fails with ErrLotCommitted if
+// that stock is reserved or in transit. A blocked lot stays blocked. Setting
+// the status a lot already has changes nothing.
+func (r *InventoryRepository) SetLotStatus(id uint, status model.LotStatus, reason string, userID uint32) (*model.Lot, error) {
+	lot, err := r.GetLot(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the inventory row before the lot, like stock changes do
+		if err := lockForUpdate(tx).Where("item_id = ?", lot.ItemID).Find(&[]model.Inventory{}).Error; err != nil {
+			return err
+		}
+		locked, err := lockLot(tx, lot.ItemID, id)
+		if err != nil {
+			return err
+		}
+		lot = locked
+
+		switch {
+		case lot.Status == status:
+			return nil
+		case lot.Status == model.LotBlocked:
+			return ErrLotBlocked
+		}
+
+		held := 0
+		switch {
+		case status.Held() && !lot.Status.Held():
+			held = lot.Quantity
+		case !status.Held() && lot.Status.Held():
+			held = -lot.Quantity
+		}
+		if held != 0 {
+			query := tx.Model(&model.Inventory{}).Where("item_id = ?", lot.ItemID)
+			if held > 0 {
+				query = query.Where(availableStock+" >= ?", held)
+			}
+			result := query.Update("held", gorm.Expr("held + ?", held))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrLotCommitted
+			}
+		}
+
+		lot.Status = status
+		lot.HoldReason = reason
+		lot.UpdatedBy = userID
+		return tx.Save(lot).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lot, nil
+}
+
+// differ reports whether two dates are both set and not equal.
+func differ(a, b *time.Time) bool {
+	return a != nil && b != nil && !a.Equal(*b)
+}
+
+// lockLot locks one of an item's lots until the transaction ends.
+func lockLot(tx *gorm.DB, itemID string, id uint) (*model.Lot, error) {
+	var lot model.Lot
+	err := lockForUpdate(tx).Where("id = ? AND item_id = ?", id, itemID).First(&lot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+// fefo orders lots first-expired-first-out; lots without an expiry date
+// come last.
+func fefo(query *gorm.DB) *gorm.DB {
+	return query.Order("expires_at IS NULL, expires_at, id")
+}
+
+// allocateLots spreads a change of an item's total stock over its lots,
+// returning the part of the change in each; see allocate. Increments go to
+// lotID, or are left untracked without one. Decrements are taken from
+// lotID, or without one from lots that are neither held nor expired,
+// first-expired-first-out, and then from untracked stock.
+func allocateLots(tx *gorm.DB, inventory *model.Inventory, delta int, lotID *uint) ([]part, error) {
+	switch {
+	case lotID != nil:
+		update := tx.Model(&model.Lot{}).Where("id = ?", *lotID)
+		if delta < 0 {
+			update = update.Where("quantity + ? >= 0", delta)
+		}
+		result := update.Update("quantity", gorm.Expr("quantity + ?", delta))
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, ErrInsufficientStock
+		}
+		return []part{{lotID, delta}}, nil
+	case delta > 0:
+		return []part{{nil, delta}}, nil
+	}
+
+	var tracked int
+	err := tx.Model(&model.Lot{}).
+		Where("item_id = ?", inventory.ItemID).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&tracked).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var lots []*model.Lot
+	err = fefo(tx.Where("item_id = ? AND status = ? AND quantity > 0", inventory.ItemID, model.LotAvailable)).
+		Find(&lots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []part
+	need := -delta
+	expired := false
+	for _, lot := range lots {
+		if lot.Expired {
+			expired = true
+			continue
+		}
+		take := min(lot.Quantity, need)
+		result := tx.Model(&model.Lot{}).
+			Where("id = ? AND quantity >= ?", lot.ID, take).
+			Update("quantity", gorm.Expr("quantity - ?", take))
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, ErrInsufficientStock
+		}
+		id := lot.ID
+		parts = append(parts, part{&id, -take})
+		if need -= take; need == 0 {
+			return parts, nil
+		}
+	}
+
+	untracked := inventory.Stock - delta - tracked
+	if take := min(max(untracked, 0), need); take > 0 {
+		parts = append(parts, part{nil, -take})
+		need -= take
+	}
+	switch {
+	case need == 0:
+		return parts, nil
+	case expired:
+		return nil, ErrLotsExpired
+	}
+	return nil, ErrInsufficientStock
+}
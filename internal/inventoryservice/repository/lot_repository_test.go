@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+func receiveLot(t *testing.T, repo *InventoryRepository, itemID, lotNumber string, quantity int, expiresIn time.Duration) *model.Lot {
+	t.Helper()
+
+	expiresAt := time.Now().Add(expiresIn)
+	lot, err := repo.EnsureLot(&model.Lot{ItemID: itemID, LotNumber: lotNumber, ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AdjustStock(itemID, quantity, &model.StockMovement{Type: model.MovementReceipt, LotID: &lot.ID}); err != nil {
+		t.Fatal(err)
+	}
+	return lot
+}
+
+func lotQuantities(t *testing.T, repo *InventoryRepository, itemID string) map[string]int {
+	t.Helper()
+
+	lots, err := repo.GetLots(itemID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quantities := make(map[string]int)
+	for _, lot := range lots {
+		quantities[lot.LotNumber] = lot.Quantity
+	}
+	return quantities
+}
+
+func TestAdjustStockTakesLotsFirstExpiredFirstOut(t *testing.T) {
+	repo := newTestRepository(t)
+	day := 24 * time.Hour
+	receiveLot(t, repo, "milk", "late", 5, 10*day)
+	receiveLot(t, repo, "milk", "early", 5, 2*day)
+	receiveLot(t, repo, "milk", "gone", 5, -day)
+	if _, err := repo.AdjustStock("milk", 3, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The expired lot is skipped; untracked stock goes last
+	if _, err := repo.AdjustStock("milk", -7, &model.StockMovement{Type: model.MovementSale}); err != nil {
+		t.Fatal(err)
+	}
+	got := lotQuantities(t, repo, "milk")
+	if got["early"] != 0 || got["late"] != 3 || got["gone"] != 5 {
+		t.Errorf("lots = %v, want early 0, late 3, gone 5", got)
+	}
+
+	if _, err := repo.AdjustStock("milk", -7, &model.StockMovement{Type: model.MovementSale}); !errors.Is(err, ErrLotsExpired) {
+		t.Errorf("decrement needing the expired lot: err = %v, want ErrLotsExpired", err)
+	}
+	assertStock(t, repo, "milk", 11)
+
+	if _, err := repo.AdjustStock("milk", -6, &model.StockMovement{Type: model.MovementSale}); err != nil {
+		t.Fatal(err)
+	}
+	movements, err := repo.GetMovements("milk", model.MovementFilter{Type: model.MovementSale})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movements) != 4 || movements[3].LotID != nil || movements[3].Quantity != -3 {
+		t.Errorf("sale movements = %+v, want the last one -3 untracked", movements)
+	}
+	assertStock(t, repo, "milk", 5)
+
+	expiring, err := repo.ExpiringLots(time.Now().Add(7 * day))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expiring) != 1 || expiring[0].LotNumber != "gone" || !expiring[0].Expired {
+		t.Errorf("expiring lots = %+v, want only the expired lot", expiring)
+	}
+}
+
+func TestHeldLotsAreNotAvailable(t *testing.T) {
+	repo := newTestRepository(t)
+	reservations := NewReservationRepository(repo.db)
+	lot := receiveLot(t, repo, "milk", "suspect", 6, time.Hour)
+	receiveLot(t, repo, "milk", "good", 4, 2*time.Hour)
+
+	reservation, _, err := reservations.Reserve(newReservation("milk", 5, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.SetLotStatus(lot.ID, model.LotQuarantined, "smells", 1); !errors.Is(err, ErrLotCommitted) {
+		t.Fatalf("quarantine of reserved stock: err = %v, want ErrLotCommitted", err)
+	}
+	if _, err := reservations.Release(reservation.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.SetLotStatus(lot.ID, model.LotQuarantined, "smells", 1); err != nil {
+		t.Fatal(err)
+	}
+	inventory, err := repo.GetByItemID("milk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inventory.Held != 6 || inventory.Available != 4 {
+		t.Errorf("held %d, available %d; want 6, 4", inventory.Held, inventory.Available)
+	}
+	if _, _, err := reservations.Reserve(newReservation("milk", 5, "")); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("reservation of quarantined stock: err = %v, want ErrInsufficientStock", err)
+	}
+
+	// Only the good lot is sold; a return to the quarantined lot stays held
+	if _, err := repo.AdjustStock("milk", -4, &model.StockMovement{Type: model.MovementSale}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AdjustStock("milk", 2, &model.StockMovement{Type: model.MovementReturn, LotID: &lot.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got := lotQuantities(t, repo, "milk"); got["suspect"] != 8 || got["good"] != 0 {
+		t.Errorf("lots = %v, want suspect 8, good 0", got)
+	}
+
+	if _, err := repo.SetLotStatus(lot.ID, model.LotAvailable, "", 1); err != nil {
+		t.Fatal(err)
+	}
+	inventory, err = repo.GetByItemID("milk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inventory.Held != 0 || inventory.Available != 8 {
+		t.Errorf("after release: held %d, available %d; want 0, 8", inventory.Held, inventory.Available)
+	}
+}
+
+func TestBlockedLotCanOnlyBeWrittenOff(t *testing.T) {
+	repo := newTestRepository(t)
+	lot := receiveLot(t, repo, "milk", "recalled", 6, time.Hour)
+	receiveLot(t, repo, "milk", "good", 4, 2*time.Hour)
+
+	if _, err := repo.SetLotStatus(lot.ID, model.LotBlocked, "recall", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.SetLotStatus(lot.ID, model.LotAvailable, "", 1); !errors.Is(err, ErrLotBlocked) {
+		t.Errorf("release of blocked lot: err = %v, want ErrLotBlocked", err)
+	}
+	if _, err := repo.AdjustStock("milk", -5, &model.StockMovement{Type: model.MovementSale}); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("sale of blocked stock: err = %v, want ErrInsufficientStock", err)
+	}
+
+	inventory, err := repo.AdjustStock("milk", -6, &model.StockMovement{Type: model.MovementShrinkage, LotID: &lot.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inventory.Stock != 4 || inventory.Held != 0 || inventory.Available != 4 {
+		t.Errorf("stock %d, held %d, available %d; want 4, 0, 4", inventory.Stock, inventory.Held, inventory.Available)
+	}
+
+	if err := repo.Delete("milk", 1); err != nil {
+		t.Fatal(err)
+	}
+	if lots := lotQuantities(t, repo, "milk"); len(lots) != 0 {
+		t.Errorf("lots after delete = %v, want none", lots)
+	}
+}
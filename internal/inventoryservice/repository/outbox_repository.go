@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+	"rancher-manager/kafka"
+
+	"gorm.io/gorm"
+)
+
+// OutboxRepository reads the outbox for the relay. Events are written to it
+// by the repositories making the changes they report, in the same
+// transaction.
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// outboxEvent is an event that can be written to the outbox.
+type outboxEvent interface {
+	Message() (*kafka.Message, error)
+}
+
+// enqueue writes event to the outbox within tx.
+func enqueue(tx *gorm.DB, event outboxEvent) error {
+	message, err := event.Message()
+	if err != nil {
+		return err
+	}
+	return tx.Create(&model.OutboxEvent{
+		Topic:   message.Topic,
+		Key:     message.Key,
+		Payload: string(message.Value),
+	}).Error
+}
+
+// Pending returns up to limit events not yet sent, oldest first.
+func (r *OutboxRepository) Pending(limit int) ([]*model.OutboxEvent, error) {
+	var events []*model.OutboxEvent
+	err := r.db.Where("sent_at IS NULL").Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (r *OutboxRepository) MarkSent(id uint, at time.Time) error {
+	return r.db.Model(&model.OutboxEvent{}).Where("id = ?", id).Update("sent_at", at).Error
+}
+
+// RecordFailure counts a failed attempt at publishing an event.
+func (r *OutboxRepository) RecordFailure(id uint, reason string) error {
+	return r.db.Model(&model.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": reason,
+	}).Error
+}
+
+// Lag returns how many events are waiting to be sent and when the oldest
+// of them was written, nil if none are.
+func (r *OutboxRepository) Lag() (int64, *time.Time, error) {
+	var pending int64
+	if err := r.db.Model(&model.OutboxEvent{}).Where("sent_at IS NULL").Count(&pending).Error; err != nil {
+		return 0, nil, err
+	}
+	if pending == 0 {
+		return 0, nil, nil
+	}
+
+	var oldest model.OutboxEvent
+	if err := r.db.Where("sent_at IS NULL").Order("id").First(&oldest).Error; err != nil {
+		return 0, nil, err
+	}
+	return pending, &oldest.CreatedAt, nil
+}
+
+// DeleteSent deletes the events sent before the given time.
+func (r *OutboxRepository) DeleteSent(before time.Time) (int64, error) {
+	result := r.db.Where("sent_at < ?", before).Delete(&model.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// enqueueStockUpdate reports the stock of inventory as changed by userID.
+func enqueueStockUpdate(tx *gorm.DB, inventory *model.Inventory, userID uint32) error {
+	return enqueue(tx, &kafka.StockUpdateEvent{
+		ItemID:   inventory.ItemID,
+		NewStock: inventory.Stock,
+		UserID:   userID,
+	})
+}
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSupplierNotFound          = errors.New("supplier not found")
+	ErrSupplierExists            = errors.New("a supplier with this name already exists")
+	ErrPurchaseOrderNotFound     = errors.New("purchase order not found")
+	ErrPurchaseOrderLineNotFound = errors.New("purchase order line not found")
+	// ErrPurchaseOrderFinished is returned when stock would be received on,
+	// or an order would be closed or cancelled, that is no longer expecting
+	// stock.
+	ErrPurchaseOrderFinished = errors.New("purchase order is already finished")
+	// ErrPurchaseOrderReceiving is returned when an order that has received
+	// stock would be cancelled.
+	ErrPurchaseOrderReceiving = errors.New("purchase order has already received stock; close it instead")
+)
+
+// PurchaseOrderRepository stores suppliers and the purchase orders placed
+// with them. Receiving stock posts it through the InventoryRepository.
+type PurchaseOrderRepository struct {
+	db          *gorm.DB
+	inventories *InventoryRepository
+}
+
+func NewPurchaseOrderRepository(db *gorm.DB, inventories *InventoryRepository) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{db: db, inventories: inventories}
+}
+
+func (r *PurchaseOrderRepository) CreateSupplier(supplier *model.Supplier) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Supplier{}).Where("name = ?", supplier.Name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrSupplierExists
+		}
+		return tx.Create(supplier).Error
+	})
+}
+
+// GetSuppliers lists suppliers by name.
+func (r *PurchaseOrderRepository) GetSuppliers() ([]*model.Supplier, error) {
+	var suppliers []*model.Supplier
+	err := r.db.Order("name").Find(&suppliers).Error
+	return suppliers, err
+}
+
+// Create places order with its lines as open.
+func (r *PurchaseOrderRepository) Create(order *model.PurchaseOrder) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Select("id").First(&model.Supplier{}, order.SupplierID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSupplierNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		order.Status = model.PurchaseOrderOpen
+		return tx.Create(order).Error
+	})
+}
+
+// GetByID returns an order with its supplier, lines, receipts and
+// discrepancies.
+func (r *PurchaseOrderRepository) GetByID(id uint) (*model.PurchaseOrder, error) {
+	byID := func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}
+
+	var order model.PurchaseOrder
+	err := r.db.
+		Preload("Supplier").
+		Preload("Lines", byID).
+		Preload("Receipts", byID).
+		Preload("Receipts.Lines", byID).
+		Preload("Discrepancies", byID).
+		First(&order, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetAll lists orders with their supplier but without their lines, newest
+// first, optionally only those in one status or with one supplier.
+func (r *PurchaseOrderRepository) GetAll(status model.PurchaseOrderStatus, supplierID uint) ([]*model.PurchaseOrder, error) {
+	query := r.db.Preload("Supplier").Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if supplierID != 0 {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+
+	var orders []*model.PurchaseOrder
+	err := query.Find(&orders).Error
+	return orders, err
+}
+
+// Receive records receipt, a delivery on an order, posting each of its
+// lines as a receipt movement referencing the order, in one transaction.
+// Stock received beyond what a line ordered is raised as an over-receipt.
+// The order is received once every line has been received in full. It
+// returns the stock changes, each with the sync_stock saga started for it.
+func (r *PurchaseOrderRepository) Receive(id uint, receipt *model.Receipt) ([]*StockChange, error) {
+	var changes stockChanges
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		order, err := lockExpectingOrder(tx, id)
+		if err != nil {
+			return err
+		}
+
+		var lines []*model.PurchaseOrderLine
+		if err := tx.Where("purchase_order_id = ?", id).Order("id").Find(&lines).Error; err != nil {
+			return err
+		}
+		byID := make(map[uint]*model.PurchaseOrderLine, len(lines))
+		for _, line := range lines {
+			byID[line.ID] = line
+		}
+		for _, received := range receipt.Lines {
+			line, ok := byID[received.LineID]
+			if !ok {
+				return fmt.Errorf("%w: %d", ErrPurchaseOrderLineNotFound, received.LineID)
+			}
+			received.ItemID = line.ItemID
+		}
+
+		receipt.ID = 0
+		receipt.PurchaseOrderID = id
+		if err := tx.Create(receipt).Error; err != nil {
+			return err
+		}
+
+		// Post in item order so that concurrent receipts lock inventory rows
+		// in the same order
+		posted := append([]*model.ReceiptLine(nil), receipt.Lines...)
+		sort.SliceStable(posted, func(i, j int) bool {
+			return posted[i].ItemID < posted[j].ItemID
+		})
+		for _, received := range posted {
+			line := byID[received.LineID]
+			inventory, err := r.inventories.adjustStock(tx, line.ItemID, received.Quantity, &model.StockMovement{
+				Type:       model.MovementReceipt,
+				LocationID: receipt.LocationID,
+				LotID:      received.LotID,
+				Reason:     fmt.Sprintf("received on purchase order %d", id),
+				Reference:  fmt.Sprintf("po-%d", id),
+				UserID:     receipt.ReceivedBy,
+			})
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line.ID, err)
+			}
+			changes.add(inventory, received.Quantity, receipt.ReceivedBy)
+
+			before := line.Received
+			line.Received += received.Quantity
+			line.InventoryID = &inventory.ID
+			if err := tx.Save(line).Error; err != nil {
+				return err
+			}
+
+			if over := line.Received - max(before, line.Ordered); over > 0 {
+				err := tx.Create(&model.ReceiptDiscrepancy{
+					PurchaseOrderID: id,
+					LineID:          line.ID,
+					ReceiptID:       &receipt.ID,
+					ItemID:          line.ItemID,
+					Type:            model.OverReceipt,
+					Quantity:        over,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := changes.start(tx); err != nil {
+			return err
+		}
+
+		status := model.PurchaseOrderReceived
+		for _, line := range lines {
+			if line.Received < line.Ordered {
+				status = model.PurchaseOrderPartiallyReceived
+				break
+			}
+		}
+		return finishOrder(tx, order, status, receipt.ReceivedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes.changes, nil
+}
+
+// Close stops an order expecting stock, raising an under-receipt for each
+// line not received in full.
+func (r *PurchaseOrderRepository) Close(id uint, userID uint32) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		order, err := lockExpectingOrder(tx, id)
+		if err != nil {
+			return err
+		}
+
+		var lines []*model.PurchaseOrderLine
+		if err := tx.Where("purchase_order_id = ? AND received < ordered", id).Order("id").Find(&lines).Error; err != nil {
+			return err
+		}
+		for _, line := range lines {
+			err := tx.Create(&model.ReceiptDiscrepancy{
+				PurchaseOrderID: id,
+				LineID:          line.ID,
+				ItemID:          line.ItemID,
+				Type:            model.UnderReceipt,
+				Quantity:        line.Ordered - line.Received,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return finishOrder(tx, order, model.PurchaseOrderClosed, userID)
+	})
+}
+
+// Cancel withdraws an order nothing has been received on.
+func (r *PurchaseOrderRepository) Cancel(id uint, userID uint32) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		order, err := lockExpectingOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		if order.Status != model.PurchaseOrderOpen {
+			return ErrPurchaseOrderReceiving
+		}
+		return finishOrder(tx, order, model.PurchaseOrderCancelled, userID)
+	})
+}
+
+// lockExpectingOrder locks an order that is still expecting stock.
+func lockExpectingOrder(tx *gorm.DB, id uint) (*model.PurchaseOrder, error) {
+	var order model.PurchaseOrder
+	err := lockForUpdate(tx).First(&order, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != model.PurchaseOrderOpen && order.Status != model.PurchaseOrderPartiallyReceived {
+		return nil, fmt.Errorf("%w: it is %s", ErrPurchaseOrderFinished, order.Status)
+	}
+	return &order, nil
+}
+
+// finishOrder moves order to status, recording who finished it when it
+// expects no more stock.
+func finishOrder(tx *gorm.DB, order *model.PurchaseOrder, status model.PurchaseOrderStatus, userID uint32) error {
+	updates := map[string]interface{}{"status": status}
+	if status != model.PurchaseOrderPartiallyReceived {
+		updates["closed_by"] = userID
+		updates["closed_at"] = time.Now()
+	}
+	return tx.Model(order).Updates(updates).Error
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+func createPurchaseOrder(t *testing.T, orders *PurchaseOrderRepository, supplierID uint, lines map[string]int) *model.PurchaseOrder {
+	t.Helper()
+
+	order := &model.PurchaseOrder{SupplierID: supplierID}
+	for _, itemID := range []string{"desk", "lamp"} {
+		if quantity, ok := lines[itemID]; ok {
+			order.Lines = append(order.Lines, &model.PurchaseOrderLine{ItemID: itemID, Ordered: quantity})
+		}
+	}
+	if err := orders.Create(order); err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func TestReceivePurchaseOrderRaisesOverReceipts(t *testing.T) {
+	inventories := newTestRepository(t)
+	orders := NewPurchaseOrderRepository(inventories.db, inventories)
+	north := createLocation(t, NewLocationRepository(inventories.db), "north", "a", "1")
+
+	supplier := &model.Supplier{Name: "Acme"}
+	if err := orders.CreateSupplier(supplier); err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.CreateSupplier(&model.Supplier{Name: "Acme"}); !errors.Is(err, ErrSupplierExists) {
+		t.Errorf("duplicate supplier: err = %v, want ErrSupplierExists", err)
+	}
+	if err := orders.Create(&model.PurchaseOrder{SupplierID: 99}); !errors.Is(err, ErrSupplierNotFound) {
+		t.Errorf("order with unknown supplier: err = %v, want ErrSupplierNotFound", err)
+	}
+
+	order := createPurchaseOrder(t, orders, supplier.ID, map[string]int{"desk": 5, "lamp": 10})
+	desk, lamp := order.Lines[0], order.Lines[1]
+
+	if _, err := orders.Receive(order.ID, &model.Receipt{
+		LocationID: &north.ID,
+		Lines:      []*model.ReceiptLine{{LineID: lamp.ID, Quantity: 6}},
+		ReceivedBy: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	assertStock(t, inventories, "lamp", 6)
+	if err := orders.Cancel(order.ID, 1); !errors.Is(err, ErrPurchaseOrderReceiving) {
+		t.Errorf("cancel after a receipt: err = %v, want ErrPurchaseOrderReceiving", err)
+	}
+
+	changed, err := orders.Receive(order.ID, &model.Receipt{
+		Lines:      []*model.ReceiptLine{{LineID: lamp.ID, Quantity: 6}, {LineID: desk.ID, Quantity: 5}},
+		ReceivedBy: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 {
+		t.Errorf("changed %d inventory records, want 2", len(changed))
+	}
+	assertStock(t, inventories, "lamp", 12)
+	assertStock(t, inventories, "desk", 5)
+
+	got, err := orders.GetByID(order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != model.PurchaseOrderReceived || len(got.Receipts) != 2 {
+		t.Errorf("order = %s with %d receipts, want received with 2", got.Status, len(got.Receipts))
+	}
+	inventory, err := inventories.GetByItemID("lamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := got.Lines[1]; line.Received != 12 || line.InventoryID == nil || *line.InventoryID != inventory.ID {
+		t.Errorf("lamp line = %+v, want 12 received into inventory %d", line, inventory.ID)
+	}
+	if len(got.Discrepancies) != 1 || got.Discrepancies[0].Type != model.OverReceipt || got.Discrepancies[0].Quantity != 2 {
+		t.Errorf("discrepancies = %+v, want an over-receipt of 2", got.Discrepancies)
+	}
+
+	movements, err := inventories.GetMovements("lamp", model.MovementFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := movements[0]; first.Type != model.MovementReceipt || first.Reference != "po-1" || first.LocationID == nil || *first.LocationID != north.ID {
+		t.Errorf("first movement = %+v, want a receipt at north referencing po-1", first)
+	}
+
+	if _, err := orders.Receive(order.ID, &model.Receipt{
+		Lines: []*model.ReceiptLine{{LineID: lamp.ID, Quantity: 1}},
+	}); !errors.Is(err, ErrPurchaseOrderFinished) {
+		t.Errorf("receipt on a received order: err = %v, want ErrPurchaseOrderFinished", err)
+	}
+}
+
+func TestClosePurchaseOrderRaisesUnderReceipts(t *testing.T) {
+	inventories := newTestRepository(t)
+	orders := NewPurchaseOrderRepository(inventories.db, inventories)
+	supplier := &model.Supplier{Name: "Acme"}
+	if err := orders.CreateSupplier(supplier); err != nil {
+		t.Fatal(err)
+	}
+
+	order := createPurchaseOrder(t, orders, supplier.ID, map[string]int{"desk": 5, "lamp": 4})
+	if _, err := orders.Receive(order.ID, &model.Receipt{
+		Lines: []*model.ReceiptLine{{LineID: order.Lines[0].ID, Quantity: 3}, {LineID: order.Lines[1].ID, Quantity: 4}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orders.Receive(order.ID, &model.Receipt{
+		Lines: []*model.ReceiptLine{{LineID: 999, Quantity: 1}},
+	}); !errors.Is(err, ErrPurchaseOrderLineNotFound) {
+		t.Errorf("receipt of an unknown line: err = %v, want ErrPurchaseOrderLineNotFound", err)
+	}
+
+	if err := orders.Close(order.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	got, err := orders.GetByID(order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != model.PurchaseOrderClosed || got.ClosedAt == nil {
+		t.Errorf("status = %s, want closed", got.Status)
+	}
+	if len(got.Discrepancies) != 1 || got.Discrepancies[0].ItemID != "desk" || got.Discrepancies[0].Type != model.UnderReceipt || got.Discrepancies[0].Quantity != 2 {
+		t.Errorf("discrepancies = %+v, want desk under-received by 2", got.Discrepancies)
+	}
+	if err := orders.Cancel(order.ID, 1); !errors.Is(err, ErrPurchaseOrderFinished) {
+		t.Errorf("cancel of a closed order: err = %v, want ErrPurchaseOrderFinished", err)
+	}
+}
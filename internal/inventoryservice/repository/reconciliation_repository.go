@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrReconciliationNotFound = errors.New("reconciliation not found: none has run yet")
+
+// ReconciliationRepository stores reconciliation runs.
+type ReconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{db: db}
+}
+
+// Start records run as running. It returns false, recording nothing, if a
+// run in the same slot has been started already, by this or another
+// instance.
+func (r *ReconciliationRepository) Start(run *model.ReconciliationRun) (bool, error) {
+	run.Status = model.ReconciliationRunning
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "slot"}},
+		DoNothing: true,
+	}).Create(run)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Finish stores the counts and discrepancies of a run that has ended,
+// with reason if it failed.
+func (r *ReconciliationRepository) Finish(run *model.ReconciliationRun, reason string) error {
+	discrepancies, err := json.Marshal(run.Discrepancies)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	run.Status = model.ReconciliationCompleted
+	if reason != "" {
+		run.Status = model.ReconciliationFailed
+	}
+	run.Error = reason
+	run.CompletedAt = &now
+	return r.db.Model(&model.ReconciliationRun{}).
+		Where("id = ?", run.ID).
+		Updates(map[string]interface{}{
+			"status":        run.Status,
+			"checked":       run.Checked,
+			"skipped":       run.Skipped,
+			"found":         run.Found,
+			"repaired":      run.Repaired,
+			"discrepancies": string(discrepancies),
+			"error":         reason,
+			"completed_at":  now,
+		}).Error
+}
+
+// Latest returns the last run to have ended.
+func (r *ReconciliationRepository) Latest() (*model.ReconciliationRun, error) {
+	var run model.ReconciliationRun
+	err := r.db.Where("status <> ?", model.ReconciliationRunning).
+		Order("id DESC").
+		First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReconciliationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
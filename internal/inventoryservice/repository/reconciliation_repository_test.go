@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+func TestReconciliationRunsOncePerSlot(t *testing.T) {
+	inventories := newTestRepository(t)
+	runs := NewReconciliationRepository(inventories.db)
+
+	run := &model.ReconciliationRun{Slot: 100, Source: model.ReconcileFromInventory, StartedAt: time.Now()}
+	if started, err := runs.Start(run); err != nil || !started {
+		t.Fatalf("start = %v, %v, want started", started, err)
+	}
+	// Another instance ticking in the same period
+	other := &model.ReconciliationRun{Slot: 100, Source: model.ReconcileFromInventory, StartedAt: time.Now()}
+	if started, err := runs.Start(other); err != nil || started {
+		t.Fatalf("second start in the slot = %v, %v, want not started", started, err)
+	}
+
+	if _, err := runs.Latest(); !errors.Is(err, ErrReconciliationNotFound) {
+		t.Errorf("latest while running: err = %v, want ErrReconciliationNotFound", err)
+	}
+
+	run.Checked = 2
+	run.Add(model.Discrepancy{Kind: model.DiscrepancyStockMismatch, ItemID: "lamp", InventoryStock: 3, ItemStock: 5, Repaired: true})
+	if err := runs.Finish(run, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := runs.Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.ID != run.ID || latest.Status != model.ReconciliationCompleted || latest.Found != 1 || latest.Repaired != 1 {
+		t.Errorf("latest = %+v, want run %d completed with one repair", latest, run.ID)
+	}
+	if len(latest.Discrepancies) != 1 || latest.Discrepancies[0].ItemID != "lamp" {
+		t.Errorf("discrepancies = %+v, want the lamp mismatch", latest.Discrepancies)
+	}
+}
+
+func TestReconcileStockRefusesChangedStock(t *testing.T) {
+	inventories := newTestRepository(t)
+	if _, err := inventories.AdjustStock("lamp", 10, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A sale lands after the stock was compared
+	if _, err := inventories.AdjustStock("lamp", -1, &model.StockMovement{Type: model.MovementSale}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inventories.ReconcileStock("lamp", 10, 12, &model.StockMovement{Type: model.MovementCorrection}); !errors.Is(err, ErrStockChanged) {
+		t.Errorf("reconcile of changed stock: err = %v, want ErrStockChanged", err)
+	}
+	assertStock(t, inventories, "lamp", 9)
+
+	if _, err := inventories.ReconcileStock("lamp", 9, 12, &model.StockMovement{Type: model.MovementCorrection}); err != nil {
+		t.Fatal(err)
+	}
+	assertStock(t, inventories, "lamp", 12)
+
+	// An item without a record has no stock
+	if _, err := inventories.ReconcileStock("desk", 0, 4, &model.StockMovement{Type: model.MovementCorrection}); err != nil {
+		t.Fatal(err)
+	}
+	assertStock(t, inventories, "desk", 4)
+}
+
+func TestOrphanedStock(t *testing.T) {
+	inventories := newTestRepository(t)
+	receiveLot(t, inventories, "milk", "a", 4, 24*time.Hour)
+	receiveLot(t, inventories, "cream", "b", 2, 24*time.Hour)
+
+	// A soft-deleted record may still be restored by its saga
+	if _, err := NewSagaRepository(inventories.db, inventories).DeleteInventory("cream", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := inventories.db.Unscoped().Where("item_id = ?", "milk").Delete(&model.Inventory{}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	orphans, err := inventories.OrphanedStock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 1 || orphans["milk"] != 4 {
+		t.Errorf("orphans = %v, want milk 4", orphans)
+	}
+
+	known, err := inventories.KnownItemIDs([]string{"milk", "cream"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if known["milk"] || !known["cream"] {
+		t.Errorf("known = %v, want cream only", known)
+	}
+
+	if err := inventories.DeleteOrphanedStock("cream"); !errors.Is(err, ErrStockChanged) {
+		t.Errorf("delete of stock with a record: err = %v, want ErrStockChanged", err)
+	}
+	if err := inventories.DeleteOrphanedStock("milk"); err != nil {
+		t.Fatal(err)
+	}
+	if got := lotQuantities(t, inventories, "milk"); len(got) != 0 {
+		t.Errorf("lots after delete = %v, want none", got)
+	}
+}
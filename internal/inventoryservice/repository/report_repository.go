@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+
+	"gorm.io/gorm"
+)
+
+var ErrReportNotFound = errors.New("report not found")
+
+// ReportRepository stores report jobs, which workers claim and complete.
+type ReportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// Create queues report as pending.
+func (r *ReportRepository) Create(report *model.Report) error {
+	report.Status = model.ReportPending
+	return r.db.Create(report).Error
+}
+
+func (r *ReportRepository) GetByID(id uint) (*model.Report, error) {
+	var report model.Report
+	err := r.db.First(&report, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetAll lists reports without their results, newest first.
+func (r *ReportRepository) GetAll() ([]*model.Report, error) {
+	var reports []*model.Report
+	err := r.db.Omit("result").Order("id DESC").Find(&reports).Error
+	return reports, err
+}
+
+// Claim marks the oldest pending report as running and returns it, or nil
+// if there is none. Reports that have been running since before staleBefore
+// are claimed again, as their worker is presumed dead. Each report is
+// claimed by one worker only.
+func (r *ReportRepository) Claim(staleBefore time.Time) (*model.Report, error) {
+	for {
+		var report model.Report
+		err := r.db.Omit("result").
+			Where("status = ? OR (status = ? AND started_at < ?)", model.ReportPending, model.ReportRunning, staleBefore).
+			Order("id").
+			First(&report).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Only one worker's update matches the report as it was read
+		now := time.Now()
+		query := r.db.Model(&model.Report{}).Where("id = ? AND status = ?", report.ID, report.Status)
+		if report.StartedAt != nil {
+			query = query.Where("started_at = ?", report.StartedAt)
+		}
+		result := query.Updates(map[string]interface{}{
+			"status":     model.ReportRunning,
+			"started_at": now,
+		})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			report.Status = model.ReportRunning
+			report.StartedAt = &now
+			return &report, nil
+		}
+	}
+}
+
+// Complete stores the result of a running report.
+func (r *ReportRepository) Complete(id uint, result []byte) error {
+	return r.finish(id, map[string]interface{}{
+		"status": model.ReportCompleted,
+		"result": string(result),
+	})
+}
+
+// Fail records why a running report could not be generated.
+func (r *ReportRepository) Fail(id uint, reason string) error {
+	return r.finish(id, map[string]interface{}{
+		"status": model.ReportFailed,
+		"error":  reason,
+	})
+}
+
+func (r *ReportRepository) finish(id uint, updates map[string]interface{}) error {
+	updates["completed_at"] = time.Now()
+	return r.db.Model(&model.Report{}).
+		Where("id = ? AND status = ?", id, model.ReportRunning).
+		Updates(updates).Error
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+func TestReportsAreClaimedOnce(t *testing.T) {
+	reports := NewReportRepository(newTestRepository(t).db)
+
+	first := &model.Report{Type: model.ReportValuation, CreatedBy: 1}
+	second := &model.Report{Type: model.ReportTurnover, Days: 30, CreatedBy: 1}
+	for _, report := range []*model.Report{first, second} {
+		if err := reports.Create(report); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	staleBefore := time.Now().Add(-time.Hour)
+	claimed, err := reports.Claim(staleBefore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed == nil || claimed.ID != first.ID || claimed.Status != model.ReportRunning {
+		t.Fatalf("first claim = %+v, want report %d running", claimed, first.ID)
+	}
+	if claimed, err = reports.Claim(staleBefore); err != nil || claimed == nil || claimed.ID != second.ID {
+		t.Fatalf("second claim = %+v, %v, want report %d", claimed, err, second.ID)
+	}
+	if claimed, err = reports.Claim(staleBefore); err != nil || claimed != nil {
+		t.Fatalf("third claim = %+v, %v, want nothing left", claimed, err)
+	}
+
+	if err := reports.Complete(first.ID, []byte(`{"totals":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+	report, err := reports.GetByID(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != model.ReportCompleted || string(report.Result) != `{"totals":[]}` || report.CompletedAt == nil {
+		t.Errorf("completed report = %+v, want its result", report)
+	}
+
+	// A worker that died mid-report leaves it running until its lease ends
+	claimed, err = reports.Claim(time.Now().Add(time.Second))
+	if err != nil || claimed == nil || claimed.ID != second.ID {
+		t.Fatalf("claim of a stale report = %+v, %v, want report %d", claimed, err, second.ID)
+	}
+}
+
+func TestMovementTotalsAndInflows(t *testing.T) {
+	inventories := newTestRepository(t)
+
+	for _, adjustment := range []struct {
+		movementType model.MovementType
+		delta        int
+	}{
+		{model.MovementReceipt, 20},
+		{model.MovementSale, -5},
+		{model.MovementReturn, 2},
+		{model.MovementReceipt, 10},
+		{model.MovementSale, -7},
+		{model.MovementShrinkage, -1},
+	} {
+		movement := &model.StockMovement{Type: adjustment.movementType}
+		if _, err := inventories.AdjustStock("lamp", adjustment.delta, movement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	totals, err := inventories.Totals(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := totals["lamp"]; got.Net != 19 || got.Sold != 12 {
+		t.Errorf("totals = %+v, want net 19 and 12 sold", got)
+	}
+	if totals, err = inventories.Totals(time.Now().Add(time.Hour)); err != nil || len(totals) != 0 {
+		t.Errorf("totals of a period without movements = %v, %v, want none", totals, err)
+	}
+
+	inflows, err := inventories.GetInflows("lamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var quantities []int
+	for _, inflow := range inflows {
+		quantities = append(quantities, inflow.Quantity)
+	}
+	if len(quantities) != 3 || quantities[0] != 10 || quantities[1] != 2 || quantities[2] != 20 {
+		t.Errorf("inflows = %v, want 10, 2 and 20, newest first", quantities)
+	}
+}
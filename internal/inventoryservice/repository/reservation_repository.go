@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationExpired   = errors.New("reservation has expired")
+	ErrReservationConfirmed = errors.New("reservation is already confirmed")
+	ErrReservationReleased  = errors.New("reservation is already released")
+	// ErrIdempotencyKeyReused is returned when an idempotency key is sent
+	// again with a different request.
+	ErrIdempotencyKeyReused = errors.New("idempotency key already used for a different reservation")
+)
+
+// expireBatchSize caps how many reservations one sweep expires.
+const expireBatchSize = 500
+
+// ReservationRepository stores reservations and keeps Inventory.Reserved
+// equal to the quantity of active reservations: every change of a
+// reservation's status updates the counter in the same transaction.
+type ReservationRepository struct {
+	db *gorm.DB
+}
+
+func NewReservationRepository(db *gorm.DB) *ReservationRepository {
+	return &ReservationRepository{db: db}
+}
+
+// Reserve holds stock for a new reservation. If the same user has made a
+// reservation with the same idempotency key, it is returned instead and created is false.
+// ErrInsufficientStock is returned when less than the quantity is available.
+func (r *ReservationRepository) Reserve(reservation *model.Reservation) (result *model.Reservation, created bool, err error) {
+	if reservation.IdempotencyKey != "" {
+		existing, err := r.replay(reservation)
+		if existing != nil || err != nil {
+			return existing, false, err
+		}
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		update := tx.Model(&model.Inventory{}).
+			Where("item_id = ? AND "+availableStock+" >= ?", reservation.ItemID, reservation.Quantity).
+			Update("reserved", gorm.Expr("reserved + ?", reservation.Quantity))
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return ErrInsufficientStock
+		}
+
+		reservation.Status = model.ReservationActive
+		return tx.Create(reservation).Error
+	})
+	if err != nil && reservation.IdempotencyKey != "" && !errors.Is(err, ErrInsufficientStock) {
+		// A concurrent retry may have created it first
+		if existing, replayErr := r.replay(reservation); existing != nil || replayErr != nil {
+			return existing, false, replayErr
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return reservation, true, nil
+}
+
+// replay returns the reservation the same user created earlier with the
+// same idempotency key, or nil if there is none.
+func (r *ReservationRepository) replay(reservation *model.Reservation) (*model.Reservation, error) {
+	var existing model.Reservation
+	err := r.db.
+		Where("user_id = ? AND idempotency_key = ?", reservation.UserID, reservation.IdempotencyKey).
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if existing.ItemID != reservation.ItemID || existing.Quantity != reservation.Quantity {
+		return nil, ErrIdempotencyKeyReused
+	}
+	return &existing, nil
+}
+
+func (r *ReservationRepository) GetByID(id uint) (*model.Reservation, error) {
+	var reservation model.Reservation
+	err := r.db.First(&reservation, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Confirm turns a reservation into a sale: its quantity leaves both stock
+// and the reserved count, and the sale is recorded in the ledger with a
+// sync_stock saga. Confirming a confirmed reservation returns it unchanged
+// with a nil change. A reservation past its expiry is expired instead and
+// ErrReservationExpired returned.
+func (r *ReservationRepository) Confirm(id uint, userID uint32) (*model.Reservation, *StockChange, error) {
+	var reservation model.Reservation
+	var changes stockChanges
+	var closedErr error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockForUpdate(tx).First(&reservation, id).Error; err != nil {
+			return err
+		}
+
+		switch reservation.Status {
+		case model.ReservationConfirmed:
+			return nil
+		case model.ReservationReleased:
+			closedErr = ErrReservationReleased
+			return nil
+		case model.ReservationExpired:
+			closedErr = ErrReservationExpired
+			return nil
+		}
+
+		now := time.Now()
+		if !reservation.ExpiresAt.After(now) {
+			closedErr = ErrReservationExpired
+			return closeReservation(tx, &reservation, model.ReservationExpired, now)
+		}
+
+		err := tx.Model(&model.Inventory{}).
+			Where("item_id = ?", reservation.ItemID).
+			Updates(map[string]interface{}{
+				"stock":      gorm.Expr("stock - ?", reservation.Quantity),
+				"reserved":   gorm.Expr("reserved - ?", reservation.Quantity),
+				"updated_by": userID,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		inventory := &model.Inventory{}
+		if err := tx.Where("item_id = ?", reservation.ItemID).First(inventory).Error; err != nil {
+			return err
+		}
+
+		reference := reservation.Reference
+		if reference == "" {
+			reference = "reservation:" + strconv.FormatUint(uint64(reservation.ID), 10)
+		}
+		err = allocate(tx, inventory, -reservation.Quantity, &model.StockMovement{
+			Type:      model.MovementSale,
+			Reason:    fmt.Sprintf("reservation %d confirmed", reservation.ID),
+			Reference: reference,
+			UserID:    userID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := enqueueStockUpdate(tx, inventory, userID); err != nil {
+			return err
+		}
+		changes.add(inventory, -reservation.Quantity, userID)
+		if err := changes.start(tx); err != nil {
+			return err
+		}
+
+		reservation.Status = model.ReservationConfirmed
+		reservation.ConfirmedAt = &now
+		return tx.Save(&reservation).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if closedErr != nil {
+		return nil, nil, closedErr
+	}
+	if len(changes.changes) == 0 {
+		return &reservation, nil, nil
+	}
+	return &reservation, changes.changes[0], nil
+}
+
+// Release gives a reservation's stock back. Releasing a released or expired
+// reservation returns it unchanged; a confirmed one cannot be released.
+func (r *ReservationRepository) Release(id uint) (*model.Reservation, error) {
+	var reservation model.Reservation
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockForUpdate(tx).First(&reservation, id).Error; err != nil {
+			return err
+		}
+
+		switch reservation.Status {
+		case model.ReservationConfirmed:
+			return ErrReservationConfirmed
+		case model.ReservationActive:
+			return closeReservation(tx, &reservation, model.ReservationReleased, time.Now())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// ExpireDue expires the active reservations whose time is up and returns
+// how many it expired.
+func (r *ReservationRepository) ExpireDue(now time.Time) (int, error) {
+	var due []model.Reservation
+	err := r.db.Where("status = ? AND expires_at <= ?", model.ReservationActive, now).
+		Order("expires_at").
+		Limit(expireBatchSize).
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range due {
+		var released bool
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			// Skip reservations confirmed or released since they were listed
+			result := tx.Model(&model.Reservation{}).
+				Where("id = ? AND status = ?", due[i].ID, model.ReservationActive).
+				Updates(map[string]interface{}{"status": model.ReservationExpired, "released_at": now})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			released = true
+			return tx.Model(&model.Inventory{}).
+				Where("item_id = ?", due[i].ItemID).
+				Update("reserved", gorm.Expr("reserved - ?", due[i].Quantity)).Error
+		})
+		if err != nil {
+			return expired, err
+		}
+		if released {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// closeReservation ends an active reservation and returns its stock.
+func closeReservation(tx *gorm.DB, reservation *model.Reservation, status model.ReservationStatus, now time.Time) error {
+	err := tx.Model(&model.Inventory{}).
+		Where("item_id = ?", reservation.ItemID).
+		Update("reserved", gorm.Expr("reserved - ?", reservation.Quantity)).Error
+	if err != nil {
+		return err
+	}
+
+	reservation.Status = status
+	reservation.ReleasedAt = &now
+	return tx.Save(reservation).Error
+}
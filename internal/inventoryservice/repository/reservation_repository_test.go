@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+func newReservation(itemID string, quantity int, key string) *model.Reservation {
+	return &model.Reservation{
+		ItemID:         itemID,
+		Quantity:       quantity,
+		IdempotencyKey: key,
+		ExpiresAt:      time.Now().Add(time.Minute),
+	}
+}
+
+func assertReserved(t *testing.T, repo *InventoryRepository, itemID string, stock, reserved int) {
+	t.Helper()
+
+	inventory, err := repo.GetByItemID(itemID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inventory.Stock != stock || inventory.Reserved != reserved || inventory.Available != stock-reserved {
+		t.Errorf("stock %d, reserved %d, available %d; want %d, %d, %d",
+			inventory.Stock, inventory.Reserved, inventory.Available, stock, reserved, stock-reserved)
+	}
+}
+
+func TestReserveNeverOverbooks(t *testing.T) {
+	inventories := newTestRepository(t)
+	reservations := NewReservationRepository(inventories.db)
+	if _, err := inventories.AdjustStock("lamp", 10, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, err := reservations.Reserve(newReservation("lamp", 2, fmt.Sprintf("checkout-%d", i)))
+			switch {
+			case err == nil:
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			case !errors.Is(err, ErrInsufficientStock):
+				t.Errorf("reservation %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if reserved != 5 {
+		t.Errorf("%d reservations succeeded, want 5", reserved)
+	}
+	assertReserved(t, inventories, "lamp", 10, 10)
+
+	// Reserved stock cannot be sold from under a checkout
+	if _, err := inventories.AdjustStock("lamp", -1, &model.StockMovement{Type: model.MovementSale}); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("decrement of reserved stock: err = %v, want ErrInsufficientStock", err)
+	}
+}
+
+func TestReserveIsIdempotent(t *testing.T) {
+	inventories := newTestRepository(t)
+	reservations := NewReservationRepository(inventories.db)
+	if _, err := inventories.AdjustStock("lamp", 10, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Retries of the same checkout arrive at once
+	ids := make([]uint, 8)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reservation, _, err := reservations.Reserve(newReservation("lamp", 3, "checkout-1"))
+			if err != nil {
+				t.Errorf("retry %d: %v", i, err)
+				return
+			}
+			ids[i] = reservation.ID
+		}(i)
+	}
+	wg.Wait()
+
+	for i, id := range ids {
+		if id != ids[0] {
+			t.Errorf("retry %d got reservation %d, want %d", i, id, ids[0])
+		}
+	}
+	assertReserved(t, inventories, "lamp", 10, 3)
+
+	if _, _, err := reservations.Reserve(newReservation("lamp", 4, "checkout-1")); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("different request with the same key: err = %v, want ErrIdempotencyKeyReused", err)
+	}
+}
+
+func TestReserveScopesIdempotencyKeysToTheUser(t *testing.T) {
+	inventories := newTestRepository(t)
+	reservations := NewReservationRepository(inventories.db)
+	if _, err := inventories.AdjustStock("lamp", 10, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := inventories.AdjustStock("desk", 1, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+
+	first := newReservation("lamp", 3, "checkout-1")
+	first.UserID = 1
+	if _, _, err := reservations.Reserve(first); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another user happens to send the same key
+	same := newReservation("lamp", 3, "checkout-1")
+	same.UserID = 2
+	reservation, created, err := reservations.Reserve(same)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || reservation.ID == first.ID {
+		t.Errorf("user 2 got reservation %d of user 1, want a new one", reservation.ID)
+	}
+	other := newReservation("desk", 1, "checkout-1")
+	other.UserID = 3
+	if _, _, err := reservations.Reserve(other); err != nil {
+		t.Errorf("key of another user for a different item: err = %v, want it treated as unused", err)
+	}
+	assertReserved(t, inventories, "lamp", 10, 6)
+	assertReserved(t, inventories, "desk", 1, 1)
+}
+
+func TestReservationLifecycle(t *testing.T) {
+	inventories := newTestRepository(t)
+	reservations := NewReservationRepository(inventories.db)
+	if _, err := inventories.AdjustStock("lamp", 10, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+
+	confirmed, _, err := reservations.Reserve(newReservation("lamp", 3, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	released, _, err := reservations.Reserve(newReservation("lamp", 2, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiring := newReservation("lamp", 4, "")
+	expiring.ExpiresAt = time.Now().Add(-time.Second)
+	if _, _, err := reservations.Reserve(expiring); err != nil {
+		t.Fatal(err)
+	}
+	assertReserved(t, inventories, "lamp", 10, 9)
+
+	_, change, err := reservations.Confirm(confirmed.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saga := change.Saga; saga.ID == 0 || saga.Type != model.SagaSyncStock || saga.PreviousStock != 10 || saga.NewStock != 7 {
+		t.Errorf("confirm started saga %+v, want sync_stock from 10 to 7", saga)
+	}
+	if _, change, err := reservations.Confirm(confirmed.ID, 1); err != nil || change != nil {
+		t.Errorf("second confirm: change %v, err %v; want no change", change, err)
+	}
+	assertReserved(t, inventories, "lamp", 7, 6)
+
+	if _, err := reservations.Release(released.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reservations.Release(released.ID); err != nil {
+		t.Errorf("second release: %v", err)
+	}
+	if _, err := reservations.Release(confirmed.ID); !errors.Is(err, ErrReservationConfirmed) {
+		t.Errorf("release of confirmed reservation: err = %v, want ErrReservationConfirmed", err)
+	}
+	assertReserved(t, inventories, "lamp", 7, 4)
+
+	expired, err := reservations.ExpireDue(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired != 1 {
+		t.Errorf("expired %d reservations, want 1", expired)
+	}
+	if _, _, err := reservations.Confirm(expiring.ID, 1); !errors.Is(err, ErrReservationExpired) {
+		t.Errorf("confirm of expired reservation: err = %v, want ErrReservationExpired", err)
+	}
+	assertReserved(t, inventories, "lamp", 7, 0)
+	assertStock(t, inventories, "lamp", 7)
+}
@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+	"rancher-manager/kafka"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInventoryNotFound = errors.New("inventory record not found")
+	// ErrSagaConflict is returned when a saga would be advanced from a step
+	// it is no longer at, because another worker has advanced it.
+	ErrSagaConflict = errors.New("saga was advanced concurrently")
+)
+
+// SagaRepository stores sagas and performs their local steps, each in one
+// transaction with recording the step.
+type SagaRepository struct {
+	db          *gorm.DB
+	inventories *InventoryRepository
+}
+
+func NewSagaRepository(db *gorm.DB, inventories *InventoryRepository) *SagaRepository {
+	return &SagaRepository{db: db, inventories: inventories}
+}
+
+// SetStock sets an item's stock like InventoryRepository.SetStock and
+// starts an update_stock saga for it in the same transaction.
+func (r *SagaRepository) SetStock(itemID string, newStock int, movement *model.StockMovement) (*model.Inventory, *model.Saga, error) {
+	var inventory *model.Inventory
+	var saga *model.Saga
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var previous int
+		var err error
+		inventory, previous, err = r.inventories.setStock(tx, itemID, newStock, movement)
+		if err != nil {
+			return err
+		}
+
+		saga = &model.Saga{
+			Type:          model.SagaUpdateStock,
+			ItemID:        itemID,
+			Status:        model.SagaStarted,
+			Step:          model.SagaStepStockUpdated,
+			PreviousStock: previous,
+			NewStock:      newStock,
+			UserID:        movement.UserID,
+		}
+		return tx.Create(saga).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return inventory, saga, nil
+}
+
+// AdjustStock adjusts an item's stock like InventoryRepository.AdjustStock
+// and starts an update_stock saga for it in the same transaction.
+func (r *SagaRepository) AdjustStock(itemID string, delta int, movement *model.StockMovement) (*model.Inventory, *model.Saga, error) {
+	var inventory *model.Inventory
+	var saga *model.Saga
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		inventory, err = r.inventories.adjustStock(tx, itemID, delta, movement)
+		if err != nil {
+			return err
+		}
+
+		saga = &model.Saga{
+			Type:          model.SagaUpdateStock,
+			ItemID:        itemID,
+			Status:        model.SagaStarted,
+			Step:          model.SagaStepStockUpdated,
+			PreviousStock: inventory.Stock - delta,
+			NewStock:      inventory.Stock,
+			UserID:        movement.UserID,
+		}
+		return tx.Create(saga).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return inventory, saga, nil
+}
+
+// StockChange is an item's stock as changed by a transaction, with the
+// sync_stock saga that syncs it to ItemService.
+type StockChange struct {
+	Inventory *model.Inventory
+	Saga      *model.Saga
+}
+
+// stockChanges collects the stock changes made in one transaction, one per
+// item, so that a sync_stock saga can be started for each.
+type stockChanges struct {
+	changes []*StockChange
+	byItem  map[string]*StockChange
+}
+
+// add records that inventory has changed by delta, after the changes to it
+// added before.
+func (c *stockChanges) add(inventory *model.Inventory, delta int, userID uint32) {
+	change, ok := c.byItem[inventory.ItemID]
+	if !ok {
+		if c.byItem == nil {
+			c.byItem = make(map[string]*StockChange)
+		}
+		change = &StockChange{Saga: &model.Saga{
+			Type:          model.SagaSyncStock,
+			ItemID:        inventory.ItemID,
+			Status:        model.SagaStarted,
+			Step:          model.SagaStepStockUpdated,
+			PreviousStock: inventory.Stock - delta,
+			UserID:        userID,
+		}}
+		c.byItem[inventory.ItemID] = change
+		c.changes = append(c.changes, change)
+	}
+	change.Inventory = inventory
+	change.Saga.NewStock = inventory.Stock
+}
+
+// start creates the sagas of the changes within tx.
+func (c *stockChanges) start(tx *gorm.DB) error {
+	for _, change := range c.changes {
+		if err := tx.Create(change.Saga).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteInventory deletes an item's inventory record and starts a
+// delete_item saga for it in the same transaction. The item's lots and
+// stock at locations are kept until Purge, so that Restore can bring the
+// record back as it was.
+func (r *SagaRepository) DeleteInventory(itemID string, userID uint32) (*model.Saga, error) {
+	var saga *model.Saga
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var inventory model.Inventory
+		err := lockForUpdate(tx).Where("item_id = ?", itemID).First(&inventory).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInventoryNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&inventory).Error; err != nil {
+			return err
+		}
+
+		saga = &model.Saga{
+			Type:          model.SagaDeleteItem,
+			ItemID:        itemID,
+			Status:        model.SagaStarted,
+			Step:          model.SagaStepInventoryDeleted,
+			PreviousStock: inventory.Stock,
+			UserID:        userID,
+		}
+		return tx.Create(saga).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saga, nil
+}
+
+// Revert compensates an update_stock saga by adjusting the stock back by
+// the change it made, recorded as a correction. Stock changed since by
+// others is kept. It returns the inventory record as reverted.
+func (r *SagaRepository) Revert(saga *model.Saga) (*model.Inventory, error) {
+	var inventory *model.Inventory
+	status, step := saga.Status, saga.Step
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Recording the step first stops a second revert of the same saga
+		if err := advance(tx, saga, model.SagaCompensating, model.SagaStepStockReverted); err != nil {
+			return err
+		}
+
+		var err error
+		if delta := saga.PreviousStock - saga.NewStock; delta != 0 {
+			inventory, err = r.inventories.adjustStock(tx, saga.ItemID, delta, &model.StockMovement{
+				Type:      model.MovementCorrection,
+				Reason:    "stock change reverted as it could not be synced to the item service",
+				Reference: fmt.Sprintf("saga-%d", saga.ID),
+				UserID:    saga.UserID,
+			})
+		} else {
+			inventory = &model.Inventory{}
+			err = tx.Where("item_id = ?", saga.ItemID).First(inventory).Error
+		}
+		return err
+	})
+	if err != nil {
+		saga.Status, saga.Step = status, step
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// Restore compensates a delete_item saga by bringing back the inventory
+// record.
+func (r *SagaRepository) Restore(saga *model.Saga) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&model.Inventory{}).
+			Where("item_id = ?", saga.ItemID).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+		return advance(tx, saga, model.SagaCompensated, model.SagaStepRestored)
+	})
+}
+
+// Purge completes a delete_item saga by deleting the item's lots and stock
+// at locations, and reports the deletion.
+func (r *SagaRepository) Purge(saga *model.Saga) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("item_id = ?", saga.ItemID).Delete(&model.LocationStock{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("item_id = ?", saga.ItemID).Delete(&model.Lot{}).Error; err != nil {
+			return err
+		}
+		if err := enqueue(tx, &kafka.ItemDeleteEvent{ItemID: saga.ItemID, UserID: saga.UserID}); err != nil {
+			return err
+		}
+		return advance(tx, saga, model.SagaCompleted, model.SagaStepPurged)
+	})
+}
+
+// Advance records that saga has completed step and is now in status.
+func (r *SagaRepository) Advance(saga *model.Saga, status model.SagaStatus, step model.SagaStep) error {
+	return advance(r.db, saga, status, step)
+}
+
+// Fail records that saga has stopped without completing or being
+// compensated.
+func (r *SagaRepository) Fail(saga *model.Saga, reason string) error {
+	saga.LastError = reason
+	return advance(r.db, saga, model.SagaFailed, saga.Step)
+}
+
+// RecordError records why the last attempt at a step of saga failed.
+func (r *SagaRepository) RecordError(saga *model.Saga, reason string) error {
+	saga.LastError = reason
+	return r.db.Model(saga).Update("last_error", reason).Error
+}
+
+// Claim returns the oldest saga that is still running but has not moved
+// since staleBefore, or nil if there is none, counting an attempt at it.
+// Each saga is claimed by one worker only until it goes stale again.
+func (r *SagaRepository) Claim(staleBefore time.Time) (*model.Saga, error) {
+	for {
+		var saga model.Saga
+		err := r.db.
+			Where("status IN ? AND updated_at < ?", []model.SagaStatus{model.SagaStarted, model.SagaCompensating}, staleBefore).
+			Order("id").
+			First(&saga).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		result := r.db.Model(&model.Saga{}).
+			Where("id = ? AND updated_at = ?", saga.ID, saga.UpdatedAt).
+			Updates(map[string]interface{}{
+				"attempts":   saga.Attempts + 1,
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			saga.Attempts++
+			saga.UpdatedAt = now
+			return &saga, nil
+		}
+	}
+}
+
+// GetAll lists sagas, newest first, optionally only those in one status.
+func (r *SagaRepository) GetAll(status model.SagaStatus) ([]*model.Saga, error) {
+	query := r.db.Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var sagas []*model.Saga
+	err := query.Find(&sagas).Error
+	return sagas, err
+}
+
+// advance moves saga on from the step it is at, failing with
+// ErrSagaConflict if it is no longer there.
+func advance(tx *gorm.DB, saga *model.Saga, status model.SagaStatus, step model.SagaStep) error {
+	result := tx.Model(&model.Saga{}).
+		Where("id = ? AND step = ? AND status = ?", saga.ID, saga.Step, saga.Status).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"step":       step,
+			"last_error": saga.LastError,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSagaConflict
+	}
+	saga.Status = status
+	saga.Step = step
+	return nil
+}
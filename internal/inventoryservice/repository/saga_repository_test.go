@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+func TestRevertKeepsConcurrentStockChanges(t *testing.T) {
+	inventories := newTestRepository(t)
+	sagas := NewSagaRepository(inventories.db, inventories)
+
+	if _, err := inventories.AdjustStock("lamp", 10, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+	inventory, saga, err := sagas.SetStock("lamp", 25, &model.StockMovement{Type: model.MovementCorrection, UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inventory.Stock != 25 || saga.PreviousStock != 10 || saga.Step != model.SagaStepStockUpdated {
+		t.Fatalf("saga = %+v with stock %d, want 10 to 25 and stock_updated", saga, inventory.Stock)
+	}
+
+	// A sale lands before the failed sync is compensated
+	if _, err := inventories.AdjustStock("lamp", -3, &model.StockMovement{Type: model.MovementSale}); err != nil {
+		t.Fatal(err)
+	}
+
+	inventory, err = sagas.Revert(saga)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inventory.Stock != 7 || saga.Status != model.SagaCompensating || saga.Step != model.SagaStepStockReverted {
+		t.Errorf("reverted stock %d, saga %s at %s, want 7, compensating at stock_reverted", inventory.Stock, saga.Status, saga.Step)
+	}
+
+	// A second revert, say by a worker that took the saga over, is refused
+	stale := *saga
+	stale.Status, stale.Step = model.SagaStarted, model.SagaStepStockUpdated
+	if _, err := sagas.Revert(&stale); !errors.Is(err, ErrSagaConflict) {
+		t.Errorf("second revert: err = %v, want ErrSagaConflict", err)
+	}
+	assertStock(t, inventories, "lamp", 7)
+
+	movements, err := inventories.GetMovements("lamp", model.MovementFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := movements[len(movements)-1]; last.Quantity != -15 || last.Reference != "saga-1" {
+		t.Errorf("last movement = %+v, want -15 referencing saga-1", last)
+	}
+}
+
+func TestDeleteInventoryCanBeRestoredUntilPurged(t *testing.T) {
+	inventories := newTestRepository(t)
+	sagas := NewSagaRepository(inventories.db, inventories)
+	receiveLot(t, inventories, "milk", "a", 4, 24*time.Hour)
+
+	if _, err := sagas.DeleteInventory("cheese", 1); !errors.Is(err, ErrInventoryNotFound) {
+		t.Errorf("delete of an unknown item: err = %v, want ErrInventoryNotFound", err)
+	}
+
+	saga, err := sagas.DeleteInventory("milk", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inventories.GetByItemID("milk"); err == nil {
+		t.Fatal("inventory record still there after delete")
+	}
+
+	if err := sagas.Restore(saga); err != nil {
+		t.Fatal(err)
+	}
+	assertStock(t, inventories, "milk", 4)
+	if got := lotQuantities(t, inventories, "milk"); got["a"] != 4 {
+		t.Errorf("lots after restore = %v, want a 4", got)
+	}
+
+	saga, err = sagas.DeleteInventory("milk", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sagas.Advance(saga, model.SagaStarted, model.SagaStepItemDeleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := sagas.Purge(saga); err != nil {
+		t.Fatal(err)
+	}
+	if got := lotQuantities(t, inventories, "milk"); len(got) != 0 {
+		t.Errorf("lots after purge = %v, want none", got)
+	}
+	if saga.Status != model.SagaCompleted {
+		t.Errorf("status = %s, want completed", saga.Status)
+	}
+}
+
+func TestClaimTakesOverStaleSagas(t *testing.T) {
+	inventories := newTestRepository(t)
+	sagas := NewSagaRepository(inventories.db, inventories)
+
+	_, running, err := sagas.SetStock("lamp", 5, &model.StockMovement{Type: model.MovementCorrection})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, done, err := sagas.SetStock("desk", 5, &model.StockMovement{Type: model.MovementCorrection})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sagas.Advance(done, model.SagaCompleted, model.SagaStepItemSynced); err != nil {
+		t.Fatal(err)
+	}
+
+	if saga, err := sagas.Claim(time.Now().Add(-time.Minute)); err != nil || saga != nil {
+		t.Fatalf("claim of a saga in progress = %+v, %v, want nothing", saga, err)
+	}
+
+	staleBefore := time.Now().Add(time.Second)
+	saga, err := sagas.Claim(staleBefore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saga == nil || saga.ID != running.ID || saga.Attempts != 1 {
+		t.Fatalf("claim = %+v, want saga %d on its first attempt", saga, running.ID)
+	}
+
+	// Claiming refreshed the saga, so it is not handed out twice
+	if saga, err := sagas.Claim(saga.UpdatedAt); err != nil || saga != nil {
+		t.Errorf("second claim = %+v, %v, want nothing", saga, err)
+	}
+}
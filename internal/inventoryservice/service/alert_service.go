@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+	"rancher-manager/kafka"
+)
+
+// consumptionWindow is how far back sales and write-offs are averaged to
+// estimate an item's daily consumption.
+const consumptionWindow = 30 * 24 * time.Hour
+
+// UpdateThresholds sets an item's MinStock, MaxStock and lead time.
+func (s *InventoryService) UpdateThresholds(itemID string, req *model.UpdateThresholdsRequest, userID uint32) (*model.Inventory, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	if err := s.checkStockWritable(itemID, userID); err != nil {
+		return nil, err
+	}
+
+	inventory, err := s.inventoryRepo.SetThresholds(itemID, req, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.checkLowStock(inventory)
+	return inventory, nil
+}
+
+// GetAlerts lists the items that are low on stock or have reached their
+// reorder point, lowest first.
+func (s *InventoryService) GetAlerts(userID uint32) ([]*model.StockAlert, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	inventories, err := s.inventoryRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	consumption, err := s.inventoryRepo.Consumption(time.Now().Add(-consumptionWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := []*model.StockAlert{}
+	for _, inventory := range inventories {
+		if alert := stockAlert(inventory, consumption[inventory.ItemID]); alert != nil {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Level != alerts[j].Level {
+			return alerts[i].Level == model.AlertLow
+		}
+		return alerts[i].Available-alerts[i].ReorderPoint < alerts[j].Available-alerts[j].ReorderPoint
+	})
+	return alerts, nil
+}
+
+// stockAlert returns the alert for an item that consumed the given quantity
+// during the consumption window, or nil if it needs no replenishing.
+func stockAlert(inventory *model.Inventory, consumed int) *model.StockAlert {
+	daily := float64(consumed) / (consumptionWindow.Hours() / 24)
+	reorderPoint := inventory.MinStock + int(math.Ceil(daily*float64(inventory.LeadTimeDays)))
+
+	var level model.AlertLevel
+	switch {
+	case inventory.IsLow():
+		level = model.AlertLow
+	case reorderPoint > 0 && inventory.Available <= reorderPoint:
+		level = model.AlertReorder
+	default:
+		return nil
+	}
+
+	return &model.StockAlert{
+		ItemID:                 inventory.ItemID,
+		Level:                  level,
+		Stock:                  inventory.Stock,
+		Reserved:               inventory.Reserved,
+		Available:              inventory.Available,
+		MinStock:               inventory.MinStock,
+		MaxStock:               inventory.MaxStock,
+		LowStockSince:          inventory.LowStockSince,
+		DailyConsumption:       math.Round(daily*100) / 100,
+		LeadTimeDays:           inventory.LeadTimeDays,
+		ReorderPoint:           reorderPoint,
+		SuggestedOrderQuantity: max(inventory.MaxStock-inventory.Available, 0),
+	}
+}
+
+// checkLowStock reports a stock_low event through the outbox when an item's
+// available stock has dropped below MinStock since the last check, and
+// forgets the alert once it has recovered. Failures are logged; the periodic
+// detector retries.
+func (s *InventoryService) checkLowStock(inventory *model.Inventory) {
+	if !inventory.IsLow() {
+		if inventory.LowStockSince != nil {
+			if err := s.inventoryRepo.ClearLowStock(inventory.ItemID); err != nil {
+				log.Printf("Failed to clear low stock alert of %s: %v", inventory.ItemID, err)
+			}
+		}
+		return
+	}
+	if inventory.LowStockSince != nil {
+		return
+	}
+
+	consumption, err := s.inventoryRepo.Consumption(time.Now().Add(-consumptionWindow), inventory.ItemID)
+	if err != nil {
+		log.Printf("Failed to check low stock of %s: %v", inventory.ItemID, err)
+		return
+	}
+	alert := stockAlert(inventory, consumption[inventory.ItemID])
+
+	// The event is written to the outbox together with the mark
+	event := &kafka.StockLowEvent{
+		ItemID:                 alert.ItemID,
+		Stock:                  alert.Stock,
+		Available:              alert.Available,
+		MinStock:               alert.MinStock,
+		ReorderPoint:           alert.ReorderPoint,
+		SuggestedOrderQuantity: alert.SuggestedOrderQuantity,
+	}
+	if _, err := s.inventoryRepo.MarkLowStock(event, time.Now()); err != nil {
+		log.Printf("Failed to record low stock alert of %s: %v", inventory.ItemID, err)
+	}
+}
+
+// DetectLowStock checks every item for low stock. It catches what the checks
+// after each stock change miss, such as stock taken by reservations.
+func (s *InventoryService) DetectLowStock() error {
+	inventories, err := s.inventoryRepo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list inventory: %v", err)
+	}
+
+	for _, inventory := range inventories {
+		s.checkLowStock(inventory)
+	}
+	return nil
+}
+
+// RunLowStockDetector runs DetectLowStock every interval until ctx ends.
+func (s *InventoryService) RunLowStockDetector(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.DetectLowStock(); err != nil {
+				log.Printf("Low stock detection failed: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// SupervisorRoles are the auth roles allowed to approve count variances
+// above the session's limit.
+var SupervisorRoles = map[string]bool{
+	"admin":      true,
+	"manager":    true,
+	"supervisor": true,
+}
+
+// SetCountVarianceLimit sets the largest variance, in units, count sessions
+// started from now on let counters approve without a supervisor.
+func (s *InventoryService) SetCountVarianceLimit(limit int) {
+	s.countVarianceLimit = limit
+}
+
+// CreateCount starts a count session, snapshotting the stock of the given
+// items and of everything stocked at the given locations.
+func (s *InventoryService) CreateCount(req *model.CreateCountRequest, userID uint32) (*model.CountSession, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	var itemIDs []string
+	for _, itemID := range req.ItemIDs {
+		if itemID = strings.TrimSpace(itemID); itemID != "" {
+			itemIDs = append(itemIDs, itemID)
+		}
+	}
+	if len(itemIDs) == 0 && len(req.LocationIDs) == 0 {
+		return nil, errors.New("item_ids or location_ids are required")
+	}
+
+	session := &model.CountSession{
+		Name:          strings.TrimSpace(req.Name),
+		VarianceLimit: s.countVarianceLimit,
+		CreatedBy:     userID,
+	}
+	if err := s.countRepo.Create(session, itemIDs, req.LocationIDs); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *InventoryService) GetCount(id uint, userID uint32) (*model.CountSession, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	return s.countRepo.GetByID(id)
+}
+
+func (s *InventoryService) GetCounts(status model.CountStatus, userID uint32) ([]*model.CountSession, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	return s.countRepo.GetAll(status)
+}
+
+// SubmitCount records counted quantities and returns the session with the
+// resulting variances.
+func (s *InventoryService) SubmitCount(id uint, req *model.SubmitCountRequest, userID uint32) (*model.CountSession, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	counts := make(map[uint]int, len(req.Counts))
+	for _, entry := range req.Counts {
+		if _, duplicate := counts[entry.LineID]; duplicate {
+			return nil, fmt.Errorf("line %d is counted twice", entry.LineID)
+		}
+		counts[entry.LineID] = *entry.Quantity
+	}
+
+	if err := s.countRepo.Submit(id, counts, userID); err != nil {
+		return nil, err
+	}
+	return s.countRepo.GetByID(id)
+}
+
+// ApproveCount posts the variances of counted lines to stock. Variances
+// above the session's limit need a user with one of the SupervisorRoles.
+func (s *InventoryService) ApproveCount(id uint, req *model.ApproveCountRequest, userID uint32) (*model.CountSession, error) {
+	// Validate user exists via gRPC
+	user, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	if req.ReasonCode != "" && !req.ReasonCode.Valid() {
+		return nil, fmt.Errorf("invalid reason code %q", req.ReasonCode)
+	}
+
+	supervisor := SupervisorRoles[user.GetUser().GetRole()]
+	changed, err := s.countRepo.Approve(id, req.LineIDs, req.ReasonCode, userID, supervisor)
+	if err != nil {
+		return nil, err
+	}
+
+	s.completeStockChanges(changed)
+
+	return s.countRepo.GetByID(id)
+}
+
+// CloseCount ends a count session without posting the lines not yet
+// approved.
+func (s *InventoryService) CloseCount(id uint, userID uint32) (*model.CountSession, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	if err := s.countRepo.Close(id, userID); err != nil {
+		return nil, err
+	}
+	return s.countRepo.GetByID(id)
+}
@@ -7,165 +7,283 @@ import (
 	"rancher-manager/internal/inventoryservice/grpc"
 	"rancher-manager/internal/inventoryservice/model"
 	"rancher-manager/internal/inventoryservice/repository"
-	"rancher-manager/kafka"
 )
 
 type InventoryService struct {
-	inventoryRepo *repository.InventoryRepository
-	authClient    *grpc.AuthClient
-	itemClient    *grpc.ItemClient
-	publisher     *kafka.Publisher
+	inventoryRepo      *repository.InventoryRepository
+	reservationRepo    *repository.ReservationRepository
+	locationRepo       *repository.LocationRepository
+	countRepo          *repository.CountRepository
+	reportRepo         *repository.ReportRepository
+	sagaRepo           *repository.SagaRepository
+	reconciliationRepo *repository.ReconciliationRepository
+	purchaseOrderRepo  *repository.PurchaseOrderRepository
+	authClient         *grpc.AuthClient
+	itemClient         *grpc.ItemClient
+	// countVarianceLimit is the variance limit of new count sessions.
+	countVarianceLimit int
+	// reportQueued wakes the report worker when a report is created.
+	reportQueued chan struct{}
 }
 
 func NewInventoryService(
 	inventoryRepo *repository.InventoryRepository,
+	reservationRepo *repository.ReservationRepository,
+	locationRepo *repository.LocationRepository,
+	countRepo *repository.CountRepository,
+	reportRepo *repository.ReportRepository,
+	sagaRepo *repository.SagaRepository,
+	reconciliationRepo *repository.ReconciliationRepository,
+	purchaseOrderRepo *repository.PurchaseOrderRepository,
 	authClient *grpc.AuthClient,
 	itemClient *grpc.ItemClient,
-	publisher *kafka.Publisher,
 ) *InventoryService {
 	return &InventoryService{
-		inventoryRepo: inventoryRepo,
-		authClient:    authClient,
-		itemClient:    itemClient,
-		publisher:     publisher,
+		inventoryRepo:      inventoryRepo,
+		reservationRepo:    reservationRepo,
+		locationRepo:       locationRepo,
+		countRepo:          countRepo,
+		reportRepo:         reportRepo,
+		sagaRepo:           sagaRepo,
+		reconciliationRepo: reconciliationRepo,
+		purchaseOrderRepo:  purchaseOrderRepo,
+		authClient:         authClient,
+		itemClient:         itemClient,
+		countVarianceLimit: model.DefaultCountVarianceLimit,
+		reportQueued:       make(chan struct{}, 1),
 	}
 }
 
-func (s *InventoryService) UpdateStock(itemID string, newStock int, userID uint32) (*model.Inventory, error) {
+// UpdateStock sets an item's stock and records the change in the ledger.
+func (s *InventoryService) UpdateStock(itemID string, req *model.UpdateStockRequest, userID uint32) (*model.Inventory, error) {
 	// Validate user exists via gRPC
 	_, err := s.authClient.GetUser(userID)
 	if err != nil {
-		return nil, errors.New("unauthorized: invalid user")
+		return nil, model.ErrUnauthorized
 	}
 
-	// Get item from ItemService via gRPC
-	itemResponse, err := s.itemClient.GetItem(itemID, userID)
+	movementType, err := movementTypeOrDefault(req.Type)
 	if err != nil {
-		return nil, errors.New("item not found")
+		return nil, err
 	}
 
-	if !itemResponse.Success {
-		return nil, errors.New("item not found")
+	if err := s.checkStockWritable(itemID, userID); err != nil {
+		return nil, err
 	}
 
-	// Check if inventory record exists
-	exists, err := s.inventoryRepo.ExistsByItemID(itemID)
+	// Setting the stock and syncing it to ItemService is a saga, so that the
+	// two stores agree again if the sync fails
+	inventory, saga, err := s.sagaRepo.SetStock(itemID, req.NewStock, &model.StockMovement{
+		Type:      movementType,
+		Reason:    req.Reason,
+		Reference: req.Reference,
+		UserID:    userID,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var inventory *model.Inventory
-	if !exists {
-		// Create new inventory record
-		inventory = &model.Inventory{
-			ItemID:    itemID,
-			Stock:     newStock,
-			MinStock:  0,
-			MaxStock:  1000,
-			UpdatedBy: userID,
+	if err := s.completeStockUpdate(saga, inventory); err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// AdjustStock adds delta to an item's stock, which may be negative, and
+// records the change in the ledger. Unlike UpdateStock it is safe to use
+// concurrently: every adjustment is applied, and none takes stock below
+// zero.
+func (s *InventoryService) AdjustStock(itemID string, delta int, req *model.AdjustStockRequest, userID uint32) (*model.Inventory, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	if delta == 0 {
+		return nil, model.InvalidRequest("quantity must not be zero")
+	}
+
+	movementType, err := movementTypeOrDefault(req.Type)
+	if err != nil {
+		return nil, err
+	}
+	if direction := movementType.Direction(); direction != 0 && direction*delta < 0 {
+		if delta > 0 {
+			return nil, model.InvalidRequest("movement type %q cannot increase stock", movementType)
 		}
-		err = s.inventoryRepo.Create(inventory)
-	} else {
-		// Update existing inventory record
-		inventory, err = s.inventoryRepo.GetByItemID(itemID)
+		return nil, model.InvalidRequest("movement type %q cannot decrease stock", movementType)
+	}
+
+	if err := s.checkStockWritable(itemID, userID); err != nil {
+		return nil, err
+	}
+
+	movement := &model.StockMovement{
+		LocationID: req.LocationID,
+		Type:       movementType,
+		Reason:     req.Reason,
+		Reference:  req.Reference,
+		UserID:     userID,
+	}
+	if req.LotNumber != "" {
+		lot, err := s.adjustedLot(itemID, delta, req, userID)
 		if err != nil {
 			return nil, err
 		}
-		inventory.Stock = newStock
-		inventory.UpdatedBy = userID
-		err = s.inventoryRepo.Update(inventory)
+		movement.LotID = &lot.ID
 	}
 
+	// Like UpdateStock, adjusting the stock and syncing it to ItemService is
+	// a saga
+	inventory, saga, err := s.sagaRepo.AdjustStock(itemID, delta, movement)
+	if errors.Is(err, repository.ErrInsufficientStock) {
+		return nil, fmt.Errorf("%w: cannot remove %d", repository.ErrInsufficientStock, -delta)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Update stock in ItemService via gRPC
-	_, err = s.itemClient.UpdateStock(itemID, int32(newStock), userID)
+	if err := s.completeStockUpdate(saga, inventory); err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// movementTypeOrDefault checks a client-supplied movement type, defaulting to
+// a correction.
+func movementTypeOrDefault(movementType model.MovementType) (model.MovementType, error) {
+	if movementType == "" {
+		return model.MovementCorrection, nil
+	}
+	if !movementType.Valid() {
+		return "", model.InvalidRequest("invalid movement type %q", movementType)
+	}
+	return movementType, nil
+}
+
+// checkStockWritable checks with ItemService that the item exists and is
+// not archived.
+func (s *InventoryService) checkStockWritable(itemID string, userID uint32) error {
+	// Get item from ItemService via gRPC
+	itemResponse, err := s.itemClient.GetItem(itemID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update stock in item service: %v", err)
+		return model.Errorf(model.ErrItemSync, "failed to get item from item service: %v", err)
 	}
 
-	// Publish Kafka event
-	if s.publisher != nil {
-		event := &kafka.StockUpdateEvent{
-			ItemID:   itemID,
-			NewStock: newStock,
-			UserID:   userID,
-		}
-		if err := s.publisher.PublishStockUpdate(event); err != nil {
-			// Log error but don't fail the operation
-			fmt.Printf("Failed to publish stock update event: %v\n", err)
-		}
+	if !itemResponse.Success {
+		return model.ErrItemNotFound
 	}
 
-	return inventory, nil
+	if itemResponse.Item != nil && itemResponse.Item.Status == "archived" {
+		return model.ErrItemArchived
+	}
+	return nil
 }
 
 func (s *InventoryService) DeleteItem(itemID string, userID uint32) error {
 	// Validate user exists via gRPC
 	_, err := s.authClient.GetUser(userID)
 	if err != nil {
-		return errors.New("unauthorized: invalid user")
+		return model.ErrUnauthorized
 	}
 
-	// Check if inventory record exists
-	exists, err := s.inventoryRepo.ExistsByItemID(itemID)
+	// The inventory record is deleted before the item, which cannot be
+	// restored, so that failing to delete the item can be compensated
+	saga, err := s.sagaRepo.DeleteInventory(itemID, userID)
 	if err != nil {
 		return err
 	}
 
-	if !exists {
-		return errors.New("inventory record not found")
+	return s.completeItemDelete(saga)
+}
+
+func (s *InventoryService) GetStock(itemID string, userID uint32) (*model.Inventory, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
 	}
 
-	// Delete from ItemService via gRPC
-	_, err = s.itemClient.DeleteItem(itemID, userID)
+	inventory, err := s.inventoryRepo.GetByItemID(itemID)
 	if err != nil {
-		return fmt.Errorf("failed to delete item from item service: %v", err)
+		return nil, repository.ErrInventoryNotFound
 	}
 
-	// Delete inventory record
-	err = s.inventoryRepo.Delete(itemID)
+	return inventory, nil
+}
+
+// BatchGetStock returns the inventory records of the given items, in the
+// order asked for, and the IDs of those that have none.
+func (s *InventoryService) BatchGetStock(itemIDs []string, userID uint32) ([]*model.Inventory, []string, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
 	if err != nil {
-		return err
+		return nil, nil, model.ErrUnauthorized
 	}
 
-	// Publish Kafka event
-	if s.publisher != nil {
-		event := &kafka.ItemDeleteEvent{
-			ItemID: itemID,
-			UserID: userID,
-		}
-		if err := s.publisher.PublishItemDelete(event); err != nil {
-			// Log error but don't fail the operation
-			fmt.Printf("Failed to publish item delete event: %v\n", err)
-		}
+	found, err := s.inventoryRepo.GetByItemIDs(itemIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	byItemID := make(map[string]*model.Inventory, len(found))
+	for _, inventory := range found {
+		byItemID[inventory.ItemID] = inventory
 	}
 
-	return nil
+	inventories := []*model.Inventory{}
+	missingIDs := []string{}
+	for _, itemID := range itemIDs {
+		if inventory, ok := byItemID[itemID]; ok {
+			inventories = append(inventories, inventory)
+		} else {
+			missingIDs = append(missingIDs, itemID)
+		}
+	}
+	return inventories, missingIDs, nil
 }
 
-func (s *InventoryService) GetStock(itemID string, userID uint32) (*model.Inventory, error) {
+// GetMovements lists an item's ledger entries together with its current
+// stock and the stock the ledger adds up to.
+func (s *InventoryService) GetMovements(itemID string, filter model.MovementFilter, userID uint32) (*model.MovementsResponse, error) {
 	// Validate user exists via gRPC
 	_, err := s.authClient.GetUser(userID)
 	if err != nil {
-		return nil, errors.New("unauthorized: invalid user")
+		return nil, model.ErrUnauthorized
+	}
+
+	if filter.Type != "" && !filter.Type.Valid() && filter.Type != model.MovementOpeningBalance {
+		return nil, model.InvalidRequest("invalid movement type %q", filter.Type)
 	}
 
 	inventory, err := s.inventoryRepo.GetByItemID(itemID)
 	if err != nil {
-		return nil, errors.New("inventory record not found")
+		return nil, repository.ErrInventoryNotFound
 	}
 
-	return inventory, nil
+	movements, err := s.inventoryRepo.GetMovements(itemID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerStock, err := s.inventoryRepo.LedgerStock(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.MovementsResponse{
+		Data:        movements,
+		Stock:       inventory.Stock,
+		LedgerStock: ledgerStock,
+		Reconciled:  inventory.Stock == ledgerStock,
+	}, nil
 }
 
 func (s *InventoryService) GetAllItems(userID uint32) ([]*model.Inventory, error) {
 	// Validate user exists via gRPC
 	_, err := s.authClient.GetUser(userID)
 	if err != nil {
-		return nil, errors.New("unauthorized: invalid user")
+		return nil, model.ErrUnauthorized
 	}
 
 	inventories, err := s.inventoryRepo.GetAll()
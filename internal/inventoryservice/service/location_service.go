@@ -0,0 +1,173 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"rancher-manager/internal/inventoryservice/model"
+	"rancher-manager/internal/inventoryservice/repository"
+)
+
+func (s *InventoryService) CreateLocation(req *model.CreateLocationRequest, userID uint32) (*model.Location, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	location := &model.Location{
+		Warehouse: strings.TrimSpace(req.Warehouse),
+		Zone:      strings.TrimSpace(req.Zone),
+		Bin:       strings.TrimSpace(req.Bin),
+		Name:      strings.TrimSpace(req.Name),
+	}
+	if location.Warehouse == "" {
+		return nil, errors.New("warehouse is required")
+	}
+	if location.Bin != "" && location.Zone == "" {
+		return nil, errors.New("a bin must be within a zone")
+	}
+	if strings.Contains(location.Warehouse+location.Zone+location.Bin, "/") {
+		return nil, errors.New("location levels may not contain '/'")
+	}
+	location.Code = model.LocationCode(location.Warehouse, location.Zone, location.Bin)
+
+	if err := s.locationRepo.Create(location); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+func (s *InventoryService) GetLocations(warehouse string, userID uint32) ([]*model.Location, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	return s.locationRepo.GetAll(warehouse)
+}
+
+// GetItemLocations breaks an item's stock down by location. Stock is the
+// total across locations, which is what ItemService is kept in sync with.
+func (s *InventoryService) GetItemLocations(itemID string, userID uint32) (*model.ItemLocations, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	inventory, err := s.inventoryRepo.GetByItemID(itemID)
+	if err != nil {
+		return nil, errors.New("inventory record not found")
+	}
+
+	stocks, err := s.locationRepo.ItemStock(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	unassigned := inventory.Stock - inventory.InTransit
+	for _, stock := range stocks {
+		unassigned -= stock.Quantity
+	}
+
+	return &model.ItemLocations{
+		ItemID:     itemID,
+		Stock:      inventory.Stock,
+		Reserved:   inventory.Reserved,
+		InTransit:  inventory.InTransit,
+		Available:  inventory.Available,
+		Unassigned: unassigned,
+		Locations:  stocks,
+	}, nil
+}
+
+// CreateTransfer ships stock from one location to another. It stays in
+// transit, unavailable, until the transfer is received.
+func (s *InventoryService) CreateTransfer(req *model.CreateTransferRequest, userID uint32) (*model.Transfer, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	transfer := &model.Transfer{
+		ItemID:         req.ItemID,
+		FromLocationID: req.FromLocationID,
+		ToLocationID:   req.ToLocationID,
+		Quantity:       req.Quantity,
+		Reference:      req.Reference,
+		CreatedBy:      userID,
+	}
+	if err := s.locationRepo.Ship(transfer); err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return nil, errors.New("insufficient stock at the source location")
+		}
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func (s *InventoryService) GetTransfer(id uint, userID uint32) (*model.Transfer, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	transfer, err := s.locationRepo.GetTransfer(id)
+	if err != nil {
+		return nil, transferError(err)
+	}
+	return transfer, nil
+}
+
+func (s *InventoryService) GetTransfers(itemID string, status model.TransferStatus, userID uint32) ([]*model.Transfer, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	return s.locationRepo.GetTransfers(itemID, status)
+}
+
+// ReceiveTransfer puts the stock of a transfer at its destination.
+func (s *InventoryService) ReceiveTransfer(id uint, userID uint32) (*model.Transfer, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	transfer, err := s.locationRepo.Receive(id, userID)
+	if err != nil {
+		return nil, transferError(err)
+	}
+	return transfer, nil
+}
+
+// CancelTransfer returns the stock of a transfer to its source.
+func (s *InventoryService) CancelTransfer(id uint, userID uint32) (*model.Transfer, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	transfer, err := s.locationRepo.Cancel(id)
+	if err != nil {
+		return nil, transferError(err)
+	}
+	return transfer, nil
+}
+
+func transferError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("transfer not found")
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// adjustedLot returns the lot a stock adjustment names. Increments create
+// it if it is new; decrements need it to exist.
+func (s *InventoryService) adjustedLot(itemID string, delta int, req *model.AdjustStockRequest, userID uint32) (*model.Lot, error) {
+	lotNumber := strings.TrimSpace(req.LotNumber)
+	if lotNumber == "" {
+		return nil, model.InvalidRequest("lot_number must not be blank")
+	}
+	if delta < 0 {
+		return s.inventoryRepo.GetLotByNumber(itemID, lotNumber)
+	}
+
+	if req.ManufacturedAt != nil && req.ExpiresAt != nil && req.ExpiresAt.Before(*req.ManufacturedAt) {
+		return nil, model.InvalidRequest("expires_at must not be before manufactured_at")
+	}
+	return s.inventoryRepo.EnsureLot(&model.Lot{
+		ItemID:         itemID,
+		LotNumber:      lotNumber,
+		ManufacturedAt: req.ManufacturedAt,
+		ExpiresAt:      req.ExpiresAt,
+		UpdatedBy:      userID,
+	})
+}
+
+// GetLots lists an item's lots in the order decrements take from them.
+func (s *InventoryService) GetLots(itemID string, userID uint32) ([]*model.Lot, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	return s.inventoryRepo.GetLots(itemID)
+}
+
+// GetExpiringLots lists lots with stock that expire within the given number
+// of days, including those already expired.
+func (s *InventoryService) GetExpiringLots(days int, userID uint32) ([]*model.Lot, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	return s.inventoryRepo.ExpiringLots(time.Now().AddDate(0, 0, days))
+}
+
+// QuarantineLot holds a lot's stock back from sale until it is released.
+func (s *InventoryService) QuarantineLot(id uint, req *model.LotHoldRequest, userID uint32) (*model.Lot, error) {
+	return s.setLotStatus(id, model.LotQuarantined, req.Reason, userID)
+}
+
+// BlockLot holds a lot's stock back from sale for good; it can only be
+// written off.
+func (s *InventoryService) BlockLot(id uint, req *model.LotHoldRequest, userID uint32) (*model.Lot, error) {
+	return s.setLotStatus(id, model.LotBlocked, req.Reason, userID)
+}
+
+// ReleaseLot makes a quarantined lot's stock available again.
+func (s *InventoryService) ReleaseLot(id uint, userID uint32) (*model.Lot, error) {
+	return s.setLotStatus(id, model.LotAvailable, "", userID)
+}
+
+// setLotStatus changes a lot's status and checks its item for low stock,
+// since holding a lot lowers the stock available.
+func (s *InventoryService) setLotStatus(id uint, status model.LotStatus, reason string, userID uint32) (*model.Lot, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	lot, err := s.inventoryRepo.SetLotStatus(id, status, strings.TrimSpace(reason), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if inventory, err := s.inventoryRepo.GetByItemID(lot.ItemID); err == nil {
+		s.checkLowStock(inventory)
+	}
+	return lot, nil
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"rancher-manager/internal/inventoryservice/repository"
+	"rancher-manager/kafka"
+)
+
+const (
+	// outboxBatchSize is how many events the relay reads at a time.
+	outboxBatchSize = 100
+	// outboxMaxBackoff caps how long the relay waits after a failed publish.
+	outboxMaxBackoff = time.Minute
+	// outboxRetention is how long sent events are kept.
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+// OutboxPublisher sends events to Kafka; *kafka.Publisher is one.
+type OutboxPublisher interface {
+	Publish(message *kafka.Message) error
+}
+
+// OutboxMetrics reports how far the outbox relay is behind.
+type OutboxMetrics struct {
+	pending   prometheus.Gauge
+	lag       prometheus.Gauge
+	published prometheus.Counter
+	failures  prometheus.Counter
+}
+
+// NewOutboxMetrics registers inventory_outbox_pending_events,
+// inventory_outbox_lag_seconds, inventory_outbox_published_total and
+// inventory_outbox_publish_failures_total.
+func NewOutboxMetrics(reg prometheus.Registerer) *OutboxMetrics {
+	m := &OutboxMetrics{
+		pending: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "inventory_outbox_pending_events",
+			Help: "Events in the outbox not yet published to Kafka.",
+		}),
+		lag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "inventory_outbox_lag_seconds",
+			Help: "Age of the oldest event in the outbox not yet published, 0 if there is none.",
+		}),
+		published: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "inventory_outbox_published_total",
+			Help: "Events published from the outbox.",
+		}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "inventory_outbox_publish_failures_total",
+			Help: "Failed attempts at publishing an event from the outbox.",
+		}),
+	}
+	reg.MustRegister(m.pending, m.lag, m.published, m.failures)
+	return m
+}
+
+// OutboxRelay publishes the events in the outbox to Kafka in the order they
+// were written. An event that fails to publish is retried, with backoff,
+// before any later event is published, so that consumers never see an
+// item's stock go back to an older value. Events are published at least
+// once; each carries its outbox ID as event_id so that consumers can drop
+// duplicates.
+type OutboxRelay struct {
+	outbox    *repository.OutboxRepository
+	connect   func() (OutboxPublisher, error)
+	publisher OutboxPublisher
+	metrics   *OutboxMetrics
+}
+
+// NewOutboxRelay returns a relay that connects to Kafka with connect,
+// retrying until it succeeds; events wait in the outbox meanwhile.
+func NewOutboxRelay(outbox *repository.OutboxRepository, connect func() (OutboxPublisher, error), metrics *OutboxMetrics) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:  outbox,
+		connect: connect,
+		metrics: metrics,
+	}
+}
+
+// Relay publishes pending events until none are left or one fails, and
+// returns how many it published.
+func (r *OutboxRelay) Relay() (int, error) {
+	defer r.updateLag()
+
+	if r.publisher == nil {
+		publisher, err := r.connect()
+		if err != nil {
+			return 0, fmt.Errorf("failed to connect to Kafka: %v", err)
+		}
+		r.publisher = publisher
+	}
+
+	published := 0
+	for {
+		events, err := r.outbox.Pending(outboxBatchSize)
+		if err != nil {
+			return published, err
+		}
+
+		for _, event := range events {
+			message := &kafka.Message{
+				Topic: event.Topic,
+				Key:   event.Key,
+				Value: []byte(event.Payload),
+				ID:    fmt.Sprintf("inventoryservice-%d", event.ID),
+			}
+			if err := r.publisher.Publish(message); err != nil {
+				r.metrics.failures.Inc()
+				if rerr := r.outbox.RecordFailure(event.ID, err.Error()); rerr != nil {
+					log.Printf("Failed to record failed publish of outbox event %d: %v", event.ID, rerr)
+				}
+				return published, fmt.Errorf("failed to publish outbox event %d: %v", event.ID, err)
+			}
+
+			if err := r.outbox.MarkSent(event.ID, time.Now()); err != nil {
+				// Published but not marked: it goes out again next time
+				return published, err
+			}
+			r.metrics.published.Inc()
+			published++
+		}
+
+		if len(events) < outboxBatchSize {
+			return published, nil
+		}
+	}
+}
+
+// updateLag sets the pending and lag gauges.
+func (r *OutboxRelay) updateLag() {
+	pending, oldest, err := r.outbox.Lag()
+	if err != nil {
+		log.Printf("Failed to measure outbox lag: %v", err)
+		return
+	}
+	r.metrics.pending.Set(float64(pending))
+	if oldest == nil {
+		r.metrics.lag.Set(0)
+	} else {
+		r.metrics.lag.Set(time.Since(*oldest).Seconds())
+	}
+}
+
+// Run relays events every interval until ctx ends, waiting up to
+// outboxMaxBackoff, doubling the wait after each consecutive failure. Sent
+// events are deleted after outboxRetention.
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	wait := interval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if _, err := r.Relay(); err != nil {
+			log.Printf("Outbox relay: %v", err)
+			wait = min(wait*2, max(interval, outboxMaxBackoff))
+			continue
+		}
+		wait = interval
+
+		if _, err := r.outbox.DeleteSent(time.Now().Add(-outboxRetention)); err != nil {
+			log.Printf("Failed to delete sent outbox events: %v", err)
+		}
+	}
+}
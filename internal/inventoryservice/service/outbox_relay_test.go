@@ -0,0 +1,133 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"rancher-manager/internal/inventoryservice/model"
+	"rancher-manager/internal/inventoryservice/repository"
+	"rancher-manager/kafka"
+)
+
+// fakePublisher records what it publishes and fails while down is set.
+type fakePublisher struct {
+	down     bool
+	messages []*kafka.Message
+}
+
+func (p *fakePublisher) Publish(message *kafka.Message) error {
+	if p.down {
+		return errors.New("kafka: client has run out of available brokers")
+	}
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "inventory.db") + "?_busy_timeout=10000&_journal_mode=WAL&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(
+		&model.Inventory{},
+		&model.StockMovement{},
+		&model.LocationStock{},
+		&model.Lot{},
+		&model.Reservation{},
+		&model.Saga{},
+		&model.OutboxEvent{},
+	); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestOutboxRelayPublishesInOrderAfterKafkaRecovers(t *testing.T) {
+	db := newTestDB(t)
+	inventories := repository.NewInventoryRepository(db)
+	publisher := &fakePublisher{down: true}
+	connects := 0
+	metrics := NewOutboxMetrics(prometheus.NewRegistry())
+	relay := NewOutboxRelay(repository.NewOutboxRepository(db), func() (OutboxPublisher, error) {
+		if connects++; connects == 1 {
+			return nil, errors.New("kafka: client has run out of available brokers")
+		}
+		return publisher, nil
+	}, metrics)
+
+	for _, delta := range []int{10, -4, 6} {
+		if _, err := inventories.AdjustStock("lamp", delta, &model.StockMovement{Type: model.MovementCorrection, UserID: 7}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A change that fails writes no event
+	if _, err := inventories.AdjustStock("lamp", -100, &model.StockMovement{Type: model.MovementSale}); err == nil {
+		t.Fatal("decrement below zero succeeded")
+	}
+
+	if _, err := relay.Relay(); err == nil {
+		t.Fatal("relay succeeded without Kafka")
+	}
+	if _, err := relay.Relay(); err == nil {
+		t.Fatal("relay succeeded while Kafka is down")
+	}
+	if got := testutil.ToFloat64(metrics.pending); got != 3 {
+		t.Errorf("pending = %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(metrics.failures); got != 1 {
+		t.Errorf("failures = %v, want 1", got)
+	}
+	var first model.OutboxEvent
+	if err := db.Order("id").First(&first).Error; err != nil {
+		t.Fatal(err)
+	}
+	if first.Attempts != 1 || first.LastError == "" || first.SentAt != nil {
+		t.Errorf("first event = %+v, want one failed attempt", first)
+	}
+
+	publisher.down = false
+	published, err := relay.Relay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published != 3 || len(publisher.messages) != 3 {
+		t.Fatalf("published %d, %d messages, want 3", published, len(publisher.messages))
+	}
+	for i, want := range []int{10, 6, 12} {
+		message := publisher.messages[i]
+		var event kafka.StockUpdateEvent
+		if err := json.Unmarshal(message.Value, &event); err != nil {
+			t.Fatal(err)
+		}
+		if message.Topic != kafka.TopicStockUpdates || event.NewStock != want || event.UserID != 7 || message.ID == "" {
+			t.Errorf("message %d = %s %s %+v, want stock %d", i, message.Topic, message.ID, event, want)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.pending); got != 0 {
+		t.Errorf("pending after recovery = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(metrics.lag); got != 0 {
+		t.Errorf("lag after recovery = %v, want 0", got)
+	}
+
+	// Sent events are not published again
+	if published, err := relay.Relay(); err != nil || published != 0 {
+		t.Errorf("second relay published %d, %v, want nothing", published, err)
+	}
+}
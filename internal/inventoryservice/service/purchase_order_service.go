@@ -0,0 +1,191 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"rancher-manager/internal/inventoryservice/model"
+	"rancher-manager/internal/inventoryservice/repository"
+)
+
+func (s *InventoryService) CreateSupplier(req *model.CreateSupplierRequest, userID uint32) (*model.Supplier, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	supplier := &model.Supplier{
+		Name:      strings.TrimSpace(req.Name),
+		Email:     strings.TrimSpace(req.Email),
+		Phone:     strings.TrimSpace(req.Phone),
+		CreatedBy: userID,
+	}
+	if supplier.Name == "" {
+		return nil, errors.New("name must not be blank")
+	}
+	if err := s.purchaseOrderRepo.CreateSupplier(supplier); err != nil {
+		return nil, err
+	}
+	return supplier, nil
+}
+
+func (s *InventoryService) GetSuppliers(userID uint32) ([]*model.Supplier, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	return s.purchaseOrderRepo.GetSuppliers()
+}
+
+// CreatePurchaseOrder places an order for items ItemService knows and has
+// not archived.
+func (s *InventoryService) CreatePurchaseOrder(req *model.CreatePurchaseOrderRequest, userID uint32) (*model.PurchaseOrder, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	order := &model.PurchaseOrder{
+		SupplierID: req.SupplierID,
+		Reference:  strings.TrimSpace(req.Reference),
+		ExpectedAt: req.ExpectedAt,
+		CreatedBy:  userID,
+	}
+	itemIDs := make([]string, 0, len(req.Lines))
+	seen := make(map[string]bool, len(req.Lines))
+	for _, line := range req.Lines {
+		itemID := strings.TrimSpace(line.ItemID)
+		if itemID == "" {
+			return nil, errors.New("item_id must not be blank")
+		}
+		if seen[itemID] {
+			return nil, fmt.Errorf("item %s is listed more than once", itemID)
+		}
+		seen[itemID] = true
+		itemIDs = append(itemIDs, itemID)
+		order.Lines = append(order.Lines, &model.PurchaseOrderLine{ItemID: itemID, Ordered: line.Quantity})
+	}
+
+	items, missingIDs, err := s.itemClient.BatchGetItems(itemIDs, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items from item service: %v", err)
+	}
+	if len(missingIDs) > 0 {
+		return nil, fmt.Errorf("item not found: %s", strings.Join(missingIDs, ", "))
+	}
+	for _, item := range items {
+		if item.Status == "archived" {
+			return nil, fmt.Errorf("item %s is archived; it can no longer be ordered", item.Id)
+		}
+	}
+
+	if err := s.purchaseOrderRepo.Create(order); err != nil {
+		return nil, err
+	}
+	return s.purchaseOrderRepo.GetByID(order.ID)
+}
+
+func (s *InventoryService) GetPurchaseOrder(id uint, userID uint32) (*model.PurchaseOrder, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	return s.purchaseOrderRepo.GetByID(id)
+}
+
+func (s *InventoryService) GetPurchaseOrders(status model.PurchaseOrderStatus, supplierID uint, userID uint32) ([]*model.PurchaseOrder, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	if status != "" && !status.Valid() {
+		return nil, fmt.Errorf("invalid purchase order status %q", status)
+	}
+	return s.purchaseOrderRepo.GetAll(status, supplierID)
+}
+
+// ReceivePurchaseOrder posts a delivery on an order to stock.
+func (s *InventoryService) ReceivePurchaseOrder(id uint, req *model.ReceivePurchaseOrderRequest, userID uint32) (*model.PurchaseOrder, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	order, err := s.purchaseOrderRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	itemIDs := make(map[uint]string, len(order.Lines))
+	for _, line := range order.Lines {
+		itemIDs[line.ID] = line.ItemID
+	}
+
+	receipt := &model.Receipt{LocationID: req.LocationID, ReceivedBy: userID}
+	for _, line := range req.Lines {
+		received := &model.ReceiptLine{LineID: line.LineID, Quantity: line.Quantity}
+		if line.LotNumber != "" {
+			itemID, ok := itemIDs[line.LineID]
+			if !ok {
+				return nil, fmt.Errorf("%w: %d", repository.ErrPurchaseOrderLineNotFound, line.LineID)
+			}
+			lot, err := s.adjustedLot(itemID, line.Quantity, &model.AdjustStockRequest{
+				LotNumber:      line.LotNumber,
+				ManufacturedAt: line.ManufacturedAt,
+				ExpiresAt:      line.ExpiresAt,
+			}, userID)
+			if err != nil {
+				return nil, err
+			}
+			received.LotID = &lot.ID
+		}
+		receipt.Lines = append(receipt.Lines, received)
+	}
+
+	changed, err := s.purchaseOrderRepo.Receive(id, receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	s.completeStockChanges(changed)
+
+	return s.purchaseOrderRepo.GetByID(id)
+}
+
+// ClosePurchaseOrder stops an order expecting more stock, recording what
+// was never received as under-receipts.
+func (s *InventoryService) ClosePurchaseOrder(id uint, userID uint32) (*model.PurchaseOrder, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	if err := s.purchaseOrderRepo.Close(id, userID); err != nil {
+		return nil, err
+	}
+	return s.purchaseOrderRepo.GetByID(id)
+}
+
+// CancelPurchaseOrder withdraws an order nothing has been received on.
+func (s *InventoryService) CancelPurchaseOrder(id uint, userID uint32) (*model.PurchaseOrder, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	if err := s.purchaseOrderRepo.Cancel(id, userID); err != nil {
+		return nil, err
+	}
+	return s.purchaseOrderRepo.GetByID(id)
+}
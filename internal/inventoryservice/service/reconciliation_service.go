@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+const (
+	// reconciliationPageSize is how many inventory records or items are
+	// compared at a time.
+	reconciliationPageSize = 100
+	// reconciliationGrace is how long after a change an inventory record is
+	// left out of reconciliation, as its sync to ItemService may still be
+	// on its way.
+	reconciliationGrace = time.Minute
+)
+
+// GetReconciliation returns the last reconciliation run to have ended.
+func (s *InventoryService) GetReconciliation(userID uint32) (*model.ReconciliationRun, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	return s.reconciliationRepo.Latest()
+}
+
+// RunReconciliation compares the inventory records with ItemService every
+// interval until ctx ends, repairing the discrepancies it finds from
+// source. Of several instances, only one compares in each interval.
+// ItemService is called as userID.
+func (s *InventoryService) RunReconciliation(ctx context.Context, interval time.Duration, source model.ReconciliationSource, userID uint32) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		run := &model.ReconciliationRun{
+			Slot:      now.Truncate(interval).Unix(),
+			Source:    source,
+			StartedAt: now,
+		}
+		started, err := s.reconciliationRepo.Start(run)
+		if err != nil {
+			log.Printf("Failed to start reconciliation: %v", err)
+			continue
+		}
+		if started {
+			s.reconcile(run, userID)
+		}
+	}
+}
+
+// reconcile compares and repairs the stores and records the outcome of run.
+func (s *InventoryService) reconcile(run *model.ReconciliationRun, userID uint32) {
+	// Orphaned stock is cleared first, so that it is not added to records
+	// recreated from ItemService
+	err := s.reconcileOrphans(run, userID)
+	if err == nil {
+		err = s.reconcileInventory(run, userID)
+	}
+	if err == nil {
+		err = s.reconcileItems(run, userID)
+	}
+
+	reason := ""
+	if err != nil {
+		reason = err.Error()
+		log.Printf("Reconciliation %d failed: %v", run.ID, err)
+	}
+	if ferr := s.reconciliationRepo.Finish(run, reason); ferr != nil {
+		log.Printf("Failed to record reconciliation %d: %v", run.ID, ferr)
+	}
+	log.Printf("Reconciliation %d: %d checked, %d discrepancies, %d repaired", run.ID, run.Checked, run.Found, run.Repaired)
+}
+
+func (s *InventoryService) reconcileOrphans(run *model.ReconciliationRun, userID uint32) error {
+	orphans, err := s.inventoryRepo.OrphanedStock()
+	if err != nil {
+		return fmt.Errorf("failed to find orphaned stock: %v", err)
+	}
+
+	itemIDs := make([]string, 0, len(orphans))
+	for itemID := range orphans {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sort.Strings(itemIDs)
+
+	for _, itemID := range itemIDs {
+		s.repairDiscrepancy(run, model.Discrepancy{
+			Kind:           model.DiscrepancyOrphanedStock,
+			ItemID:         itemID,
+			InventoryStock: orphans[itemID],
+		}, userID)
+	}
+	return nil
+}
+
+// reconcileInventory compares each inventory record with its item.
+func (s *InventoryService) reconcileInventory(run *model.ReconciliationRun, userID uint32) error {
+	changedSince := run.StartedAt.Add(-reconciliationGrace)
+	after := ""
+	for {
+		inventories, err := s.inventoryRepo.Page(after, reconciliationPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to read inventory: %v", err)
+		}
+		if len(inventories) == 0 {
+			return nil
+		}
+		after = inventories[len(inventories)-1].ItemID
+
+		itemIDs := make([]string, len(inventories))
+		for i, inventory := range inventories {
+			itemIDs[i] = inventory.ItemID
+		}
+		items, _, err := s.itemClient.BatchGetItems(itemIDs, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get items from item service: %v", err)
+		}
+		itemStock := make(map[string]int, len(items))
+		for _, item := range items {
+			itemStock[item.Id] = int(item.Stock)
+		}
+
+		for _, inventory := range inventories {
+			if inventory.UpdatedAt.After(changedSince) {
+				run.Skipped++
+				continue
+			}
+			run.Checked++
+
+			discrepancy := model.Discrepancy{ItemID: inventory.ItemID, InventoryStock: inventory.Stock}
+			stock, ok := itemStock[inventory.ItemID]
+			switch {
+			case !ok:
+				discrepancy.Kind = model.DiscrepancyMissingInItemService
+			case stock != inventory.Stock:
+				discrepancy.Kind = model.DiscrepancyStockMismatch
+				discrepancy.ItemStock = stock
+			default:
+				continue
+			}
+			s.repairDiscrepancy(run, discrepancy, userID)
+		}
+
+		if len(inventories) < reconciliationPageSize {
+			return nil
+		}
+	}
+}
+
+// reconcileItems looks for items in ItemService that have stock but no
+// inventory record.
+func (s *InventoryService) reconcileItems(run *model.ReconciliationRun, userID uint32) error {
+	pageToken := ""
+	for {
+		resp, err := s.itemClient.ListItems(pageToken, reconciliationPageSize, userID)
+		if err != nil {
+			return fmt.Errorf("failed to list items in item service: %v", err)
+		}
+		run.Checked += len(resp.Items)
+
+		var stocked []string
+		for _, item := range resp.Items {
+			if item.Stock != 0 {
+				stocked = append(stocked, item.Id)
+			}
+		}
+		if len(stocked) > 0 {
+			known, err := s.inventoryRepo.KnownItemIDs(stocked)
+			if err != nil {
+				return fmt.Errorf("failed to read inventory: %v", err)
+			}
+			for _, item := range resp.Items {
+				if item.Stock != 0 && !known[item.Id] {
+					s.repairDiscrepancy(run, model.Discrepancy{
+						Kind:      model.DiscrepancyMissingInInventory,
+						ItemID:    item.Id,
+						ItemStock: int(item.Stock),
+					}, userID)
+				}
+			}
+		}
+
+		if resp.NextPageToken == "" {
+			return nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// repairDiscrepancy repairs a discrepancy from the run's source of truth,
+// unless it reports only, and adds it to the run.
+func (s *InventoryService) repairDiscrepancy(run *model.ReconciliationRun, discrepancy model.Discrepancy, userID uint32) {
+	if run.Source != model.ReconcileReportOnly {
+		if err := s.repair(run, &discrepancy, userID); err != nil {
+			discrepancy.Error = err.Error()
+		} else {
+			discrepancy.Repaired = true
+		}
+	}
+	run.Add(discrepancy)
+}
+
+func (s *InventoryService) repair(run *model.ReconciliationRun, discrepancy *model.Discrepancy, userID uint32) error {
+	itemID := discrepancy.ItemID
+	if discrepancy.Kind == model.DiscrepancyOrphanedStock {
+		return s.inventoryRepo.DeleteOrphanedStock(itemID)
+	}
+
+	if run.Source == model.ReconcileFromInventory {
+		switch discrepancy.Kind {
+		case model.DiscrepancyMissingInItemService:
+			return errors.New("the item must be recreated in the item service or its inventory deleted")
+		case model.DiscrepancyMissingInInventory:
+			// Without a record the item has no stock
+			return s.syncItemStock(&model.Inventory{ItemID: itemID}, userID)
+		}
+		inventory, err := s.inventoryRepo.GetByItemID(itemID)
+		if err != nil {
+			return err
+		}
+		return s.syncItemStock(inventory, userID)
+	}
+
+	if discrepancy.Kind == model.DiscrepancyMissingInItemService {
+		return s.inventoryRepo.Delete(itemID, userID)
+	}
+	inventory, err := s.inventoryRepo.ReconcileStock(itemID, discrepancy.InventoryStock, discrepancy.ItemStock, &model.StockMovement{
+		Type:      model.MovementCorrection,
+		Reason:    "stock reconciled with the item service",
+		Reference: fmt.Sprintf("reconciliation-%d", run.ID),
+		UserID:    userID,
+	})
+	if err != nil {
+		return err
+	}
+	s.checkLowStock(inventory)
+	return nil
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+)
+
+// reportLease is how long a worker may take to generate a report before
+// another worker takes it over.
+const reportLease = 10 * time.Minute
+
+// ErrReportNotReady is returned when the result of a report that has not
+// completed is asked for.
+var ErrReportNotReady = errors.New("report is not ready")
+
+// CreateReport queues a report for the report worker and returns it
+// pending.
+func (s *InventoryService) CreateReport(req *model.CreateReportRequest, userID uint32) (*model.Report, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	if !req.Type.Valid() {
+		return nil, fmt.Errorf("invalid report type %q", req.Type)
+	}
+
+	report := &model.Report{Type: req.Type, CreatedBy: userID}
+	if req.Type == model.ReportTurnover {
+		report.Days = req.Days
+		if report.Days == 0 {
+			report.Days = model.DefaultTurnoverDays
+		}
+	}
+	if err := s.reportRepo.Create(report); err != nil {
+		return nil, err
+	}
+
+	// Wake the worker rather than wait for its next poll
+	select {
+	case s.reportQueued <- struct{}{}:
+	default:
+	}
+	return report, nil
+}
+
+func (s *InventoryService) GetReport(id uint, userID uint32) (*model.Report, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	return s.reportRepo.GetByID(id)
+}
+
+func (s *InventoryService) GetReports(userID uint32) ([]*model.Report, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	return s.reportRepo.GetAll()
+}
+
+// ReportCSV returns a completed report and its result as CSV records.
+func (s *InventoryService) ReportCSV(id uint, userID uint32) (*model.Report, [][]string, error) {
+	report, err := s.GetReport(id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if report.Status != model.ReportCompleted {
+		return nil, nil, fmt.Errorf("%w: it is %s", ErrReportNotReady, report.Status)
+	}
+
+	var result interface{ Records() [][]string }
+	switch report.Type {
+	case model.ReportValuation:
+		result = &model.ValuationReport{}
+	case model.ReportAging:
+		result = &model.AgingReport{}
+	case model.ReportTurnover:
+		result = &model.TurnoverReport{}
+	default:
+		return nil, nil, fmt.Errorf("invalid report type %q", report.Type)
+	}
+	if err := json.Unmarshal(report.Result, result); err != nil {
+		return nil, nil, fmt.Errorf("failed to read report result: %v", err)
+	}
+	return report, result.Records(), nil
+}
+
+// RunReportWorker generates queued reports, one at a time, as they are
+// created and every interval until ctx ends. Reports are generated here
+// rather than in the request, as they read every inventory record.
+func (s *InventoryService) RunReportWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.runQueuedReports()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.reportQueued:
+		}
+	}
+}
+
+func (s *InventoryService) runQueuedReports() {
+	for {
+		report, err := s.reportRepo.Claim(time.Now().Add(-reportLease))
+		if err != nil {
+			log.Printf("Failed to claim report: %v", err)
+			return
+		}
+		if report == nil {
+			return
+		}
+
+		result, err := s.generateReport(report)
+		if err == nil {
+			var data []byte
+			if data, err = json.Marshal(result); err == nil {
+				err = s.reportRepo.Complete(report.ID, data)
+			}
+		}
+		if err != nil {
+			log.Printf("Report %d failed: %v", report.ID, err)
+			if err := s.reportRepo.Fail(report.ID, err.Error()); err != nil {
+				log.Printf("Failed to record failure of report %d: %v", report.ID, err)
+			}
+		}
+	}
+}
+
+func (s *InventoryService) generateReport(report *model.Report) (interface{}, error) {
+	switch report.Type {
+	case model.ReportValuation:
+		return s.valuationReport(report.CreatedBy)
+	case model.ReportAging:
+		return s.agingReport()
+	case model.ReportTurnover:
+		return s.turnoverReport(report.Days)
+	}
+	return nil, fmt.Errorf("invalid report type %q", report.Type)
+}
+
+// valuationReport prices the stock on hand with the items' prices in
+// ItemService, fetched on behalf of the user who asked for the report.
+func (s *InventoryService) valuationReport(userID uint32) (*model.ValuationReport, error) {
+	inventories, err := s.inventoryRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	itemIDs := make([]string, len(inventories))
+	for i, inventory := range inventories {
+		itemIDs[i] = inventory.ItemID
+	}
+	items, missingIDs, err := s.itemClient.BatchGetItems(itemIDs, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items from item service: %v", err)
+	}
+
+	report := &model.ValuationReport{GeneratedAt: time.Now(), MissingIDs: missingIDs}
+	categories := make(map[[2]string]*model.ValuationLine)
+	totals := make(map[string]*model.ValuationLine)
+	stock := make(map[string]int, len(inventories))
+	for _, inventory := range inventories {
+		stock[inventory.ItemID] = inventory.Stock
+	}
+
+	for _, item := range items {
+		price := item.GetPriceMoney()
+		if price.GetCurrency() == "" {
+			report.UnpricedIDs = append(report.UnpricedIDs, item.GetId())
+			continue
+		}
+
+		category := item.GetCategory()
+		if category == "" {
+			category = "uncategorized"
+		}
+		key := [2]string{category, price.GetCurrency()}
+		if categories[key] == nil {
+			categories[key] = &model.ValuationLine{Category: category, Currency: price.GetCurrency()}
+			report.Categories = append(report.Categories, categories[key])
+		}
+		if totals[price.GetCurrency()] == nil {
+			totals[price.GetCurrency()] = &model.ValuationLine{Currency: price.GetCurrency()}
+			report.Totals = append(report.Totals, totals[price.GetCurrency()])
+		}
+
+		units := stock[item.GetId()]
+		value := int64(units) * price.GetAmountMinor()
+		for _, line := range []*model.ValuationLine{categories[key], totals[price.GetCurrency()]} {
+			line.Items++
+			line.Units += units
+			line.ValueMinor += value
+		}
+	}
+
+	sort.Slice(report.Categories, func(i, j int) bool {
+		a, b := report.Categories[i], report.Categories[j]
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		return a.Currency < b.Currency
+	})
+	sort.Slice(report.Totals, func(i, j int) bool {
+		return report.Totals[i].Currency < report.Totals[j].Currency
+	})
+	sort.Strings(report.UnpricedIDs)
+	sort.Strings(report.MissingIDs)
+	return report, nil
+}
+
+// agingReport ages the stock of every item in stock, attributing it to the
+// item's most recent inflows.
+func (s *InventoryService) agingReport() (*model.AgingReport, error) {
+	inventories, err := s.inventoryRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &model.AgingReport{GeneratedAt: now, Items: []*model.AgingLine{}}
+	for _, inventory := range inventories {
+		if inventory.Stock <= 0 {
+			continue
+		}
+
+		inflows, err := s.inventoryRepo.GetInflows(inventory.ItemID)
+		if err != nil {
+			return nil, err
+		}
+
+		line := &model.AgingLine{ItemID: inventory.ItemID, Stock: inventory.Stock}
+		remaining := inventory.Stock
+		for _, inflow := range inflows {
+			if remaining == 0 {
+				break
+			}
+			quantity := min(inflow.Quantity, remaining)
+			line.Add(int(now.Sub(inflow.CreatedAt).Hours()/24), quantity)
+			remaining -= quantity
+		}
+		line.Unknown = remaining
+		report.Items = append(report.Items, line)
+	}
+
+	sort.Slice(report.Items, func(i, j int) bool {
+		return report.Items[i].ItemID < report.Items[j].ItemID
+	})
+	return report, nil
+}
+
+// turnoverReport computes every item's turnover over the last days days.
+// An item's stock at the start of the period is its current stock less
+// the movements since.
+func (s *InventoryService) turnoverReport(days int) (*model.TurnoverReport, error) {
+	inventories, err := s.inventoryRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -days)
+	totals, err := s.inventoryRepo.Totals(from)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.TurnoverReport{GeneratedAt: now, From: from, Days: days, Items: []*model.TurnoverLine{}}
+	for _, inventory := range inventories {
+		total := totals[inventory.ItemID]
+		line := &model.TurnoverLine{
+			ItemID:       inventory.ItemID,
+			OpeningStock: inventory.Stock - total.Net,
+			ClosingStock: inventory.Stock,
+			Sold:         total.Sold,
+		}
+		average := float64(line.OpeningStock+line.ClosingStock) / 2
+		line.AverageStock = round2(average)
+		if average > 0 && line.Sold > 0 {
+			turnover := float64(line.Sold) / average
+			line.Turnover = round2(turnover)
+			line.DaysOfInventory = math.Round(float64(days)/turnover*10) / 10
+		}
+		report.Items = append(report.Items, line)
+	}
+
+	sort.Slice(report.Items, func(i, j int) bool {
+		return report.Items[i].ItemID < report.Items[j].ItemID
+	})
+	return report, nil
+}
+
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"rancher-manager/internal/inventoryservice/model"
+	"rancher-manager/internal/inventoryservice/repository"
+)
+
+// CreateReservation holds stock of an item for a checkout. A request with
+// the idempotency key of an earlier one returns that reservation, and
+// created is false.
+func (s *InventoryService) CreateReservation(req *model.CreateReservationRequest, userID uint32) (reservation *model.Reservation, created bool, err error) {
+	// Validate user exists via gRPC
+	_, err = s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, false, model.ErrUnauthorized
+	}
+
+	ttl := model.DefaultReservationTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl > model.MaxReservationTTL {
+		return nil, false, model.InvalidRequest("ttl_seconds must be at most %d", int(model.MaxReservationTTL.Seconds()))
+	}
+
+	if err := s.checkStockWritable(req.ItemID, userID); err != nil {
+		return nil, false, err
+	}
+
+	reservation, created, err = s.reservationRepo.Reserve(&model.Reservation{
+		ItemID:         req.ItemID,
+		Quantity:       req.Quantity,
+		IdempotencyKey: req.IdempotencyKey,
+		Reference:      req.Reference,
+		UserID:         userID,
+		ExpiresAt:      time.Now().Add(ttl),
+	})
+	if errors.Is(err, repository.ErrInsufficientStock) {
+		return nil, false, fmt.Errorf("%w: cannot reserve %d", repository.ErrInsufficientStock, req.Quantity)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return reservation, created, nil
+}
+
+func (s *InventoryService) GetReservation(id uint, userID uint32) (*model.Reservation, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	reservation, err := s.reservationRepo.GetByID(id)
+	if err != nil {
+		return nil, reservationError(err)
+	}
+	return reservation, nil
+}
+
+// ConfirmReservation turns a reservation into a sale of its stock.
+func (s *InventoryService) ConfirmReservation(id uint, userID uint32) (*model.Reservation, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	reservation, change, err := s.reservationRepo.Confirm(id, userID)
+	if err != nil {
+		return nil, reservationError(err)
+	}
+
+	// change is nil when the reservation was confirmed before
+	if change != nil {
+		s.completeStockChanges([]*repository.StockChange{change})
+	}
+	return reservation, nil
+}
+
+// ReleaseReservation gives a reservation's stock back.
+func (s *InventoryService) ReleaseReservation(id uint, userID uint32) (*model.Reservation, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	reservation, err := s.reservationRepo.Release(id)
+	if err != nil {
+		return nil, reservationError(err)
+	}
+	return reservation, nil
+}
+
+// RunReservationSweeper releases expired reservations every interval until
+// ctx ends.
+func (s *InventoryService) RunReservationSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.reservationRepo.ExpireDue(time.Now())
+			if err != nil {
+				log.Printf("Failed to expire reservations: %v", err)
+			} else if expired > 0 {
+				log.Printf("Expired %d reservations", expired)
+			}
+		}
+	}
+}
+
+func reservationError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repository.ErrReservationNotFound
+	}
+	return err
+}
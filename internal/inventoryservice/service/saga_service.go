@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"rancher-manager/internal/inventoryservice/model"
+	"rancher-manager/internal/inventoryservice/repository"
+)
+
+const (
+	// sagaLease is how long a saga may go without progress before the
+	// recovery worker takes it over from the request that started it.
+	sagaLease = time.Minute
+	// sagaMaxAttempts is how often the recovery worker resumes a saga
+	// before giving up on it.
+	sagaMaxAttempts = 10
+)
+
+// errItemKept is returned when ItemService has refused to delete an item,
+// as opposed to when it cannot be told whether it did.
+var errItemKept = errors.New("item service kept the item")
+
+// GetSagas lists sagas, newest first, optionally only those in one status.
+func (s *InventoryService) GetSagas(status model.SagaStatus, userID uint32) ([]*model.Saga, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(userID)
+	if err != nil {
+		return nil, model.ErrUnauthorized
+	}
+
+	if status != "" && !status.Valid() {
+		return nil, fmt.Errorf("invalid saga status %q", status)
+	}
+	return s.sagaRepo.GetAll(status)
+}
+
+// completeStockUpdate syncs the stock changed by an update_stock or
+// sync_stock saga to ItemService. If that fails, update_stock sagas are
+// compensated and sync_stock sagas left to the recovery worker.
+func (s *InventoryService) completeStockUpdate(saga *model.Saga, inventory *model.Inventory) error {
+	if err := s.syncItemStock(inventory, saga.UserID); err != nil {
+		if saga.Type == model.SagaSyncStock {
+			if rerr := s.sagaRepo.RecordError(saga, err.Error()); rerr != nil {
+				log.Printf("Failed to record error of saga %d: %v", saga.ID, rerr)
+			}
+			return model.Errorf(model.ErrItemSync, "failed to update stock in item service: %v; the update will be retried", err)
+		}
+		if cerr := s.compensateStockUpdate(saga, err); cerr != nil {
+			log.Printf("Failed to compensate saga %d: %v", saga.ID, cerr)
+			return model.Errorf(model.ErrItemSync, "failed to update stock in item service: %v; reverting the change", err)
+		}
+		return model.Errorf(model.ErrItemSync, "failed to update stock in item service: %v; the change was reverted", err)
+	}
+
+	// ItemService has the stock; should this fail, the recovery worker
+	// syncs it again
+	if err := s.sagaRepo.Advance(saga, model.SagaCompleted, model.SagaStepItemSynced); err != nil {
+		log.Printf("Failed to record completion of saga %d: %v", saga.ID, err)
+	}
+	s.checkLowStock(inventory)
+	return nil
+}
+
+// compensateStockUpdate reverts the stock change of an update_stock saga
+// that failed because of cause, unless it has been reverted already, and
+// syncs the stock as reverted to ItemService, in case the failed call did
+// reach it.
+func (s *InventoryService) compensateStockUpdate(saga *model.Saga, cause error) error {
+	if cause != nil {
+		saga.LastError = cause.Error()
+	}
+
+	if saga.Step == model.SagaStepStockUpdated {
+		_, err := s.sagaRepo.Revert(saga)
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			// The stock has been sold or reserved since; taking it back is
+			// for someone to decide
+			return s.sagaRepo.Fail(saga, "cannot revert: the stock is no longer available: "+saga.LastError)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	inventory, err := s.inventoryRepo.GetByItemID(saga.ItemID)
+	if err != nil {
+		return s.sagaRepo.Fail(saga, "cannot sync reverted stock: "+err.Error())
+	}
+	if err := s.syncItemStock(inventory, saga.UserID); err != nil {
+		if rerr := s.sagaRepo.RecordError(saga, err.Error()); rerr != nil {
+			log.Printf("Failed to record error of saga %d: %v", saga.ID, rerr)
+		}
+		return err
+	}
+	if err := s.sagaRepo.Advance(saga, model.SagaCompensated, model.SagaStepItemSynced); err != nil {
+		return err
+	}
+
+	s.checkLowStock(inventory)
+	return nil
+}
+
+// completeItemDelete deletes the item of a delete_item saga in ItemService
+// and then its lots and stock at locations, compensating the saga if
+// ItemService keeps the item. If ItemService cannot be reached, the saga is
+// left to the recovery worker.
+func (s *InventoryService) completeItemDelete(saga *model.Saga) error {
+	if saga.Step == model.SagaStepInventoryDeleted {
+		err := s.deleteFromItemService(saga.ItemID, saga.UserID)
+		if errors.Is(err, errItemKept) {
+			saga.LastError = err.Error()
+			if cerr := s.sagaRepo.Restore(saga); cerr != nil {
+				log.Printf("Failed to compensate saga %d: %v", saga.ID, cerr)
+			}
+			return fmt.Errorf("failed to delete item from item service: %v", err)
+		}
+		if err != nil {
+			if rerr := s.sagaRepo.RecordError(saga, err.Error()); rerr != nil {
+				log.Printf("Failed to record error of saga %d: %v", saga.ID, rerr)
+			}
+			return fmt.Errorf("failed to delete item from item service: %v; the deletion will be retried", err)
+		}
+
+		// Should this fail, the recovery worker finds the item gone and
+		// carries on
+		if err := s.sagaRepo.Advance(saga, model.SagaStarted, model.SagaStepItemDeleted); err != nil {
+			log.Printf("Failed to record progress of saga %d: %v", saga.ID, err)
+			return nil
+		}
+	}
+
+	if err := s.sagaRepo.Purge(saga); err != nil {
+		log.Printf("Failed to purge stock of deleted item %s: %v", saga.ItemID, err)
+	}
+	return nil
+}
+
+// deleteFromItemService deletes an item in ItemService, which counts as
+// done if the item is already gone. It fails with errItemKept if
+// ItemService still has the item, and with other errors if that cannot be
+// told.
+func (s *InventoryService) deleteFromItemService(itemID string, userID uint32) error {
+	resp, err := s.itemClient.DeleteItem(itemID, userID)
+	if err == nil {
+		if resp.Success || strings.Contains(resp.Message, "not found") {
+			return nil
+		}
+		return fmt.Errorf("%w: %s", errItemKept, resp.Message)
+	}
+
+	// The call may have failed after the item was deleted
+	itemResp, getErr := s.itemClient.GetItem(itemID, userID)
+	switch {
+	case getErr != nil:
+		return err
+	case itemResp.Success:
+		return fmt.Errorf("%w: %v", errItemKept, err)
+	case strings.Contains(itemResp.Message, "not found"):
+		return nil
+	}
+	return err
+}
+
+// completeStockChanges completes the sync_stock sagas of changes. The
+// changes are committed; failing to sync them is only logged, as they are
+// synced again by the recovery worker.
+func (s *InventoryService) completeStockChanges(changes []*repository.StockChange) {
+	for _, change := range changes {
+		if err := s.completeStockUpdate(change.Saga, change.Inventory); err != nil {
+			log.Printf("Failed to sync stock of %s: %v", change.Inventory.ItemID, err)
+		}
+	}
+}
+
+// syncItemStock sets an item's stock in ItemService to its inventory stock.
+func (s *InventoryService) syncItemStock(inventory *model.Inventory, userID uint32) error {
+	resp, err := s.itemClient.UpdateStock(inventory.ItemID, int32(inventory.Stock), userID)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.Message)
+	}
+	return nil
+}
+
+// RecoverSagas resumes the sagas that have made no progress for sagaLease,
+// such as those interrupted by a crash, completing or compensating them.
+func (s *InventoryService) RecoverSagas() error {
+	for {
+		saga, err := s.sagaRepo.Claim(time.Now().Add(-sagaLease))
+		if err != nil {
+			return fmt.Errorf("failed to claim saga: %v", err)
+		}
+		if saga == nil {
+			return nil
+		}
+		if err := s.resumeSaga(saga); err != nil {
+			log.Printf("Saga %d is still incomplete: %v", saga.ID, err)
+		}
+	}
+}
+
+func (s *InventoryService) resumeSaga(saga *model.Saga) error {
+	if saga.Attempts > sagaMaxAttempts {
+		log.Printf("Giving up on saga %d after %d attempts", saga.ID, sagaMaxAttempts)
+		return s.sagaRepo.Fail(saga, fmt.Sprintf("gave up after %d attempts: %s", sagaMaxAttempts, saga.LastError))
+	}
+
+	switch saga.Type {
+	case model.SagaUpdateStock, model.SagaSyncStock:
+		if saga.Status == model.SagaCompensating {
+			return s.compensateStockUpdate(saga, nil)
+		}
+		inventory, err := s.inventoryRepo.GetByItemID(saga.ItemID)
+		if err != nil {
+			return s.sagaRepo.Fail(saga, "inventory record is gone: "+err.Error())
+		}
+		return s.completeStockUpdate(saga, inventory)
+	case model.SagaDeleteItem:
+		return s.completeItemDelete(saga)
+	}
+	return s.sagaRepo.Fail(saga, fmt.Sprintf("unknown saga type %q", saga.Type))
+}
+
+// RunSagaRecovery runs RecoverSagas every interval until ctx ends.
+func (s *InventoryService) RunSagaRecovery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.RecoverSagas(); err != nil {
+				log.Printf("Saga recovery failed: %v", err)
+			}
+		}
+	}
+}
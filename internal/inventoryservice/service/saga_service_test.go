@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+
+	pb "rancher-manager/api/proto/itemservice"
+	itemgrpc "rancher-manager/internal/inventoryservice/grpc"
+	"rancher-manager/internal/inventoryservice/model"
+	"rancher-manager/internal/inventoryservice/repository"
+)
+
+// fakeItemService keeps the stock synced to it and rejects updates while
+// rejecting is set.
+type fakeItemService struct {
+	pb.UnimplementedItemServiceServer
+	mu        sync.Mutex
+	rejecting bool
+	stock     map[string]int32
+}
+
+func (f *fakeItemService) UpdateStock(ctx context.Context, req *pb.UpdateStockRequest) (*pb.UpdateStockResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.rejecting {
+		return &pb.UpdateStockResponse{Success: false, Message: "item is locked"}, nil
+	}
+	f.stock[req.ItemId] = req.NewStock
+	return &pb.UpdateStockResponse{Success: true}, nil
+}
+
+func (f *fakeItemService) setRejecting(rejecting bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rejecting = rejecting
+}
+
+func newSagaTestService(t *testing.T) (*InventoryService, *fakeItemService, *gorm.DB) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := &fakeItemService{stock: make(map[string]int32)}
+	server := grpc.NewServer()
+	pb.RegisterItemServiceServer(server, items)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	itemClient, err := itemgrpc.NewItemClient(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := newTestDB(t)
+	inventoryRepo := repository.NewInventoryRepository(db)
+	return &InventoryService{
+		inventoryRepo:   inventoryRepo,
+		reservationRepo: repository.NewReservationRepository(db),
+		sagaRepo:        repository.NewSagaRepository(db, inventoryRepo),
+		itemClient:      itemClient,
+	}, items, db
+}
+
+func assertSaga(t *testing.T, s *InventoryService, id uint, status model.SagaStatus, step model.SagaStep) *model.Saga {
+	t.Helper()
+
+	sagas, err := s.sagaRepo.GetAll("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, saga := range sagas {
+		if saga.ID == id {
+			if saga.Status != status || saga.Step != step {
+				t.Errorf("saga %d is %s at %s, want %s at %s", id, saga.Status, saga.Step, status, step)
+			}
+			return saga
+		}
+	}
+	t.Fatalf("saga %d not found", id)
+	return nil
+}
+
+func TestAdjustStockRevertedWhenSyncFails(t *testing.T) {
+	s, items, _ := newSagaTestService(t)
+	if _, err := s.inventoryRepo.AdjustStock("lamp", 10, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+
+	items.setRejecting(true)
+	inventory, saga, err := s.sagaRepo.AdjustStock("lamp", -4, &model.StockMovement{Type: model.MovementSale, UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.completeStockUpdate(saga, inventory); err == nil {
+		t.Fatal("adjustment rejected by the item service succeeded")
+	}
+
+	// The adjustment is taken back, but ItemService still has to be told
+	reverted, err := s.inventoryRepo.GetByItemID("lamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverted.Stock != 10 {
+		t.Errorf("stock = %d after the revert, want 10", reverted.Stock)
+	}
+	if saga := assertSaga(t, s, saga.ID, model.SagaCompensating, model.SagaStepStockReverted); saga.LastError != "item is locked" {
+		t.Errorf("last error = %q, want the item service's message", saga.LastError)
+	}
+
+	items.setRejecting(false)
+	if err := s.resumeSaga(saga); err != nil {
+		t.Fatal(err)
+	}
+	assertSaga(t, s, saga.ID, model.SagaCompensated, model.SagaStepItemSynced)
+	if items.stock["lamp"] != 10 {
+		t.Errorf("item service stock = %d, want 10", items.stock["lamp"])
+	}
+}
+
+func TestConfirmedSaleSyncedByRecovery(t *testing.T) {
+	s, items, db := newSagaTestService(t)
+	if _, err := s.inventoryRepo.AdjustStock("lamp", 10, &model.StockMovement{Type: model.MovementReceipt}); err != nil {
+		t.Fatal(err)
+	}
+	reservation, _, err := s.reservationRepo.Reserve(&model.Reservation{
+		ItemID:    "lamp",
+		Quantity:  3,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items.setRejecting(true)
+	_, change, err := s.reservationRepo.Confirm(reservation.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.completeStockChanges([]*repository.StockChange{change})
+
+	// The sale stands and its saga waits for the recovery worker
+	sold, err := s.inventoryRepo.GetByItemID("lamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sold.Stock != 7 {
+		t.Errorf("stock = %d after the sync failed, want 7", sold.Stock)
+	}
+	assertSaga(t, s, change.Saga.ID, model.SagaStarted, model.SagaStepStockUpdated)
+
+	// Once the saga has made no progress for its lease, the worker takes
+	// it over
+	items.setRejecting(false)
+	err = db.Model(change.Saga).UpdateColumn("updated_at", time.Now().Add(-2*sagaLease)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecoverSagas(); err != nil {
+		t.Fatal(err)
+	}
+	if saga := assertSaga(t, s, change.Saga.ID, model.SagaCompleted, model.SagaStepItemSynced); saga.Attempts != 1 {
+		t.Errorf("attempts = %d, want 1", saga.Attempts)
+	}
+	if items.stock["lamp"] != 7 {
+		t.Errorf("item service stock = %d, want 7", items.stock["lamp"])
+	}
+}
@@ -0,0 +1,42 @@
+package cache
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Lookup results recorded by Metrics.
+const (
+	ResultHit         = "hit"
+	ResultNegativeHit = "negative_hit"
+	ResultMiss        = "miss"
+	ResultError       = "error"
+)
+
+// Metrics counts cache lookups by result, and invalidations.
+type Metrics struct {
+	requests      *prometheus.CounterVec
+	invalidations prometheus.Counter
+}
+
+// NewMetrics registers the counters of the named cache, e.g. "item", which
+// become item_cache_requests_total and item_cache_invalidations_total.
+func NewMetrics(reg prometheus.Registerer, name string) *Metrics {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: name + "_cache_requests_total",
+			Help: "Cache lookups by result (hit, negative_hit, miss, error).",
+		}, []string{"result"}),
+		invalidations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: name + "_cache_invalidations_total",
+			Help: "Cache entries invalidated after writes.",
+		}),
+	}
+	reg.MustRegister(m.requests, m.invalidations)
+	return m
+}
+
+func (m *Metrics) Request(result string) {
+	m.requests.WithLabelValues(result).Inc()
+}
+
+func (m *Metrics) Invalidated(n int) {
+	m.invalidations.Add(float64(n))
+}
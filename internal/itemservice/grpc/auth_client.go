@@ -3,6 +3,7 @@ package grpc
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -10,22 +11,32 @@ import (
 	pb "rancher-manager/api/proto/authservice"
 )
 
+// DefaultAuthTimeout bounds calls to the auth service when the caller's
+// context has no earlier deadline.
+const DefaultAuthTimeout = 3 * time.Second
+
 type AuthClient struct {
-	client pb.AuthServiceClient
+	client  pb.AuthServiceClient
+	timeout time.Duration
 }
 
-func NewAuthClient(authServiceAddr string) (*AuthClient, error) {
+func NewAuthClient(authServiceAddr string, timeout time.Duration) (*AuthClient, error) {
 	conn, err := grpc.Dial(authServiceAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to auth service: %v", err)
 	}
 
 	client := pb.NewAuthServiceClient(conn)
-	return &AuthClient{client: client}, nil
+	if timeout <= 0 {
+		timeout = DefaultAuthTimeout
+	}
+	return &AuthClient{client: client, timeout: timeout}, nil
 }
 
-func (c *AuthClient) ValidateToken(token string) (interface{}, error) {
-	ctx := context.Background()
+func (c *AuthClient) ValidateToken(ctx context.Context, token string) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
 	req := &pb.ValidateTokenRequest{
 		Token: token,
 	}
@@ -46,8 +57,10 @@ func (c *AuthClient) ValidateToken(token string) (interface{}, error) {
 	return result, nil
 }
 
-func (c *AuthClient) GetUser(userID uint32) (interface{}, error) {
-	ctx := context.Background()
+func (c *AuthClient) GetUser(ctx context.Context, userID uint32) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
 	req := &pb.GetUserRequest{
 		UserId: userID,
 	}
@@ -0,0 +1,79 @@
+package grpc
+
+import (
+	"errors"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"rancher-manager/internal/itemservice/model"
+	"rancher-manager/internal/itemservice/service"
+)
+
+// infrastructureStatus converts timeouts, cancellations and outages into a
+// gRPC status error, or returns nil for errors caused by the request. The
+// existing RPCs keep reporting request errors in the response payload so
+// that current clients are unaffected.
+func infrastructureStatus(err error) error {
+	switch {
+	case errors.Is(err, service.ErrTimeout):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, service.ErrCanceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, service.ErrUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return nil
+}
+
+// statusError converts a service error into a gRPC status error for the RPCs
+// that report failures as statuses rather than in the response payload.
+func statusError(err error) error {
+	if st := infrastructureStatus(err); st != nil {
+		return st
+	}
+
+	var validationErr *model.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationStatus(validationErr)
+	}
+
+	message := err.Error()
+	switch {
+	case errors.Is(err, service.ErrResumeTokenExpired):
+		return status.Error(codes.OutOfRange, message)
+	case errors.Is(err, service.ErrUnauthorized):
+		return status.Error(codes.Unauthenticated, message)
+	case strings.Contains(message, "forbidden"):
+		return status.Error(codes.PermissionDenied, message)
+	case strings.Contains(message, "not found"):
+		return status.Error(codes.NotFound, message)
+	case strings.Contains(message, "already exists"):
+		return status.Error(codes.AlreadyExists, message)
+	case strings.Contains(message, "archived"),
+		strings.Contains(message, "invalid transition"),
+		strings.Contains(message, "has subcategories"):
+		return status.Error(codes.FailedPrecondition, message)
+	}
+	return status.Error(codes.InvalidArgument, message)
+}
+
+// validationStatus reports each invalid field as a BadRequest field
+// violation, with the description prefixed by the validation code.
+func validationStatus(err *model.ValidationError) error {
+	badRequest := &errdetails.BadRequest{}
+	for _, fieldErr := range err.Errors {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fieldErr.Field,
+			Description: fieldErr.Code + ": " + fieldErr.Message,
+		})
+	}
+
+	st, detailErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(badRequest)
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return st.Err()
+}
@@ -0,0 +1,422 @@
+package grpc
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "rancher-manager/api/proto/itemservice"
+	"rancher-manager/internal/itemservice/model"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+	maxBatchGetIDs  = 100
+)
+
+// The RPCs in this file report failures as gRPC status errors; see
+// statusError for the mapping.
+
+func (s *ItemGRPCServer) CreateItem(ctx context.Context, req *pb.CreateItemRequest) (*pb.Item, error) {
+	userID, err := contextUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validation happens in the service, as for REST and Kafka writes
+	createReq := &model.CreateItemRequest{
+		Name:        req.Name,
+		SKU:         req.Sku,
+		Description: req.Description,
+		Category:    req.Category,
+		Attributes:  fromProtoAttributes(req.Attributes),
+		Stock:       int(req.Stock),
+	}
+	if req.Price != nil {
+		createReq.Price = fromProtoMoney(req.Price)
+	}
+	if createReq.PriceList, err = fromProtoPriceList(req.PriceList); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	item, err := s.itemService.CreateItem(ctx, createReq, userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoItem(item), nil
+}
+
+func (s *ItemGRPCServer) UpdateItem(ctx context.Context, req *pb.UpdateItemRequest) (*pb.Item, error) {
+	userID, err := contextUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Item == nil {
+		return nil, status.Error(codes.InvalidArgument, "item is required")
+	}
+
+	patch, err := itemPatch(req.Item, req.GetUpdateMask().GetPaths())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	item, err := s.itemService.PatchItem(ctx, req.ItemId, patch, userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoItem(item), nil
+}
+
+// itemPatch builds a patch from the masked fields of item. Without a mask,
+// the fields that are set are applied, matching PUT /items/:id.
+func itemPatch(item *pb.Item, paths []string) (*model.ItemPatch, error) {
+	patch := &model.ItemPatch{}
+	if len(paths) == 0 {
+		if item.Name != "" {
+			paths = append(paths, "name")
+		}
+		if item.Sku != "" {
+			paths = append(paths, "sku")
+		}
+		if item.Description != "" {
+			paths = append(paths, "description")
+		}
+		if item.PriceMoney != nil {
+			paths = append(paths, "price_money")
+		}
+		if item.Category != "" {
+			paths = append(paths, "category")
+		}
+		if item.Attributes != nil {
+			paths = append(paths, "attributes")
+		}
+		if item.Stock != 0 {
+			paths = append(paths, "stock")
+		}
+		if len(item.PriceList) > 0 {
+			paths = append(paths, "price_list")
+		}
+	}
+
+	for _, path := range paths {
+		switch path {
+		case "name":
+			patch.Name = &item.Name
+		case "sku":
+			patch.SKU = &item.Sku
+		case "description":
+			patch.Description = &item.Description
+		case "price_money":
+			if item.PriceMoney == nil {
+				return nil, fmt.Errorf("price_money is required when listed in update_mask")
+			}
+			price := fromProtoMoney(item.PriceMoney)
+			patch.Price = &price
+		case "category":
+			patch.Category = &item.Category
+		case "attributes":
+			attributes := fromProtoAttributes(item.Attributes)
+			patch.Attributes = &attributes
+		case "stock":
+			stock := int(item.Stock)
+			patch.Stock = &stock
+		case "price_list":
+			priceList, err := fromProtoPriceList(item.PriceList)
+			if err != nil {
+				return nil, err
+			}
+			if priceList == nil {
+				priceList = []model.PriceListEntry{}
+			}
+			patch.PriceList = &priceList
+		default:
+			return nil, fmt.Errorf("unsupported update_mask path %q", path)
+		}
+	}
+	return patch, nil
+}
+
+func (s *ItemGRPCServer) ListItems(ctx context.Context, req *pb.ListItemsRequest) (*pb.ListItemsResponse, error) {
+	userID, err := contextUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := int(req.PageSize)
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	afterID, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &model.ItemFilter{
+		Category:           req.Category,
+		IncludeDescendants: req.IncludeDescendants,
+		ParentID:           req.ParentId,
+		Attributes:         req.Attributes,
+		Statuses:           statusFilter(req.Statuses),
+		AfterID:            afterID,
+		// One extra item tells whether another page follows
+		Limit: pageSize + 1,
+	}
+
+	items, err := s.itemService.ListItems(ctx, filter, userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	response := &pb.ListItemsResponse{}
+	if len(items) > pageSize {
+		items = items[:pageSize]
+		response.NextPageToken = encodePageToken(items[len(items)-1].ID.Hex())
+	}
+	for _, item := range items {
+		response.Items = append(response.Items, toProtoItem(item))
+	}
+	return response, nil
+}
+
+// statusFilter mirrors the status query parameter of GET /items: no
+// statuses means active items only and "all" means every status.
+func statusFilter(values []string) []model.ItemStatus {
+	if len(values) == 0 {
+		return nil
+	}
+
+	statuses := []model.ItemStatus{}
+	for _, value := range values {
+		if value == "all" {
+			return []model.ItemStatus{}
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			statuses = append(statuses, model.ItemStatus(value))
+		}
+	}
+	return statuses
+}
+
+// Page tokens are opaque to clients; they carry the ID of the last item
+// returned.
+func encodePageToken(lastID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastID))
+}
+
+func decodePageToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	lastID, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(lastID) == 0 {
+		return "", status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+	return string(lastID), nil
+}
+
+func (s *ItemGRPCServer) BatchGetItems(ctx context.Context, req *pb.BatchGetItemsRequest) (*pb.BatchGetItemsResponse, error) {
+	userID, err := contextUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.ItemIds) > maxBatchGetIDs {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d item_ids may be requested at once", maxBatchGetIDs)
+	}
+
+	items, missing, err := s.itemService.GetItems(ctx, req.ItemIds, userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	response := &pb.BatchGetItemsResponse{MissingIds: missing}
+	for _, item := range items {
+		response.Items = append(response.Items, toProtoItem(item))
+	}
+	return response, nil
+}
+
+func (s *ItemGRPCServer) WatchItems(req *pb.WatchItemsRequest, stream pb.ItemService_WatchItemsServer) error {
+	ctx := stream.Context()
+	userID, err := contextUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	events, cancel, err := s.itemService.WatchItems(ctx, &model.WatchFilter{
+		ItemIDs:            req.ItemIds,
+		Category:           req.Category,
+		IncludeDescendants: req.IncludeDescendants,
+		ResumeToken:        req.ResumeToken,
+	}, userID)
+	if err != nil {
+		return statusError(err)
+	}
+	defer cancel()
+
+	for event := range events {
+		if err := stream.Send(toProtoEvent(event)); err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Unavailable, "item feed interrupted; reconnect with the last resume_token")
+}
+
+func toProtoEvent(event model.ItemEvent) *pb.ItemEvent {
+	protoEvent := &pb.ItemEvent{
+		ItemId:      event.ItemID,
+		OccurredAt:  event.OccurredAt.Format(time.RFC3339),
+		ResumeToken: event.ResumeToken,
+	}
+	switch event.Type {
+	case model.ItemEventCreated:
+		protoEvent.Type = pb.ItemEvent_CREATED
+	case model.ItemEventUpdated:
+		protoEvent.Type = pb.ItemEvent_UPDATED
+	case model.ItemEventDeleted:
+		protoEvent.Type = pb.ItemEvent_DELETED
+	}
+	if event.Item != nil {
+		protoEvent.Item = toProtoItem(event.Item)
+	}
+	return protoEvent
+}
+
+// contextUserID returns the user set by the auth interceptors.
+func contextUserID(ctx context.Context) (uint32, error) {
+	userID, ok := ctx.Value("user_id").(uint32)
+	if !ok {
+		return 0, status.Error(codes.Unauthenticated, "user not authenticated")
+	}
+	return userID, nil
+}
+
+func fromProtoMoney(m *pb.Money) model.Money {
+	currency := strings.ToUpper(m.Currency)
+	if currency == "" {
+		currency = model.DefaultCurrency
+	}
+	return model.Money{Amount: m.AmountMinor, Currency: currency}
+}
+
+// fromProtoPriceList converts price list entries, parsing their validity
+// bounds as RFC 3339.
+func fromProtoPriceList(entries []*pb.PriceListEntry) ([]model.PriceListEntry, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	priceList := make([]model.PriceListEntry, 0, len(entries))
+	for i, entry := range entries {
+		if entry.Price == nil {
+			return nil, fmt.Errorf("price_list[%d]: price is required", i)
+		}
+		validFrom, err := parseProtoTime(entry.ValidFrom)
+		if err != nil {
+			return nil, fmt.Errorf("price_list[%d]: invalid valid_from: %v", i, err)
+		}
+		validTo, err := parseProtoTime(entry.ValidTo)
+		if err != nil {
+			return nil, fmt.Errorf("price_list[%d]: invalid valid_to: %v", i, err)
+		}
+		priceList = append(priceList, model.PriceListEntry{
+			Market:    entry.Market,
+			Price:     fromProtoMoney(entry.Price),
+			ValidFrom: validFrom,
+			ValidTo:   validTo,
+		})
+	}
+	return priceList, nil
+}
+
+func toProtoPriceList(priceList []model.PriceListEntry) []*pb.PriceListEntry {
+	entries := make([]*pb.PriceListEntry, 0, len(priceList))
+	for _, entry := range priceList {
+		protoEntry := &pb.PriceListEntry{
+			Market: entry.Market,
+			Price:  &pb.Money{AmountMinor: entry.Price.Amount, Currency: entry.Price.Currency},
+		}
+		if entry.ValidFrom != nil {
+			protoEntry.ValidFrom = entry.ValidFrom.Format(time.RFC3339)
+		}
+		if entry.ValidTo != nil {
+			protoEntry.ValidTo = entry.ValidTo.Format(time.RFC3339)
+		}
+		entries = append(entries, protoEntry)
+	}
+	return entries
+}
+
+// parseProtoTime parses an RFC 3339 timestamp, nil if value is empty.
+func parseProtoTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func fromProtoAttributes(attributes *structpb.Struct) map[string]interface{} {
+	if attributes == nil {
+		return nil
+	}
+	return attributes.AsMap()
+}
+
+// toProtoAttributes converts item attributes to a Struct. Values that have
+// no JSON form are left out rather than failing the whole item.
+func toProtoAttributes(attributes map[string]interface{}) *structpb.Struct {
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]*structpb.Value, len(attributes))
+	for name, value := range attributes {
+		if v, err := structpb.NewValue(value); err == nil {
+			fields[name] = v
+		}
+	}
+	return &structpb.Struct{Fields: fields}
+}
+
+// authStreamInterceptor is the streaming counterpart of authInterceptor.
+func authStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticatedStream overrides the context of a stream with the one
+// carrying the user ID.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,48 @@
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	pb "rancher-manager/api/proto/itemservice"
+)
+
+func TestItemPatchPriceList(t *testing.T) {
+	item := &pb.Item{PriceList: []*pb.PriceListEntry{{
+		Market:    "eu",
+		Price:     &pb.Money{AmountMinor: 1999, Currency: "eur"},
+		ValidFrom: "2024-01-01T00:00:00Z",
+	}}}
+
+	patch, err := itemPatch(item, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patch.PriceList == nil || len(*patch.PriceList) != 1 {
+		t.Fatalf("price list = %v, want one entry", patch.PriceList)
+	}
+	entry := (*patch.PriceList)[0]
+	if entry.Market != "eu" || entry.Price.Amount != 1999 || entry.Price.Currency != "EUR" || entry.ValidTo != nil {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.ValidFrom == nil || !entry.ValidFrom.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("valid_from = %v, want 2024-01-01", entry.ValidFrom)
+	}
+	if got := toProtoPriceList(*patch.PriceList); len(got) != 1 || got[0].ValidFrom != "2024-01-01T00:00:00Z" || got[0].ValidTo != "" {
+		t.Errorf("round trip = %v", got)
+	}
+
+	// Listing it in the mask with no entries clears the price list
+	patch, err = itemPatch(&pb.Item{}, []string{"price_list"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patch.PriceList == nil || len(*patch.PriceList) != 0 {
+		t.Errorf("price list = %v, want cleared", patch.PriceList)
+	}
+
+	item.PriceList[0].ValidTo = "tomorrow"
+	if _, err := itemPatch(item, []string{"price_list"}); err == nil {
+		t.Error("itemPatch accepted an invalid valid_to")
+	}
+}
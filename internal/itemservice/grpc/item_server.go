@@ -15,9 +15,14 @@ import (
 )
 
 type ItemServiceInterface interface {
-	GetItem(id string, userID uint32) (*model.Item, error)
-	UpdateItem(id string, req *model.UpdateItemRequest, userID uint32) (*model.Item, error)
-	DeleteItem(id string, userID uint32) error
+	CreateItem(ctx context.Context, req *model.CreateItemRequest, userID uint32) (*model.Item, error)
+	GetItem(ctx context.Context, id string, userID uint32) (*model.Item, error)
+	GetItems(ctx context.Context, ids []string, userID uint32) ([]*model.Item, []string, error)
+	ListItems(ctx context.Context, filter *model.ItemFilter, userID uint32) ([]*model.Item, error)
+	UpdateItem(ctx context.Context, id string, req *model.UpdateItemRequest, userID uint32) (*model.Item, error)
+	PatchItem(ctx context.Context, id string, patch *model.ItemPatch, userID uint32) (*model.Item, error)
+	DeleteItem(ctx context.Context, id string, userID uint32) error
+	WatchItems(ctx context.Context, filter *model.WatchFilter, userID uint32) (<-chan model.ItemEvent, func(), error)
 }
 
 type ItemGRPCServer struct {
@@ -33,6 +38,16 @@ func NewItemGRPCServer(itemService ItemServiceInterface) *ItemGRPCServer {
 
 // authInterceptor extracts user_id from metadata and adds it to context
 func authInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// authenticate returns ctx with the user_id from the incoming metadata.
+func authenticate(ctx context.Context) (context.Context, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return nil, status.Errorf(codes.Unauthenticated, "metadata not found")
@@ -55,9 +70,7 @@ func authInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServe
 	}
 
 	// Add user_id to context
-	ctx = context.WithValue(ctx, "user_id", userID)
-
-	return handler(ctx, req)
+	return context.WithValue(ctx, "user_id", userID), nil
 }
 
 func (s *ItemGRPCServer) GetItem(ctx context.Context, req *pb.GetItemRequest) (*pb.GetItemResponse, error) {
@@ -70,8 +83,11 @@ func (s *ItemGRPCServer) GetItem(ctx context.Context, req *pb.GetItemRequest) (*
 		}, nil
 	}
 
-	item, err := s.itemService.GetItem(req.ItemId, userID)
+	item, err := s.itemService.GetItem(ctx, req.ItemId, userID)
 	if err != nil {
+		if st := infrastructureStatus(err); st != nil {
+			return nil, st
+		}
 		return &pb.GetItemResponse{
 			Success: false,
 			Message: err.Error(),
@@ -80,18 +96,7 @@ func (s *ItemGRPCServer) GetItem(ctx context.Context, req *pb.GetItemRequest) (*
 
 	return &pb.GetItemResponse{
 		Success: true,
-		Item: &pb.Item{
-			Id:          item.ID.Hex(),
-			Name:        item.Name,
-			Description: item.Description,
-			Price:       item.Price,
-			Category:    item.Category,
-			Stock:       int32(item.Stock),
-			CreatedBy:   item.CreatedBy,
-			UpdatedBy:   item.UpdatedBy,
-			CreatedAt:   item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:   item.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		},
+		Item:    toProtoItem(item),
 		Message: "Item retrieved successfully",
 	}, nil
 }
@@ -110,8 +115,11 @@ func (s *ItemGRPCServer) UpdateStock(ctx context.Context, req *pb.UpdateStockReq
 		Stock: int(req.NewStock),
 	}
 
-	item, err := s.itemService.UpdateItem(req.ItemId, updateReq, userID)
+	item, err := s.itemService.UpdateItem(ctx, req.ItemId, updateReq, userID)
 	if err != nil {
+		if st := infrastructureStatus(err); st != nil {
+			return nil, st
+		}
 		return &pb.UpdateStockResponse{
 			Success: false,
 			Message: err.Error(),
@@ -120,18 +128,7 @@ func (s *ItemGRPCServer) UpdateStock(ctx context.Context, req *pb.UpdateStockReq
 
 	return &pb.UpdateStockResponse{
 		Success: true,
-		Item: &pb.Item{
-			Id:          item.ID.Hex(),
-			Name:        item.Name,
-			Description: item.Description,
-			Price:       item.Price,
-			Category:    item.Category,
-			Stock:       int32(item.Stock),
-			CreatedBy:   item.CreatedBy,
-			UpdatedBy:   item.UpdatedBy,
-			CreatedAt:   item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:   item.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		},
+		Item:    toProtoItem(item),
 		Message: "Stock updated successfully",
 	}, nil
 }
@@ -146,8 +143,11 @@ func (s *ItemGRPCServer) DeleteItem(ctx context.Context, req *pb.DeleteItemReque
 		}, nil
 	}
 
-	err := s.itemService.DeleteItem(req.ItemId, userID)
+	err := s.itemService.DeleteItem(ctx, req.ItemId, userID)
 	if err != nil {
+		if st := infrastructureStatus(err); st != nil {
+			return nil, st
+		}
 		return &pb.DeleteItemResponse{
 			Success: false,
 			Message: err.Error(),
@@ -160,13 +160,47 @@ func (s *ItemGRPCServer) DeleteItem(ctx context.Context, req *pb.DeleteItemReque
 	}, nil
 }
 
+// toProtoItem converts an item to its wire form. The deprecated double price
+// is still filled in for clients that predate price_money.
+func toProtoItem(item *model.Item) *pb.Item {
+	protoItem := &pb.Item{
+		Id:          item.ID.Hex(),
+		Name:        item.Name,
+		Description: item.Description,
+		Price:       item.Price.Float(),
+		Category:    item.Category,
+		Stock:       int32(item.Stock),
+		CreatedBy:   item.CreatedBy,
+		UpdatedBy:   item.UpdatedBy,
+		CreatedAt:   item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   item.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		PriceMoney: &pb.Money{
+			AmountMinor: item.Price.Amount,
+			Currency:    item.Price.Currency,
+		},
+		Status:     string(item.CurrentStatus()),
+		Sku:        item.SKU,
+		Attributes: toProtoAttributes(item.Attributes),
+	}
+	if item.ParentID != nil {
+		protoItem.ParentId = item.ParentID.Hex()
+	}
+	if len(item.PriceList) > 0 {
+		protoItem.PriceList = toProtoPriceList(item.PriceList)
+	}
+	return protoItem
+}
+
 func StartGRPCServer(itemService ItemServiceInterface, port string) error {
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
 	if err != nil {
 		return fmt.Errorf("failed to listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(authInterceptor))
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(authInterceptor),
+		grpc.StreamInterceptor(authStreamInterceptor),
+	)
 	pb.RegisterItemServiceServer(grpcServer, NewItemGRPCServer(itemService))
 
 	fmt.Printf("gRPC Item Server listening on port %s\n", port)
@@ -0,0 +1,385 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"rancher-manager/internal/itemservice/model"
+	"rancher-manager/internal/itemservice/service"
+)
+
+type CategoryHandler struct {
+	categoryService *service.CategoryService
+}
+
+func NewCategoryHandler(categoryService *service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService}
+}
+
+// CreateCategory godoc
+// @Summary Create a category
+// @Description Register a category together with its attribute schema
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param category body model.CreateCategoryRequest true "Category data"
+// @Success 201 {object} model.CategoryResponse
+// @Failure 400 {object} model.CategoryResponse
+// @Failure 401 {object} model.CategoryResponse
+// @Failure 409 {object} model.CategoryResponse
+// @Router /items/categories [post]
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.CategoryResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.CategoryResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	category, err := h.categoryService.CreateCategory(c.Request.Context(), &req, userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "already exists") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, model.CategoryResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.CategoryResponse{
+		Message: "Category created successfully",
+		Success: true,
+		Data:    category,
+	})
+}
+
+// GetCategory godoc
+// @Summary Get category by slug
+// @Description Get a category and its own attribute schema
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param slug path string true "Category slug"
+// @Success 200 {object} model.CategoryResponse
+// @Failure 401 {object} model.CategoryResponse
+// @Failure 404 {object} model.CategoryResponse
+// @Router /items/categories/{slug} [get]
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.CategoryResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	category, err := h.categoryService.GetCategory(c.Request.Context(), c.Param("slug"), userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.CategoryResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CategoryResponse{
+		Message: "Category retrieved successfully",
+		Success: true,
+		Data:    category,
+	})
+}
+
+// GetCategoryTree godoc
+// @Summary Get category tree
+// @Description Get all categories nested under their parents
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.CategoryTreeResponse
+// @Failure 401 {object} model.CategoryTreeResponse
+// @Router /items/categories [get]
+func (h *CategoryHandler) GetCategoryTree(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.CategoryTreeResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	tree, err := h.categoryService.GetCategoryTree(c.Request.Context(), userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, model.CategoryTreeResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CategoryTreeResponse{
+		Message: "Category tree retrieved successfully",
+		Success: true,
+		Data:    tree,
+	})
+}
+
+// UpdateCategory godoc
+// @Summary Update category
+// @Description Update a category's description or attribute schema
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param slug path string true "Category slug"
+// @Param category body model.UpdateCategoryRequest true "Category update data"
+// @Success 200 {object} model.CategoryResponse
+// @Failure 400 {object} model.CategoryResponse
+// @Failure 401 {object} model.CategoryResponse
+// @Failure 404 {object} model.CategoryResponse
+// @Router /items/categories/{slug} [put]
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.CategoryResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.CategoryResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	category, err := h.categoryService.UpdateCategory(c.Request.Context(), c.Param("slug"), &req, userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.CategoryResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CategoryResponse{
+		Message: "Category updated successfully",
+		Success: true,
+		Data:    category,
+	})
+}
+
+// DeleteCategory godoc
+// @Summary Delete category
+// @Description Delete a leaf category; items keep their category name
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param slug path string true "Category slug"
+// @Success 200 {object} model.CategoryResponse
+// @Failure 401 {object} model.CategoryResponse
+// @Failure 404 {object} model.CategoryResponse
+// @Failure 409 {object} model.CategoryResponse
+// @Router /items/categories/{slug} [delete]
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.CategoryResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	err := h.categoryService.DeleteCategory(c.Request.Context(), c.Param("slug"), userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "has subcategories") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, model.CategoryResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CategoryResponse{
+		Message: "Category deleted successfully",
+		Success: true,
+	})
+}
+
+// RenameCategory godoc
+// @Summary Rename category
+// @Description Rename a category; the new name and slug cascade to its items
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param slug path string true "Category slug"
+// @Param rename body model.RenameCategoryRequest true "New name"
+// @Success 200 {object} model.CategoryResponse
+// @Failure 400 {object} model.CategoryResponse
+// @Failure 401 {object} model.CategoryResponse
+// @Failure 404 {object} model.CategoryResponse
+// @Failure 409 {object} model.CategoryResponse
+// @Router /items/categories/{slug}/rename [post]
+func (h *CategoryHandler) RenameCategory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.CategoryResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.RenameCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.CategoryResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	category, err := h.categoryService.RenameCategory(c.Request.Context(), c.Param("slug"), &req, userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "already exists") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, model.CategoryResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CategoryResponse{
+		Message: "Category renamed successfully",
+		Success: true,
+		Data:    category,
+	})
+}
+
+// MoveCategory godoc
+// @Summary Move category
+// @Description Move a category (with its subtree) under another parent, or to the root
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param slug path string true "Category slug"
+// @Param move body model.MoveCategoryRequest true "New parent slug"
+// @Success 200 {object} model.CategoryResponse
+// @Failure 400 {object} model.CategoryResponse
+// @Failure 401 {object} model.CategoryResponse
+// @Failure 404 {object} model.CategoryResponse
+// @Router /items/categories/{slug}/move [post]
+func (h *CategoryHandler) MoveCategory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.CategoryResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.MoveCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.CategoryResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	category, err := h.categoryService.MoveCategory(c.Request.Context(), c.Param("slug"), &req, userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.CategoryResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CategoryResponse{
+		Message: "Category moved successfully",
+		Success: true,
+		Data:    category,
+	})
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"rancher-manager/internal/itemservice/model"
+	"rancher-manager/internal/itemservice/service"
+)
+
+// statusClientClosedRequest is the non-standard status nginx uses for
+// requests the client abandoned before a response was written.
+const statusClientClosedRequest = 499
+
+// infrastructureStatus maps timeouts, cancellations and outages of the
+// database or the auth service to a status code. ok is false for errors
+// caused by the request itself.
+func infrastructureStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, service.ErrTimeout):
+		return http.StatusGatewayTimeout, true
+	case errors.Is(err, service.ErrCanceled):
+		return statusClientClosedRequest, true
+	case errors.Is(err, service.ErrUnavailable):
+		return http.StatusServiceUnavailable, true
+	}
+	return 0, false
+}
+
+// validationErrors returns the field errors of a failed item validation, or
+// nil for any other error.
+func validationErrors(err error) []model.FieldError {
+	var validationErr *model.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Errors
+	}
+	return nil
+}
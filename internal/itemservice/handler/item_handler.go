@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -11,8 +13,8 @@ import (
 )
 
 type AuthClientInterface interface {
-	ValidateToken(token string) (interface{}, error)
-	GetUser(userID uint32) (interface{}, error)
+	ValidateToken(ctx context.Context, token string) (interface{}, error)
+	GetUser(ctx context.Context, userID uint32) (interface{}, error)
 }
 
 type ItemHandler struct {
@@ -38,6 +40,7 @@ func NewItemHandler(itemService *service.ItemService) *ItemHandler {
 // @Success 201 {object} model.ItemResponse
 // @Failure 400 {object} model.ItemResponse
 // @Failure 401 {object} model.ItemResponse
+// @Failure 409 {object} model.ItemResponse
 // @Router /items/ [post]
 func (h *ItemHandler) CreateItem(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -58,15 +61,20 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 		return
 	}
 
-	item, err := h.itemService.CreateItem(&req, userID.(uint32))
+	item, err := h.itemService.CreateItem(c.Request.Context(), &req, userID.(uint32))
 	if err != nil {
 		status := http.StatusBadRequest
-		if strings.Contains(err.Error(), "unauthorized") {
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
 			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "already exists") {
+			status = http.StatusConflict
 		}
 		c.JSON(status, model.ItemResponse{
 			Message: err.Error(),
 			Success: false,
+			Errors:  validationErrors(err),
 		})
 		return
 	}
@@ -110,10 +118,12 @@ func (h *ItemHandler) GetItem(c *gin.Context) {
 		return
 	}
 
-	item, err := h.itemService.GetItem(id, userID.(uint32))
+	item, err := h.itemService.GetItem(c.Request.Context(), id, userID.(uint32))
 	if err != nil {
 		status := http.StatusBadRequest
-		if strings.Contains(err.Error(), "unauthorized") {
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
 			status = http.StatusUnauthorized
 		} else if strings.Contains(err.Error(), "not found") {
 			status = http.StatusNotFound
@@ -134,11 +144,15 @@ func (h *ItemHandler) GetItem(c *gin.Context) {
 
 // GetAllItems godoc
 // @Summary Get all items
-// @Description Get all items with authentication
+// @Description Get all items with authentication, optionally filtered by category, parent and attribute values (attr.<name>=<value>)
 // @Tags items
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param category query string false "Category name or slug"
+// @Param include_descendants query bool false "Also match items in subcategories"
+// @Param status query string false "Comma-separated statuses, or all (default active)"
+// @Param parent_id query string false "Only variants of this parent item"
 // @Success 200 {object} model.ItemsResponse
 // @Failure 401 {object} model.ItemsResponse
 // @Router /items/ [get]
@@ -152,10 +166,20 @@ func (h *ItemHandler) GetAllItems(c *gin.Context) {
 		return
 	}
 
-	items, err := h.itemService.GetAllItems(userID.(uint32))
+	filter := &model.ItemFilter{
+		Category:           c.Query("category"),
+		IncludeDescendants: c.Query("include_descendants") == "true",
+		ParentID:           c.Query("parent_id"),
+		Attributes:         attributeFilters(c),
+		Statuses:           statusFilter(c.Query("status")),
+	}
+
+	items, err := h.itemService.ListItems(c.Request.Context(), filter, userID.(uint32))
 	if err != nil {
 		status := http.StatusBadRequest
-		if strings.Contains(err.Error(), "unauthorized") {
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
 			status = http.StatusUnauthorized
 		}
 		c.JSON(status, model.ItemsResponse{
@@ -185,6 +209,7 @@ func (h *ItemHandler) GetAllItems(c *gin.Context) {
 // @Failure 400 {object} model.ItemResponse
 // @Failure 401 {object} model.ItemResponse
 // @Failure 404 {object} model.ItemResponse
+// @Failure 409 {object} model.ItemResponse
 // @Router /items/{id} [put]
 func (h *ItemHandler) UpdateItem(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -214,17 +239,22 @@ func (h *ItemHandler) UpdateItem(c *gin.Context) {
 		return
 	}
 
-	item, err := h.itemService.UpdateItem(id, &req, userID.(uint32))
+	item, err := h.itemService.UpdateItem(c.Request.Context(), id, &req, userID.(uint32))
 	if err != nil {
 		status := http.StatusBadRequest
-		if strings.Contains(err.Error(), "unauthorized") {
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
 			status = http.StatusUnauthorized
 		} else if strings.Contains(err.Error(), "not found") {
 			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "already exists") {
+			status = http.StatusConflict
 		}
 		c.JSON(status, model.ItemResponse{
 			Message: err.Error(),
 			Success: false,
+			Errors:  validationErrors(err),
 		})
 		return
 	}
@@ -268,10 +298,12 @@ func (h *ItemHandler) DeleteItem(c *gin.Context) {
 		return
 	}
 
-	err := h.itemService.DeleteItem(id, userID.(uint32))
+	err := h.itemService.DeleteItem(c.Request.Context(), id, userID.(uint32))
 	if err != nil {
 		status := http.StatusBadRequest
-		if strings.Contains(err.Error(), "unauthorized") {
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
 			status = http.StatusUnauthorized
 		} else if strings.Contains(err.Error(), "not found") {
 			status = http.StatusNotFound
@@ -289,6 +321,488 @@ func (h *ItemHandler) DeleteItem(c *gin.Context) {
 	})
 }
 
+// CreateVariant godoc
+// @Summary Create item variant
+// @Description Create a variant (own SKU, price and stock) under a parent item
+// @Tags items
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Parent item ID"
+// @Param variant body model.CreateVariantRequest true "Variant data"
+// @Success 201 {object} model.ItemResponse
+// @Failure 400 {object} model.ItemResponse
+// @Failure 401 {object} model.ItemResponse
+// @Failure 404 {object} model.ItemResponse
+// @Failure 409 {object} model.ItemResponse
+// @Router /items/{id}/variants [post]
+func (h *ItemHandler) CreateVariant(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ItemResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, model.ItemResponse{
+			Message: "Item ID is required",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.CreateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ItemResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	variant, err := h.itemService.CreateVariant(c.Request.Context(), id, &req, userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "already exists") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, model.ItemResponse{
+			Message: err.Error(),
+			Success: false,
+			Errors:  validationErrors(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.ItemResponse{
+		Message: "Variant created successfully",
+		Success: true,
+		Data:    variant,
+	})
+}
+
+// GetVariants godoc
+// @Summary Get item variants
+// @Description Get all variants of a parent item
+// @Tags items
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Parent item ID"
+// @Success 200 {object} model.ItemsResponse
+// @Failure 401 {object} model.ItemsResponse
+// @Failure 404 {object} model.ItemsResponse
+// @Router /items/{id}/variants [get]
+func (h *ItemHandler) GetVariants(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ItemsResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	variants, err := h.itemService.GetVariants(c.Request.Context(), c.Param("id"), userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.ItemsResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ItemsResponse{
+		Message: "Variants retrieved successfully",
+		Success: true,
+		Data:    variants,
+	})
+}
+
+// GetPrice godoc
+// @Summary Get item price
+// @Description Resolve an item's price for a currency and market from its price list, falling back to exchange-rate conversion
+// @Tags items
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Item ID"
+// @Param currency query string false "ISO 4217 currency code"
+// @Param market query string false "Market code"
+// @Success 200 {object} model.PriceQuoteResponse
+// @Failure 400 {object} model.PriceQuoteResponse
+// @Failure 401 {object} model.PriceQuoteResponse
+// @Failure 404 {object} model.PriceQuoteResponse
+// @Router /items/{id}/price [get]
+func (h *ItemHandler) GetPrice(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.PriceQuoteResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	quote, err := h.itemService.GetPrice(c.Request.Context(), c.Param("id"), c.Query("currency"), c.Query("market"), userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.PriceQuoteResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.PriceQuoteResponse{
+		Message: "Price retrieved successfully",
+		Success: true,
+		Data:    quote,
+	})
+}
+
+// UploadAttachment godoc
+// @Summary Upload item attachment
+// @Description Upload a product photo or document for an item (multipart field "file")
+// @Tags items
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Item ID"
+// @Param file formData file true "Attachment"
+// @Success 201 {object} model.AttachmentResponse
+// @Failure 400 {object} model.AttachmentResponse
+// @Failure 401 {object} model.AttachmentResponse
+// @Failure 404 {object} model.AttachmentResponse
+// @Failure 413 {object} model.AttachmentResponse
+// @Failure 415 {object} model.AttachmentResponse
+// @Router /items/{id}/attachments [post]
+func (h *ItemHandler) UploadAttachment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.AttachmentResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.AttachmentResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+	if fileHeader.Size > service.MaxAttachmentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, model.AttachmentResponse{
+			Message: "Attachment too large",
+			Success: false,
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.AttachmentResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.itemService.UploadAttachment(c.Request.Context(), c.Param("id"), fileHeader.Filename, file, userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "too large") {
+			status = http.StatusRequestEntityTooLarge
+		} else if strings.Contains(err.Error(), "unsupported content type") {
+			status = http.StatusUnsupportedMediaType
+		}
+		c.JSON(status, model.AttachmentResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.AttachmentResponse{
+		Message: "Attachment uploaded successfully",
+		Success: true,
+		Data:    attachment,
+	})
+}
+
+// DownloadAttachment godoc
+// @Summary Download item attachment
+// @Description Download an attachment, or its thumbnail with thumbnail=true
+// @Tags items
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path string true "Item ID"
+// @Param attachment_id path string true "Attachment ID"
+// @Param thumbnail query bool false "Return the thumbnail instead of the original"
+// @Success 200 {file} file
+// @Failure 401 {object} model.AttachmentResponse
+// @Failure 404 {object} model.AttachmentResponse
+// @Router /items/{id}/attachments/{attachment_id} [get]
+func (h *ItemHandler) DownloadAttachment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.AttachmentResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	thumbnail := c.Query("thumbnail") == "true"
+	attachment, reader, err := h.itemService.OpenAttachment(c.Request.Context(), c.Param("id"), c.Param("attachment_id"), thumbnail, userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.AttachmentResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+	defer reader.Close()
+
+	contentType := attachment.ContentType
+	size := attachment.Size
+	if thumbnail {
+		contentType = attachment.ThumbnailType
+		size = -1
+	}
+
+	c.DataFromReader(http.StatusOK, size, contentType, reader, map[string]string{
+		"Content-Disposition": fmt.Sprintf("inline; filename=%q", attachment.FileName),
+	})
+}
+
+// DeleteAttachment godoc
+// @Summary Delete item attachment
+// @Description Delete an attachment and its stored content
+// @Tags items
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Item ID"
+// @Param attachment_id path string true "Attachment ID"
+// @Success 200 {object} model.AttachmentResponse
+// @Failure 401 {object} model.AttachmentResponse
+// @Failure 404 {object} model.AttachmentResponse
+// @Router /items/{id}/attachments/{attachment_id} [delete]
+func (h *ItemHandler) DeleteAttachment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.AttachmentResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	err := h.itemService.DeleteAttachment(c.Request.Context(), c.Param("id"), c.Param("attachment_id"), userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.AttachmentResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.AttachmentResponse{
+		Message: "Attachment deleted successfully",
+		Success: true,
+	})
+}
+
+// TransitionItem godoc
+// @Summary Change item status
+// @Description Move an item through its lifecycle (draft, pending_review, active, discontinued, archived)
+// @Tags items
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Item ID"
+// @Param transition body model.TransitionItemRequest true "Target status"
+// @Success 200 {object} model.ItemResponse
+// @Failure 400 {object} model.ItemResponse
+// @Failure 401 {object} model.ItemResponse
+// @Failure 403 {object} model.ItemResponse
+// @Failure 404 {object} model.ItemResponse
+// @Failure 409 {object} model.ItemResponse
+// @Router /items/{id}/transitions [post]
+func (h *ItemHandler) TransitionItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ItemResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	var req model.TransitionItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ItemResponse{
+			Message: "Invalid request data: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	item, err := h.itemService.TransitionItem(c.Request.Context(), c.Param("id"), &req, userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "forbidden") {
+			status = http.StatusForbidden
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "invalid transition") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, model.ItemResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ItemResponse{
+		Message: "Item status updated successfully",
+		Success: true,
+		Data:    item,
+	})
+}
+
+// GetTransitions godoc
+// @Summary Get item status history
+// @Description Get the recorded lifecycle transitions of an item
+// @Tags items
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Item ID"
+// @Success 200 {object} model.TransitionsResponse
+// @Failure 401 {object} model.TransitionsResponse
+// @Failure 404 {object} model.TransitionsResponse
+// @Router /items/{id}/transitions [get]
+func (h *ItemHandler) GetTransitions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.TransitionsResponse{
+			Message: "User not authenticated",
+			Success: false,
+		})
+		return
+	}
+
+	transitions, err := h.itemService.GetTransitions(c.Request.Context(), c.Param("id"), userID.(uint32))
+	if err != nil {
+		status := http.StatusBadRequest
+		if code, ok := infrastructureStatus(err); ok {
+			status = code
+		} else if strings.Contains(err.Error(), "unauthorized") {
+			status = http.StatusUnauthorized
+		} else if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.TransitionsResponse{
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.TransitionsResponse{
+		Message: "Transitions retrieved successfully",
+		Success: true,
+		Data:    transitions,
+	})
+}
+
+// statusFilter parses the status query parameter. An empty value leaves the
+// default (active only) to the service; "all" matches every status.
+func statusFilter(value string) []model.ItemStatus {
+	if value == "" {
+		return nil
+	}
+	if value == "all" {
+		return []model.ItemStatus{}
+	}
+
+	var statuses []model.ItemStatus
+	for _, status := range strings.Split(value, ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			statuses = append(statuses, model.ItemStatus(status))
+		}
+	}
+	return statuses
+}
+
+// attributeFilters collects attr.<name>=<value> query parameters.
+func attributeFilters(c *gin.Context) map[string]string {
+	filters := make(map[string]string)
+	for key, values := range c.Request.URL.Query() {
+		if name, ok := strings.CutPrefix(key, "attr."); ok && name != "" && len(values) > 0 {
+			filters[name] = values[0]
+		}
+	}
+	return filters
+}
+
 // AuthMiddleware validates JWT token via gRPC and sets user context
 func (h *ItemHandler) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -316,8 +830,16 @@ func (h *ItemHandler) AuthMiddleware() gin.HandlerFunc {
 		tokenString := tokenParts[1]
 
 		// Validate token via gRPC
-		response, err := h.authClient.ValidateToken(tokenString)
+		response, err := h.authClient.ValidateToken(c.Request.Context(), tokenString)
 		if err != nil {
+			if code, ok := infrastructureStatus(service.AuthError(err)); ok {
+				c.JSON(code, model.ItemResponse{
+					Message: "Auth service unavailable",
+					Success: false,
+				})
+				c.Abort()
+				return
+			}
 			c.JSON(http.StatusUnauthorized, model.ItemResponse{
 				Message: "Invalid or expired token",
 				Success: false,
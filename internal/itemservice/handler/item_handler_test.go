@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"rancher-manager/internal/itemservice/model"
+	"rancher-manager/internal/itemservice/repository"
+	"rancher-manager/internal/itemservice/service"
+	"rancher-manager/internal/itemservice/storage"
+)
+
+// fakeAuthClient accepts the tokens "user-token" and "admin-token".
+type fakeAuthClient struct {
+	err error
+}
+
+var testTokens = map[string]struct {
+	userID uint32
+	role   string
+}{
+	"user-token":  {1, "user"},
+	"admin-token": {2, "admin"},
+}
+
+func (f *fakeAuthClient) ValidateToken(ctx context.Context, token string) (interface{}, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	user, ok := testTokens[token]
+	if !ok {
+		return map[string]interface{}{"valid": false}, nil
+	}
+	return map[string]interface{}{"valid": true, "user_id": user.userID, "username": user.role, "role": user.role}, nil
+}
+
+func (f *fakeAuthClient) GetUser(ctx context.Context, userID uint32) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	for _, user := range testTokens {
+		if user.userID == userID {
+			return map[string]interface{}{"user_id": userID, "role": user.role}, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "user not found")
+}
+
+func newTestRouter(t *testing.T) (*gin.Engine, *fakeAuthClient) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := &fakeAuthClient{}
+	itemService := service.NewItemService(
+		repository.NewMemoryItemRepository(),
+		repository.NewMemoryCategoryRepository(),
+		auth,
+		nil,
+		blobStore,
+		nil,
+		nil,
+	)
+	itemHandler := NewItemHandler(itemService)
+
+	r := gin.New()
+	items := r.Group("/items")
+	items.Use(itemHandler.AuthMiddleware())
+	{
+		items.POST("/", itemHandler.CreateItem)
+		items.GET("/", itemHandler.GetAllItems)
+		items.GET("/:id", itemHandler.GetItem)
+		items.PUT("/:id", itemHandler.UpdateItem)
+		items.DELETE("/:id", itemHandler.DeleteItem)
+		items.POST("/:id/transitions", itemHandler.TransitionItem)
+	}
+	return r, auth
+}
+
+func doRequest(t *testing.T, r http.Handler, ctx context.Context, method, path, token string, body interface{}) (*httptest.ResponseRecorder, model.ItemResponse) {
+	t.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &payload).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp model.ItemResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func createTestItem(t *testing.T, r http.Handler, body map[string]interface{}) *model.Item {
+	t.Helper()
+
+	w, resp := doRequest(t, r, context.Background(), http.MethodPost, "/items/", "user-token", body)
+	if w.Code != http.StatusCreated || resp.Data == nil {
+		t.Fatalf("create: status %d, body %s", w.Code, w.Body.String())
+	}
+	return resp.Data
+}
+
+func TestItemHandlerCRUD(t *testing.T) {
+	r, _ := newTestRouter(t)
+	ctx := context.Background()
+
+	item := createTestItem(t, r, map[string]interface{}{
+		"name":  "Lamp",
+		"sku":   "LAMP-1",
+		"price": map[string]interface{}{"amount": 1999, "currency": "usd"},
+		"stock": 4,
+	})
+	if item.Status != model.ItemStatusDraft || item.Price != (model.Money{Amount: 1999, Currency: "USD"}) {
+		t.Errorf("created item = %+v", item)
+	}
+	path := "/items/" + item.ID.Hex()
+
+	w, resp := doRequest(t, r, ctx, http.MethodGet, path, "user-token", nil)
+	if w.Code != http.StatusOK || resp.Data.Name != "Lamp" {
+		t.Fatalf("get: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	w, resp = doRequest(t, r, ctx, http.MethodPut, path, "user-token", map[string]interface{}{"name": "Desk lamp", "stock": 9})
+	if w.Code != http.StatusOK || resp.Data.Name != "Desk lamp" || resp.Data.Stock != 9 {
+		t.Fatalf("update: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	w, _ = doRequest(t, r, ctx, http.MethodDelete, path, "user-token", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	w, _ = doRequest(t, r, ctx, http.MethodGet, path, "user-token", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("get after delete: status %d, want 404", w.Code)
+	}
+}
+
+func TestItemHandlerStatusCodes(t *testing.T) {
+	r, _ := newTestRouter(t)
+	item := createTestItem(t, r, map[string]interface{}{"name": "Lamp", "sku": "LAMP-1", "price": 10})
+	path := "/items/" + item.ID.Hex()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		path   string
+		token  string
+		body   interface{}
+		want   int
+	}{
+		{name: "no token", method: http.MethodGet, path: path, want: http.StatusUnauthorized},
+		{name: "bad token", method: http.MethodGet, path: path, token: "nope", want: http.StatusUnauthorized},
+		{name: "missing item", method: http.MethodGet, path: "/items/64b7f0a1c2d3e4f5a6b7c8d9", token: "user-token", want: http.StatusNotFound},
+		{name: "missing name", method: http.MethodPost, path: "/items/", token: "user-token", body: map[string]interface{}{"price": 10}, want: http.StatusBadRequest},
+		{name: "duplicate sku", method: http.MethodPost, path: "/items/", token: "user-token", body: map[string]interface{}{"name": "Other", "sku": "LAMP-1", "price": 10}, want: http.StatusConflict},
+		{name: "invalid transition", method: http.MethodPost, path: path + "/transitions", token: "admin-token", body: map[string]interface{}{"status": "active"}, want: http.StatusConflict},
+		{name: "client gone", ctx: canceled, method: http.MethodGet, path: path, token: "user-token", want: statusClientClosedRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			w, _ := doRequest(t, r, ctx, tt.method, tt.path, tt.token, tt.body)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestItemHandlerApprovalIsForbiddenForUsers(t *testing.T) {
+	r, _ := newTestRouter(t)
+	item := createTestItem(t, r, map[string]interface{}{"name": "Lamp", "price": 10})
+	path := "/items/" + item.ID.Hex() + "/transitions"
+	ctx := context.Background()
+
+	if w, _ := doRequest(t, r, ctx, http.MethodPost, path, "user-token", map[string]interface{}{"status": "pending_review"}); w.Code != http.StatusOK {
+		t.Fatalf("submit for review: status %d, body %s", w.Code, w.Body.String())
+	}
+	if w, _ := doRequest(t, r, ctx, http.MethodPost, path, "user-token", map[string]interface{}{"status": "active"}); w.Code != http.StatusForbidden {
+		t.Errorf("approve as user: status %d, want 403", w.Code)
+	}
+	if w, _ := doRequest(t, r, ctx, http.MethodPost, path, "admin-token", map[string]interface{}{"status": "active"}); w.Code != http.StatusOK {
+		t.Errorf("approve as admin: status %d, body %s", w.Code, w.Body.String())
+	}
+}
+
+func TestItemHandlerListFilters(t *testing.T) {
+	r, _ := newTestRouter(t)
+	createTestItem(t, r, map[string]interface{}{"name": "Lamp", "category": "Lighting", "price": 10})
+	createTestItem(t, r, map[string]interface{}{"name": "Mug", "category": "Kitchen", "price": 5})
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{query: "", want: 0},
+		{query: "?status=draft", want: 2},
+		{query: "?status=all&category=lighting", want: 1},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/items/"+tt.query, nil)
+		req.Header.Set("Authorization", "Bearer user-token")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var resp model.ItemsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != http.StatusOK || len(resp.Data) != tt.want {
+			t.Errorf("GET /items/%s: status %d, %d items, want %d", tt.query, w.Code, len(resp.Data), tt.want)
+		}
+	}
+}
+
+func TestAuthMiddlewareReportsAuthServiceOutage(t *testing.T) {
+	r, auth := newTestRouter(t)
+	auth.err = status.Error(codes.Unavailable, "connection refused")
+
+	w, _ := doRequest(t, r, context.Background(), http.MethodGet, "/items/", "user-token", nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+}
+
+func TestItemHandlerReturnsFieldErrors(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	w, resp := doRequest(t, r, context.Background(), http.MethodPost, "/items/", "user-token", map[string]interface{}{
+		"name":  "Lamp <script>",
+		"price": map[string]interface{}{"amount": 100, "currency": "usd"},
+		"stock": -2,
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 (body %s)", w.Code, w.Body.String())
+	}
+
+	want := []model.FieldError{
+		{Field: "name", Code: model.ValidationInvalidCharacters},
+		{Field: "stock", Code: model.ValidationOutOfRange},
+	}
+	if len(resp.Errors) != len(want) {
+		t.Fatalf("errors = %+v, want %d", resp.Errors, len(want))
+	}
+	for i, fieldErr := range resp.Errors {
+		if fieldErr.Field != want[i].Field || fieldErr.Code != want[i].Code || fieldErr.Message == "" {
+			t.Errorf("errors[%d] = %+v, want %s %s", i, fieldErr, want[i].Field, want[i].Code)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Attachment is a file (product photo, spec sheet, ...) stored in the blob
+// store and referenced from the item document.
+type Attachment struct {
+	ID            string    `json:"id" bson:"id"`
+	FileName      string    `json:"file_name" bson:"file_name"`
+	ContentType   string    `json:"content_type" bson:"content_type"`
+	Size          int64     `json:"size" bson:"size"`
+	Key           string    `json:"-" bson:"key"`
+	ThumbnailKey  string    `json:"-" bson:"thumbnail_key,omitempty"`
+	ThumbnailType string    `json:"-" bson:"thumbnail_type,omitempty"`
+	HasThumbnail  bool      `json:"has_thumbnail" bson:"has_thumbnail"`
+	UploadedBy    uint32    `json:"uploaded_by" bson:"uploaded_by"`
+	UploadedAt    time.Time `json:"uploaded_at" bson:"uploaded_at"`
+}
+
+type AttachmentResponse struct {
+	Message string      `json:"message"`
+	Success bool        `json:"success"`
+	Data    *Attachment `json:"data,omitempty"`
+}
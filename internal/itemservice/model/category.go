@@ -0,0 +1,103 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AttributeType string
+
+const (
+	AttributeTypeEnum   AttributeType = "enum"
+	AttributeTypeNumber AttributeType = "number"
+	AttributeTypeText   AttributeType = "text"
+)
+
+// AttributeDefinition describes a single typed attribute that items in a
+// category may (or must) carry, e.g. "size" as an enum or "weight" in kg.
+type AttributeDefinition struct {
+	Name      string        `json:"name" bson:"name" binding:"required"`
+	Type      AttributeType `json:"type" bson:"type" binding:"required,oneof=enum number text"`
+	Required  bool          `json:"required" bson:"required"`
+	Options   []string      `json:"options,omitempty" bson:"options,omitempty"`
+	Unit      string        `json:"unit,omitempty" bson:"unit,omitempty"`
+	MaxLength int           `json:"max_length,omitempty" bson:"max_length,omitempty" binding:"min=0"`
+}
+
+// Category is a node in the category tree. Ancestors holds the IDs from the
+// root down to the direct parent so that descendants can be found with a
+// single query.
+type Category struct {
+	ID          primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	Name        string                `json:"name" bson:"name"`
+	Slug        string                `json:"slug" bson:"slug"`
+	ParentID    *primitive.ObjectID   `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	Ancestors   []primitive.ObjectID  `json:"ancestors" bson:"ancestors"`
+	Description string                `json:"description" bson:"description"`
+	Attributes  []AttributeDefinition `json:"attributes" bson:"attributes"`
+	MinPrice    *Money                `json:"min_price,omitempty" bson:"min_price,omitempty"`
+	MaxPrice    *Money                `json:"max_price,omitempty" bson:"max_price,omitempty"`
+	CreatedBy   uint32                `json:"created_by" bson:"created_by"`
+	UpdatedBy   uint32                `json:"updated_by" bson:"updated_by"`
+	CreatedAt   time.Time             `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at" bson:"updated_at"`
+}
+
+// Attribute returns the definition with the given name, or nil if the
+// category does not declare it.
+func (c *Category) Attribute(name string) *AttributeDefinition {
+	for i := range c.Attributes {
+		if c.Attributes[i].Name == name {
+			return &c.Attributes[i]
+		}
+	}
+	return nil
+}
+
+// CategoryNode is a category together with its subcategories.
+type CategoryNode struct {
+	*Category
+	Children []*CategoryNode `json:"children"`
+}
+
+// CreateCategoryRequest registers a category. MinPrice and MaxPrice bound
+// the prices of its items, including those in subcategories without bounds
+// of their own.
+type CreateCategoryRequest struct {
+	Name        string                `json:"name" binding:"required"`
+	Parent      string                `json:"parent"`
+	Description string                `json:"description"`
+	Attributes  []AttributeDefinition `json:"attributes" binding:"dive"`
+	MinPrice    *Money                `json:"min_price"`
+	MaxPrice    *Money                `json:"max_price"`
+}
+
+type UpdateCategoryRequest struct {
+	Description string                `json:"description"`
+	Attributes  []AttributeDefinition `json:"attributes" binding:"omitempty,dive"`
+	MinPrice    *Money                `json:"min_price"`
+	MaxPrice    *Money                `json:"max_price"`
+}
+
+type RenameCategoryRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// MoveCategoryRequest moves a category under the category with the given
+// slug; an empty parent moves it to the root.
+type MoveCategoryRequest struct {
+	Parent string `json:"parent"`
+}
+
+type CategoryResponse struct {
+	Message string    `json:"message"`
+	Success bool      `json:"success"`
+	Data    *Category `json:"data,omitempty"`
+}
+
+type CategoryTreeResponse struct {
+	Message string          `json:"message"`
+	Success bool            `json:"success"`
+	Data    []*CategoryNode `json:"data"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+type ItemEventType string
+
+const (
+	ItemEventCreated ItemEventType = "created"
+	ItemEventUpdated ItemEventType = "updated"
+	ItemEventDeleted ItemEventType = "deleted"
+)
+
+// ItemEvent describes a change to an item. Item is the item after the
+// change; for deletions it is the item as it was before, or nil if the
+// source could not tell. ResumeToken identifies the event's position in the
+// feed so that a watcher can continue after it.
+type ItemEvent struct {
+	Type        ItemEventType `json:"type"`
+	ItemID      string        `json:"item_id"`
+	Item        *Item         `json:"item,omitempty"`
+	OccurredAt  time.Time     `json:"occurred_at"`
+	ResumeToken string        `json:"resume_token,omitempty"`
+}
+
+// WatchFilter selects the item changes a watcher receives. Empty fields
+// match everything.
+type WatchFilter struct {
+	ItemIDs            []string
+	Category           string
+	IncludeDescendants bool
+
+	// ResumeToken continues a previous watch after the event that carried
+	// it instead of starting with changes made from now on.
+	ResumeToken string
+}
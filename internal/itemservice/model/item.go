@@ -7,38 +7,167 @@ import (
 )
 
 type Item struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Name        string             `json:"name" bson:"name"`
-	Description string             `json:"description" bson:"description"`
-	Price       float64            `json:"price" bson:"price"`
-	Category    string             `json:"category" bson:"category"`
-	Stock       int                `json:"stock" bson:"stock"`
-	CreatedBy   uint32             `json:"created_by" bson:"created_by"`
-	UpdatedBy   uint32             `json:"updated_by" bson:"updated_by"`
-	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	ID          primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	ParentID    *primitive.ObjectID    `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	SKU         string                 `json:"sku,omitempty" bson:"sku,omitempty"`
+	Name        string                 `json:"name" bson:"name"`
+	Description string                 `json:"description" bson:"description"`
+	Status      ItemStatus             `json:"status" bson:"status"`
+	Price       Money                  `json:"price" bson:"price"`
+	PriceList   []PriceListEntry       `json:"price_list,omitempty" bson:"price_list,omitempty"`
+	Category    string                 `json:"category" bson:"category"`
+	CategoryID  *primitive.ObjectID    `json:"category_id,omitempty" bson:"category_id,omitempty"`
+	Attributes  map[string]interface{} `json:"attributes,omitempty" bson:"attributes,omitempty"`
+	Stock       int                    `json:"stock" bson:"stock"`
+	Attachments []Attachment           `json:"attachments,omitempty" bson:"attachments,omitempty"`
+	CreatedBy   uint32                 `json:"created_by" bson:"created_by"`
+	UpdatedBy   uint32                 `json:"updated_by" bson:"updated_by"`
+	CreatedAt   time.Time              `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at" bson:"updated_at"`
+}
+
+// CurrentStatus returns the lifecycle status, treating items created before
+// statuses existed as active.
+func (i *Item) CurrentStatus() ItemStatus {
+	if i.Status == "" {
+		return ItemStatusActive
+	}
+	return i.Status
+}
+
+// PriceListEntryFor returns the price list entry for a currency that is
+// active at the given time, or nil. Entries for the requested market win over
+// market-less ones, and among equals the one that became valid most recently
+// wins.
+func (i *Item) PriceListEntryFor(currency, market string, at time.Time) *PriceListEntry {
+	var best *PriceListEntry
+	for idx := range i.PriceList {
+		entry := &i.PriceList[idx]
+		if entry.Price.Currency != currency || !entry.ActiveAt(at) {
+			continue
+		}
+		if entry.Market != "" && entry.Market != market {
+			continue
+		}
+		if best == nil || betterPrice(entry, best) {
+			best = entry
+		}
+	}
+	return best
+}
+
+func betterPrice(candidate, current *PriceListEntry) bool {
+	if (candidate.Market != "") != (current.Market != "") {
+		return candidate.Market != ""
+	}
+	if candidate.ValidFrom == nil {
+		return false
+	}
+	return current.ValidFrom == nil || candidate.ValidFrom.After(*current.ValidFrom)
+}
+
+// IsVariant reports whether the item is a variant of another (parent) item.
+func (i *Item) IsVariant() bool {
+	return i.ParentID != nil
 }
 
 type CreateItemRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"required,min=0"`
-	Category    string  `json:"category"`
-	Stock       int     `json:"stock" binding:"min=0"`
+	Name        string                 `json:"name"`
+	SKU         string                 `json:"sku"`
+	Description string                 `json:"description"`
+	Price       Money                  `json:"price"`
+	PriceList   []PriceListEntry       `json:"price_list"`
+	Category    string                 `json:"category"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	Stock       int                    `json:"stock"`
 }
 
 type UpdateItemRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"min=0"`
-	Category    string  `json:"category"`
-	Stock       int     `json:"stock" binding:"min=0"`
+	Name        string                 `json:"name"`
+	SKU         string                 `json:"sku"`
+	Description string                 `json:"description"`
+	Price       *Money                 `json:"price"`
+	PriceList   []PriceListEntry       `json:"price_list"`
+	Category    string                 `json:"category"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	Stock       int                    `json:"stock" binding:"min=0"`
+}
+
+// Patch converts the request to an ItemPatch. Empty strings and a nil
+// attribute map or price list leave the field unchanged, as the REST API
+// has always done.
+func (r *UpdateItemRequest) Patch() *ItemPatch {
+	patch := &ItemPatch{Price: r.Price}
+	if r.Name != "" {
+		patch.Name = &r.Name
+	}
+	if r.SKU != "" {
+		patch.SKU = &r.SKU
+	}
+	if r.Description != "" {
+		patch.Description = &r.Description
+	}
+	if r.PriceList != nil {
+		patch.PriceList = &r.PriceList
+	}
+	if r.Category != "" {
+		patch.Category = &r.Category
+	}
+	if r.Attributes != nil {
+		patch.Attributes = &r.Attributes
+	}
+	if r.Stock >= 0 {
+		patch.Stock = &r.Stock
+	}
+	return patch
+}
+
+// ItemPatch lists the fields to change on an item. Nil fields are left
+// alone; the others are set, even to empty values.
+type ItemPatch struct {
+	Name        *string
+	SKU         *string
+	Description *string
+	Price       *Money
+	PriceList   *[]PriceListEntry
+	Category    *string
+	Attributes  *map[string]interface{}
+	Stock       *int
+}
+
+type CreateVariantRequest struct {
+	SKU        string                 `json:"sku"`
+	Name       string                 `json:"name"`
+	Price      Money                  `json:"price"`
+	PriceList  []PriceListEntry       `json:"price_list"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Stock      int                    `json:"stock"`
+}
+
+// ItemFilter narrows item listings. Attribute values are matched as given in
+// the query string and converted according to the category schema.
+// Categories is filled in by the service from Category (and, when
+// IncludeDescendants is set, its subcategories). An empty Statuses matches
+// items in any status.
+type ItemFilter struct {
+	Category           string
+	IncludeDescendants bool
+	Categories         []string
+	ParentID           string
+	Attributes         map[string]string
+	Statuses           []ItemStatus
+
+	// AfterID and Limit page through the results in creation order: only
+	// items created after the one with ID AfterID, at most Limit of them.
+	AfterID string
+	Limit   int
 }
 
 type ItemResponse struct {
-	Message string `json:"message"`
-	Success bool   `json:"success"`
-	Data    *Item  `json:"data,omitempty"`
+	Message string       `json:"message"`
+	Success bool         `json:"success"`
+	Data    *Item        `json:"data,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"`
 }
 
 type ItemsResponse struct {
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ItemStatus string
+
+const (
+	ItemStatusDraft         ItemStatus = "draft"
+	ItemStatusPendingReview ItemStatus = "pending_review"
+	ItemStatusActive        ItemStatus = "active"
+	ItemStatusDiscontinued  ItemStatus = "discontinued"
+	ItemStatusArchived      ItemStatus = "archived"
+)
+
+// ItemTransition records a lifecycle state change of an item.
+type ItemTransition struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ItemID    primitive.ObjectID `json:"item_id" bson:"item_id"`
+	From      ItemStatus         `json:"from" bson:"from"`
+	To        ItemStatus         `json:"to" bson:"to"`
+	Reason    string             `json:"reason,omitempty" bson:"reason,omitempty"`
+	UserID    uint32             `json:"user_id" bson:"user_id"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type TransitionItemRequest struct {
+	Status ItemStatus `json:"status" binding:"required"`
+	Reason string     `json:"reason"`
+}
+
+type TransitionsResponse struct {
+	Message string            `json:"message"`
+	Success bool              `json:"success"`
+	Data    []*ItemTransition `json:"data"`
+}
@@ -0,0 +1,209 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// DefaultCurrency is assumed for legacy prices stored or sent as a bare
+// number, and for prices that omit the currency.
+var DefaultCurrency = "USD"
+
+// currencyExponents lists the number of minor-unit digits per ISO 4217 code.
+var currencyExponents = map[string]int{
+	"AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2,
+	"ILS": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3,
+	"MXN": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PLN": 2, "RON": 2, "RUB": 2,
+	"SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2,
+	"UAH": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// Money is an amount in the minor units of a currency (cents for USD,
+// yen for JPY), which keeps totals exact.
+type Money struct {
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+// CurrencyExponent returns the number of minor-unit digits of a currency.
+func CurrencyExponent(currency string) (int, bool) {
+	exp, ok := currencyExponents[strings.ToUpper(currency)]
+	return exp, ok
+}
+
+// ParseMoney converts a decimal string such as "19.99" into minor units
+// without going through float64.
+func ParseMoney(amount, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	exp, ok := CurrencyExponent(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("unsupported currency %q", currency)
+	}
+
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+
+	minor, err := RoundRat(r.Mul(r, pow10(exp)))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// Rat returns the amount in major units as an exact rational.
+func (m Money) Rat() *big.Rat {
+	exp, _ := CurrencyExponent(m.Currency)
+	return new(big.Rat).Quo(new(big.Rat).SetInt64(m.Amount), pow10(exp))
+}
+
+// Float returns the amount in major units. It is lossy and only meant for
+// legacy consumers such as the deprecated proto price field.
+func (m Money) Float() float64 {
+	f, _ := m.Rat().Float64()
+	return f
+}
+
+func (m Money) String() string {
+	exp, _ := CurrencyExponent(m.Currency)
+	return m.Rat().FloatString(exp) + " " + m.Currency
+}
+
+// Validate checks that the currency is known and the amount non-negative.
+func (m Money) Validate() error {
+	if _, ok := CurrencyExponent(m.Currency); !ok {
+		return fmt.Errorf("unsupported currency %q", m.Currency)
+	}
+	if m.Amount < 0 {
+		return errors.New("amount must not be negative")
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts either {"amount": 1999, "currency": "USD"} or, for
+// older clients, a bare decimal number in DefaultCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	trimmed := strings.TrimSpace(string(data))
+	if trimmed == "null" {
+		return nil
+	}
+	if !strings.HasPrefix(trimmed, "{") {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return fmt.Errorf("price must be an object or a number: %v", err)
+		}
+		parsed, err := ParseMoney(number.String(), DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	type plain Money
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*m = Money(decoded)
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	m.Currency = strings.ToUpper(m.Currency)
+	return nil
+}
+
+// UnmarshalBSONValue reads both the embedded document form and the legacy
+// double that items were stored with before prices had a currency.
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.Double, bsontype.Int32, bsontype.Int64:
+		var legacy float64
+		if err := bson.UnmarshalValue(t, data, &legacy); err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(big.NewFloat(legacy).Text('f', -1), DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case bsontype.Null, bsontype.Undefined:
+		return nil
+	}
+
+	type plain Money
+	var decoded plain
+	if err := bson.UnmarshalValue(t, data, &decoded); err != nil {
+		return err
+	}
+	*m = Money(decoded)
+	return nil
+}
+
+// PriceListEntry is a price for a currency, optionally restricted to a
+// market and a validity window, which allows scheduling price changes.
+type PriceListEntry struct {
+	Market    string     `json:"market,omitempty" bson:"market,omitempty"`
+	Price     Money      `json:"price" bson:"price"`
+	ValidFrom *time.Time `json:"valid_from,omitempty" bson:"valid_from,omitempty"`
+	ValidTo   *time.Time `json:"valid_to,omitempty" bson:"valid_to,omitempty"`
+}
+
+// ActiveAt reports whether the entry applies at the given time.
+func (e *PriceListEntry) ActiveAt(at time.Time) bool {
+	if e.ValidFrom != nil && at.Before(*e.ValidFrom) {
+		return false
+	}
+	if e.ValidTo != nil && !at.Before(*e.ValidTo) {
+		return false
+	}
+	return true
+}
+
+// PriceQuote is the price of an item resolved for a currency and market.
+type PriceQuote struct {
+	ItemID string `json:"item_id"`
+	Price  Money  `json:"price"`
+	Source string `json:"source"`
+	Rate   string `json:"rate,omitempty"`
+}
+
+type PriceQuoteResponse struct {
+	Message string      `json:"message"`
+	Success bool        `json:"success"`
+	Data    *PriceQuote `json:"data,omitempty"`
+}
+
+// RoundRat rounds half away from zero to an int64.
+func RoundRat(r *big.Rat) (int64, error) {
+	num := r.Num()
+	den := r.Denom()
+
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if twice := new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)); twice.Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	if !q.IsInt64() {
+		return 0, errors.New("amount out of range")
+	}
+	return q.Int64(), nil
+}
+
+func pow10(exp int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+}
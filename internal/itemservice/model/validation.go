@@ -0,0 +1,36 @@
+package model
+
+import "strings"
+
+// Validation error codes reported in FieldError.Code.
+const (
+	ValidationRequired          = "required"
+	ValidationTooLong           = "too_long"
+	ValidationInvalidCharacters = "invalid_characters"
+	ValidationInvalidFormat     = "invalid_format"
+	ValidationInvalidCurrency   = "invalid_currency"
+	ValidationOutOfRange        = "out_of_range"
+	ValidationInvalidValue      = "invalid_value"
+	ValidationUndefined         = "undefined"
+)
+
+// FieldError describes one invalid field. Field is a path into the item,
+// such as "name", "price_list[1].valid_to" or "attributes.size".
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports every rule an item breaks, not just the first.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		messages[i] = fieldErr.Field + ": " + fieldErr.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
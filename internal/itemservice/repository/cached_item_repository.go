@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/sync/singleflight"
+
+	"rancher-manager/internal/itemservice/cache"
+	"rancher-manager/internal/itemservice/model"
+)
+
+// CachedItemRepository is a read-through cache for item lookups by ID in
+// front of another ItemRepository. Misses are cached too, for a shorter
+// time, and concurrent misses for the same item share one database read.
+// Every write through the repository invalidates the items it touches; the
+// TTL bounds how long a read that raced with a write can serve stale data.
+type CachedItemRepository struct {
+	ItemRepository
+
+	cache       cache.Cache
+	metrics     *cache.Metrics
+	ttl         time.Duration
+	negativeTTL time.Duration
+	group       singleflight.Group
+}
+
+func NewCachedItemRepository(inner ItemRepository, c cache.Cache, metrics *cache.Metrics, ttl, negativeTTL time.Duration) *CachedItemRepository {
+	return &CachedItemRepository{
+		ItemRepository: inner,
+		cache:          c,
+		metrics:        metrics,
+		ttl:            ttl,
+		negativeTTL:    negativeTTL,
+	}
+}
+
+func itemCacheKey(id string) string {
+	return "item:" + id
+}
+
+func (r *CachedItemRepository) GetByID(ctx context.Context, id string) (*model.Item, error) {
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	key := itemCacheKey(id)
+	data, err := r.cache.Get(ctx, key)
+	switch {
+	case err == nil:
+		if len(data) == 0 {
+			r.metrics.Request(cache.ResultNegativeHit)
+			return nil, ErrNotFound
+		}
+		r.metrics.Request(cache.ResultHit)
+		return decodeCachedItem(data)
+	case errors.Is(err, cache.ErrMiss):
+		r.metrics.Request(cache.ResultMiss)
+	default:
+		// An unreachable cache must not take reads down with it
+		r.metrics.Request(cache.ResultError)
+		log.Printf("Item cache lookup for %s failed: %v", id, err)
+	}
+
+	// The shared load must not be canceled by whichever caller started it,
+	// but each caller still stops waiting when its own context ends.
+	result := r.group.DoChan(key, func() (interface{}, error) {
+		return r.load(context.WithoutCancel(ctx), id, key)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, mapError(ctx.Err())
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return decodeCachedItem(res.Val.([]byte))
+	}
+}
+
+// GetByIDs serves the items it has cached and reads the rest from the
+// underlying repository in one query, caching what it finds and what it
+// does not.
+func (r *CachedItemRepository) GetByIDs(ctx context.Context, ids []string) ([]*model.Item, error) {
+	items := []*model.Item{}
+	var uncached []string
+	for _, objectID := range validObjectIDs(ids) {
+		id := objectID.Hex()
+		data, err := r.cache.Get(ctx, itemCacheKey(id))
+		switch {
+		case err == nil && len(data) == 0:
+			r.metrics.Request(cache.ResultNegativeHit)
+			continue
+		case err == nil:
+			item, err := decodeCachedItem(data)
+			if err == nil {
+				r.metrics.Request(cache.ResultHit)
+				items = append(items, item)
+				continue
+			}
+			r.metrics.Request(cache.ResultError)
+		case errors.Is(err, cache.ErrMiss):
+			r.metrics.Request(cache.ResultMiss)
+		default:
+			r.metrics.Request(cache.ResultError)
+			log.Printf("Item cache lookup for %s failed: %v", id, err)
+		}
+		uncached = append(uncached, id)
+	}
+	if len(uncached) == 0 {
+		return items, nil
+	}
+
+	loaded, err := r.ItemRepository.GetByIDs(ctx, uncached)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(loaded))
+	for _, item := range loaded {
+		id := item.ID.Hex()
+		found[id] = true
+		if data, err := bson.Marshal(item); err == nil {
+			r.store(ctx, itemCacheKey(id), data, jitter(r.ttl))
+		}
+	}
+	for _, id := range uncached {
+		if !found[id] {
+			r.store(ctx, itemCacheKey(id), []byte{}, r.negativeTTL)
+		}
+	}
+	return append(items, loaded...), nil
+}
+
+// load reads an item from the underlying repository and caches the result,
+// including the fact that it does not exist.
+func (r *CachedItemRepository) load(ctx context.Context, id, key string) ([]byte, error) {
+	item, err := r.ItemRepository.GetByID(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		r.store(ctx, key, []byte{}, r.negativeTTL)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := bson.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	r.store(ctx, key, data, jitter(r.ttl))
+	return data, nil
+}
+
+func (r *CachedItemRepository) store(ctx context.Context, key string, data []byte, ttl time.Duration) {
+	if err := r.cache.Set(ctx, key, data, ttl); err != nil {
+		log.Printf("Failed to cache %s: %v", key, err)
+	}
+}
+
+// Invalidate drops cached entries for the given item IDs. It runs even if
+// the caller's context has ended, since the write it follows already
+// happened.
+func (r *CachedItemRepository) Invalidate(ctx context.Context, ids ...string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = itemCacheKey(id)
+	}
+	if err := r.cache.Delete(context.WithoutCancel(ctx), keys...); err != nil {
+		log.Printf("Failed to invalidate cached items %v: %v", ids, err)
+		return
+	}
+	r.metrics.Invalidated(len(ids))
+}
+
+func (r *CachedItemRepository) Create(ctx context.Context, item *model.Item) error {
+	if err := r.ItemRepository.Create(ctx, item); err != nil {
+		return err
+	}
+	r.Invalidate(ctx, item.ID.Hex())
+	return nil
+}
+
+func (r *CachedItemRepository) Update(ctx context.Context, id string, item *model.Item) error {
+	err := r.ItemRepository.Update(ctx, id, item)
+	r.Invalidate(ctx, id)
+	return err
+}
+
+func (r *CachedItemRepository) Delete(ctx context.Context, id string) error {
+	err := r.ItemRepository.Delete(ctx, id)
+	r.Invalidate(ctx, id)
+	return err
+}
+
+func (r *CachedItemRepository) DeleteVariants(ctx context.Context, parentID string) error {
+	variants, err := r.ItemRepository.GetVariants(ctx, parentID)
+	if err != nil {
+		return err
+	}
+
+	err = r.ItemRepository.DeleteVariants(ctx, parentID)
+	r.Invalidate(ctx, itemIDs(variants)...)
+	return err
+}
+
+func (r *CachedItemRepository) RenameCategory(ctx context.Context, categoryID primitive.ObjectID, oldName, newName string) error {
+	items, err := r.ItemRepository.GetByCategory(ctx, oldName)
+	if err != nil {
+		return err
+	}
+
+	err = r.ItemRepository.RenameCategory(ctx, categoryID, oldName, newName)
+	r.Invalidate(ctx, itemIDs(items)...)
+	return err
+}
+
+func (r *CachedItemRepository) AddAttachment(ctx context.Context, id string, attachment *model.Attachment) error {
+	err := r.ItemRepository.AddAttachment(ctx, id, attachment)
+	r.Invalidate(ctx, id)
+	return err
+}
+
+func (r *CachedItemRepository) RemoveAttachment(ctx context.Context, id, attachmentID string) error {
+	err := r.ItemRepository.RemoveAttachment(ctx, id, attachmentID)
+	r.Invalidate(ctx, id)
+	return err
+}
+
+func (r *CachedItemRepository) UpdateStatus(ctx context.Context, id string, from, to model.ItemStatus, userID uint32) error {
+	err := r.ItemRepository.UpdateStatus(ctx, id, from, to, userID)
+	r.Invalidate(ctx, id)
+	return err
+}
+
+func decodeCachedItem(data []byte) (*model.Item, error) {
+	var item model.Item
+	if err := bson.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func itemIDs(items []*model.Item) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID.Hex()
+	}
+	return ids
+}
+
+// jitter spreads expiry over an extra tenth of the TTL, so entries cached
+// together do not all expire at once.
+func jitter(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int63n(int64(ttl)/10+1))
+}
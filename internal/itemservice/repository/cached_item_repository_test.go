@@ -0,0 +1,346 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"rancher-manager/internal/itemservice/cache"
+	"rancher-manager/internal/itemservice/model"
+)
+
+// countingRepository counts lookups that reach the underlying repository
+// and can hold them until released.
+type countingRepository struct {
+	ItemRepository
+	lookups atomic.Int32
+	gate    chan struct{}
+}
+
+func (r *countingRepository) GetByID(ctx context.Context, id string) (*model.Item, error) {
+	r.lookups.Add(1)
+	if r.gate != nil {
+		<-r.gate
+	}
+	return r.ItemRepository.GetByID(ctx, id)
+}
+
+func (r *countingRepository) GetByIDs(ctx context.Context, ids []string) ([]*model.Item, error) {
+	r.lookups.Add(1)
+	return r.ItemRepository.GetByIDs(ctx, ids)
+}
+
+type cacheTest struct {
+	repo  *CachedItemRepository
+	inner *countingRepository
+	reg   *prometheus.Registry
+}
+
+func newCacheTest(t *testing.T) *cacheTest {
+	t.Helper()
+
+	reg := prometheus.NewRegistry()
+	inner := &countingRepository{ItemRepository: NewMemoryItemRepository()}
+	metrics := cache.NewMetrics(reg, "item")
+
+	return &cacheTest{
+		repo:  NewCachedItemRepository(inner, cache.NewMemoryCache(), metrics, time.Minute, time.Minute),
+		inner: inner,
+		reg:   reg,
+	}
+}
+
+// counter reads a counter from the test registry, selecting by the result
+// label when one is given.
+func (ct *cacheTest) counter(t *testing.T, name, result string) int {
+	t.Helper()
+
+	families, err := ct.reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if result == "" || metric.GetLabel()[0].GetValue() == result {
+				return int(metric.GetCounter().GetValue())
+			}
+		}
+	}
+	return 0
+}
+
+func (ct *cacheTest) requests(t *testing.T, result string) int {
+	return ct.counter(t, "item_cache_requests_total", result)
+}
+
+func (ct *cacheTest) create(t *testing.T, name string) *model.Item {
+	t.Helper()
+
+	item := &model.Item{Name: name, Status: model.ItemStatusDraft, Price: model.Money{Amount: 100, Currency: "USD"}}
+	if err := ct.repo.Create(context.Background(), item); err != nil {
+		t.Fatal(err)
+	}
+	return item
+}
+
+func TestCachedItemRepositoryReadThrough(t *testing.T) {
+	ct := newCacheTest(t)
+	ctx := context.Background()
+	item := ct.create(t, "Lamp")
+
+	for i := 0; i < 3; i++ {
+		got, err := ct.repo.GetByID(ctx, item.ID.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != "Lamp" || got.Price != item.Price {
+			t.Errorf("got %+v", got)
+		}
+		// Callers get their own copy
+		got.Name = "changed"
+	}
+
+	if n := ct.inner.lookups.Load(); n != 1 {
+		t.Errorf("database lookups = %d, want 1", n)
+	}
+	if hits, misses := ct.requests(t, cache.ResultHit), ct.requests(t, cache.ResultMiss); hits != 2 || misses != 1 {
+		t.Errorf("hits = %d, misses = %d, want 2 and 1", hits, misses)
+	}
+}
+
+func TestCachedItemRepositoryCachesMisses(t *testing.T) {
+	ct := newCacheTest(t)
+	ctx := context.Background()
+	missing := primitive.NewObjectID().Hex()
+
+	for i := 0; i < 2; i++ {
+		if _, err := ct.repo.GetByID(ctx, missing); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v, want ErrNotFound", err)
+		}
+	}
+	if n := ct.inner.lookups.Load(); n != 1 {
+		t.Errorf("database lookups = %d, want 1", n)
+	}
+	if n := ct.requests(t, cache.ResultNegativeHit); n != 1 {
+		t.Errorf("negative hits = %d, want 1", n)
+	}
+
+	if _, err := ct.repo.GetByID(ctx, "not-an-id"); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("err = %v, want ErrInvalidID", err)
+	}
+}
+
+func TestCachedItemRepositoryGetByIDs(t *testing.T) {
+	ct := newCacheTest(t)
+	ctx := context.Background()
+	lamp := ct.create(t, "Lamp")
+	desk := ct.create(t, "Desk")
+	missing := primitive.NewObjectID().Hex()
+
+	if _, err := ct.repo.GetByID(ctx, lamp.ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{lamp.ID.Hex(), desk.ID.Hex(), missing, "not-an-id"}
+	for i := 0; i < 2; i++ {
+		items, err := ct.repo.GetByIDs(ctx, ids)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 2 {
+			t.Errorf("got %d items, want lamp and desk", len(items))
+		}
+	}
+
+	// One lookup for the lamp, then one query for the desk and the missing
+	// item together; the second batch is served from the cache
+	if n := ct.inner.lookups.Load(); n != 2 {
+		t.Errorf("database lookups = %d, want 2", n)
+	}
+	if n := ct.requests(t, cache.ResultNegativeHit); n != 1 {
+		t.Errorf("negative hits = %d, want 1", n)
+	}
+}
+
+func TestCachedItemRepositoryExpires(t *testing.T) {
+	ct := newCacheTest(t)
+	ct.repo.ttl = 0
+	ct.repo.negativeTTL = time.Millisecond
+	ctx := context.Background()
+	missing := primitive.NewObjectID().Hex()
+
+	if _, err := ct.repo.GetByID(ctx, missing); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := ct.repo.GetByID(ctx, missing); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if n := ct.inner.lookups.Load(); n != 2 {
+		t.Errorf("database lookups = %d, want 2 after the negative entry expired", n)
+	}
+}
+
+func TestCachedItemRepositoryInvalidatesOnWrite(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		write func(r *CachedItemRepository, item *model.Item) error
+		check func(t *testing.T, got *model.Item, err error)
+	}{
+		{
+			name: "update",
+			write: func(r *CachedItemRepository, item *model.Item) error {
+				item.Stock = 42
+				return r.Update(ctx, item.ID.Hex(), item)
+			},
+			check: func(t *testing.T, got *model.Item, err error) {
+				if err != nil || got.Stock != 42 {
+					t.Errorf("got %+v, %v; want stock 42", got, err)
+				}
+			},
+		},
+		{
+			name: "status",
+			write: func(r *CachedItemRepository, item *model.Item) error {
+				return r.UpdateStatus(ctx, item.ID.Hex(), model.ItemStatusDraft, model.ItemStatusArchived, 1)
+			},
+			check: func(t *testing.T, got *model.Item, err error) {
+				if err != nil || got.Status != model.ItemStatusArchived {
+					t.Errorf("got %+v, %v; want archived", got, err)
+				}
+			},
+		},
+		{
+			name: "attachment",
+			write: func(r *CachedItemRepository, item *model.Item) error {
+				return r.AddAttachment(ctx, item.ID.Hex(), &model.Attachment{ID: "a1", FileName: "manual.pdf"})
+			},
+			check: func(t *testing.T, got *model.Item, err error) {
+				if err != nil || len(got.Attachments) != 1 {
+					t.Errorf("got %+v, %v; want one attachment", got, err)
+				}
+			},
+		},
+		{
+			name: "rename category",
+			write: func(r *CachedItemRepository, item *model.Item) error {
+				return r.RenameCategory(ctx, primitive.NewObjectID(), "lighting", "Lamps")
+			},
+			check: func(t *testing.T, got *model.Item, err error) {
+				if err != nil || got.Category != "Lamps" {
+					t.Errorf("got %+v, %v; want category Lamps", got, err)
+				}
+			},
+		},
+		{
+			name: "delete",
+			write: func(r *CachedItemRepository, item *model.Item) error {
+				return r.Delete(ctx, item.ID.Hex())
+			},
+			check: func(t *testing.T, got *model.Item, err error) {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("err = %v, want ErrNotFound", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := newCacheTest(t)
+			item := &model.Item{Name: "Lamp", Category: "Lighting", Status: model.ItemStatusDraft}
+			if err := ct.repo.Create(ctx, item); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ct.repo.GetByID(ctx, item.ID.Hex()); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.write(ct.repo, item); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ct.repo.GetByID(ctx, item.ID.Hex())
+			tt.check(t, got, err)
+		})
+	}
+}
+
+func TestCachedItemRepositoryInvalidatesVariants(t *testing.T) {
+	ct := newCacheTest(t)
+	ctx := context.Background()
+	parent := ct.create(t, "Shirt")
+	variant := &model.Item{Name: "Shirt M", ParentID: &parent.ID}
+	if err := ct.repo.Create(ctx, variant); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ct.repo.GetByID(ctx, variant.ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ct.repo.DeleteVariants(ctx, parent.ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ct.repo.GetByID(ctx, variant.ID.Hex()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+	if n := ct.counter(t, "item_cache_invalidations_total", ""); n < 1 {
+		t.Errorf("invalidations = %d, want at least 1", n)
+	}
+}
+
+func TestCachedItemRepositoryCollapsesConcurrentMisses(t *testing.T) {
+	ct := newCacheTest(t)
+	item := ct.create(t, "Lamp")
+	ct.inner.gate = make(chan struct{})
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ct.repo.GetByID(context.Background(), item.ID.Hex())
+			errs <- err
+		}()
+	}
+
+	// Let the callers pile up behind the first lookup before releasing it
+	time.Sleep(20 * time.Millisecond)
+	close(ct.inner.gate)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := ct.inner.lookups.Load(); n != 1 {
+		t.Errorf("database lookups = %d, want 1", n)
+	}
+}
+
+func TestCachedItemRepositoryCallerCancellation(t *testing.T) {
+	ct := newCacheTest(t)
+	item := ct.create(t, "Lamp")
+	ct.inner.gate = make(chan struct{})
+	defer close(ct.inner.gate)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := ct.repo.GetByID(ctx, item.ID.Hex()); !errors.Is(err, ErrTimeout) {
+		t.Errorf("err = %v, want ErrTimeout", err)
+	}
+}
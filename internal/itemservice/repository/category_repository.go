@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+// CategoryRepository stores the category tree. GetAll is sorted by name.
+// Lookups of missing categories return ErrNotFound and slug clashes
+// ErrDuplicateKey.
+type CategoryRepository interface {
+	Create(ctx context.Context, category *model.Category) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*model.Category, error)
+	GetBySlug(ctx context.Context, slug string) (*model.Category, error)
+	GetAll(ctx context.Context) ([]*model.Category, error)
+	GetDescendants(ctx context.Context, id primitive.ObjectID) ([]*model.Category, error)
+	CountChildren(ctx context.Context, id primitive.ObjectID) (int64, error)
+	Update(ctx context.Context, category *model.Category) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
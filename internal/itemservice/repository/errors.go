@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Errors returned by the repositories. Driver errors are wrapped so callers
+// can tell a missing record apart from a database that is slow or down.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrInvalidID    = errors.New("invalid id")
+	ErrDuplicateKey = errors.New("duplicate key")
+	ErrTimeout      = errors.New("database operation timed out")
+	ErrCanceled     = errors.New("database operation canceled")
+	ErrUnavailable  = errors.New("database unavailable")
+
+	// ErrResumeTokenExpired is returned when a change feed can no longer
+	// continue from the given position.
+	ErrResumeTokenExpired = errors.New("resume token expired or invalid")
+)
+
+// mapError translates a MongoDB driver error into one of the errors above,
+// keeping the original error in the message.
+func mapError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return fmt.Errorf("%w: %v", ErrDuplicateKey, err)
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("%w: %v", ErrCanceled, err)
+	case errors.Is(err, context.DeadlineExceeded), mongo.IsTimeout(err):
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
+	case mongo.IsNetworkError(err), errors.Is(err, mongo.ErrClientDisconnected):
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+// MemoryCategoryRepository is a CategoryRepository kept in memory, for tests
+// and local development without MongoDB.
+type MemoryCategoryRepository struct {
+	mu         sync.RWMutex
+	categories map[primitive.ObjectID][]byte
+}
+
+func NewMemoryCategoryRepository() *MemoryCategoryRepository {
+	return &MemoryCategoryRepository{
+		categories: make(map[primitive.ObjectID][]byte),
+	}
+}
+
+func (r *MemoryCategoryRepository) Create(ctx context.Context, category *model.Category) error {
+	if err := mapError(ctx.Err()); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if category.ID.IsZero() {
+		category.ID = primitive.NewObjectID()
+	}
+	if _, exists := r.categories[category.ID]; exists {
+		return ErrDuplicateKey
+	}
+	if err := r.checkSlug(category); err != nil {
+		return err
+	}
+
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = time.Now()
+	return r.put(category)
+}
+
+func (r *MemoryCategoryRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Category, error) {
+	if err := mapError(ctx.Err()); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.get(id)
+}
+
+func (r *MemoryCategoryRepository) GetBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	categories, err := r.find(ctx, func(category *model.Category) bool {
+		return category.Slug == slug
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return nil, ErrNotFound
+	}
+	return categories[0], nil
+}
+
+func (r *MemoryCategoryRepository) GetAll(ctx context.Context) ([]*model.Category, error) {
+	return r.find(ctx, func(*model.Category) bool { return true })
+}
+
+func (r *MemoryCategoryRepository) GetDescendants(ctx context.Context, id primitive.ObjectID) ([]*model.Category, error) {
+	return r.find(ctx, func(category *model.Category) bool {
+		for _, ancestor := range category.Ancestors {
+			if ancestor == id {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *MemoryCategoryRepository) CountChildren(ctx context.Context, id primitive.ObjectID) (int64, error) {
+	children, err := r.find(ctx, func(category *model.Category) bool {
+		return category.ParentID != nil && *category.ParentID == id
+	})
+	return int64(len(children)), err
+}
+
+func (r *MemoryCategoryRepository) Update(ctx context.Context, category *model.Category) error {
+	if err := mapError(ctx.Err()); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.categories[category.ID]; !exists {
+		return nil
+	}
+	if err := r.checkSlug(category); err != nil {
+		return err
+	}
+
+	category.UpdatedAt = time.Now()
+	return r.put(category)
+}
+
+func (r *MemoryCategoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if err := mapError(ctx.Err()); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.categories, id)
+	return nil
+}
+
+// find returns copies of the categories accepted by match, sorted by name.
+func (r *MemoryCategoryRepository) find(ctx context.Context, match func(*model.Category) bool) ([]*model.Category, error) {
+	if err := mapError(ctx.Err()); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var categories []*model.Category
+	for id := range r.categories {
+		category, err := r.get(id)
+		if err != nil {
+			return nil, err
+		}
+		if match(category) {
+			categories = append(categories, category)
+		}
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].Name < categories[j].Name
+	})
+	return categories, nil
+}
+
+func (r *MemoryCategoryRepository) get(id primitive.ObjectID) (*model.Category, error) {
+	data, ok := r.categories[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	var category model.Category
+	if err := bson.Unmarshal(data, &category); err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *MemoryCategoryRepository) put(category *model.Category) error {
+	data, err := bson.Marshal(category)
+	if err != nil {
+		return err
+	}
+	r.categories[category.ID] = data
+	return nil
+}
+
+// checkSlug enforces the unique slug index.
+func (r *MemoryCategoryRepository) checkSlug(category *model.Category) error {
+	for id := range r.categories {
+		if id == category.ID {
+			continue
+		}
+		existing, err := r.get(id)
+		if err != nil {
+			return err
+		}
+		if existing.Slug == category.Slug {
+			return ErrDuplicateKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,496 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+// MemoryItemRepository is an ItemRepository kept in memory, for tests and
+// local development without MongoDB. Items are stored in their BSON
+// encoding, so callers always work on copies and values round-trip the same
+// way they do through the database.
+type MemoryItemRepository struct {
+	mu          sync.RWMutex
+	items       map[primitive.ObjectID][]byte
+	order       []primitive.ObjectID
+	transitions []model.ItemTransition
+}
+
+func NewMemoryItemRepository() *MemoryItemRepository {
+	return &MemoryItemRepository{
+		items: make(map[primitive.ObjectID][]byte),
+	}
+}
+
+func (r *MemoryItemRepository) Create(ctx context.Context, item *model.Item) error {
+	if err := mapError(ctx.Err()); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if item.ID.IsZero() {
+		item.ID = primitive.NewObjectID()
+	}
+	if _, exists := r.items[item.ID]; exists {
+		return ErrDuplicateKey
+	}
+	if err := r.checkSKU(item); err != nil {
+		return err
+	}
+
+	item.CreatedAt = time.Now()
+	item.UpdatedAt = time.Now()
+	if err := r.put(item); err != nil {
+		return err
+	}
+	r.order = append(r.order, item.ID)
+	return nil
+}
+
+func (r *MemoryItemRepository) GetByID(ctx context.Context, id string) (*model.Item, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	if err := mapError(ctx.Err()); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.get(objectID)
+}
+
+func (r *MemoryItemRepository) GetByIDs(ctx context.Context, ids []string) ([]*model.Item, error) {
+	if err := mapError(ctx.Err()); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := []*model.Item{}
+	for _, objectID := range validObjectIDs(ids) {
+		item, err := r.get(objectID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *MemoryItemRepository) GetAll(ctx context.Context) ([]*model.Item, error) {
+	return r.find(ctx, func(*model.Item) bool { return true })
+}
+
+// Update replaces the stored item, like the MongoDB implementation. Updating
+// a missing item is not an error.
+func (r *MemoryItemRepository) Update(ctx context.Context, id string, item *model.Item) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	if err := mapError(ctx.Err()); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.items[objectID]; !exists {
+		return nil
+	}
+
+	item.UpdatedAt = time.Now()
+	item.ID = objectID
+	if err := r.checkSKU(item); err != nil {
+		return err
+	}
+	return r.put(item)
+}
+
+func (r *MemoryItemRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	if err := mapError(ctx.Err()); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.remove(objectID)
+	return nil
+}
+
+func (r *MemoryItemRepository) GetByCategory(ctx context.Context, category string) ([]*model.Item, error) {
+	return r.find(ctx, func(item *model.Item) bool {
+		return strings.EqualFold(item.Category, category)
+	})
+}
+
+// SearchByName matches the name against a case-insensitive regular
+// expression, as MongoDB's $regex does.
+func (r *MemoryItemRepository) SearchByName(ctx context.Context, name string) ([]*model.Item, error) {
+	pattern, err := regexp.Compile("(?i)" + name)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.find(ctx, func(item *model.Item) bool {
+		return pattern.MatchString(item.Name)
+	})
+}
+
+func (r *MemoryItemRepository) GetBySKU(ctx context.Context, sku string) (*model.Item, error) {
+	items, err := r.find(ctx, func(item *model.Item) bool {
+		return item.SKU == sku
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+	return items[0], nil
+}
+
+func (r *MemoryItemRepository) GetVariants(ctx context.Context, parentID string) ([]*model.Item, error) {
+	objectID, err := primitive.ObjectIDFromHex(parentID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	return r.find(ctx, func(item *model.Item) bool {
+		return item.ParentID != nil && *item.ParentID == objectID
+	})
+}
+
+func (r *MemoryItemRepository) DeleteVariants(ctx context.Context, parentID string) error {
+	variants, err := r.GetVariants(ctx, parentID)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, variant := range variants {
+		r.remove(variant.ID)
+	}
+	return nil
+}
+
+func (r *MemoryItemRepository) Find(ctx context.Context, filter *model.ItemFilter) ([]*model.Item, error) {
+	var parentID primitive.ObjectID
+	if filter.ParentID != "" {
+		objectID, err := primitive.ObjectIDFromHex(filter.ParentID)
+		if err != nil {
+			return nil, ErrInvalidID
+		}
+		parentID = objectID
+	}
+
+	var afterID primitive.ObjectID
+	if filter.AfterID != "" {
+		objectID, err := primitive.ObjectIDFromHex(filter.AfterID)
+		if err != nil {
+			return nil, ErrInvalidID
+		}
+		afterID = objectID
+	}
+
+	categories := filter.Categories
+	if len(categories) == 0 && filter.Category != "" {
+		categories = []string{filter.Category}
+	}
+
+	items, err := r.find(ctx, func(item *model.Item) bool {
+		if filter.AfterID != "" && bytes.Compare(item.ID[:], afterID[:]) <= 0 {
+			return false
+		}
+		if len(categories) > 0 && !containsFold(categories, item.Category) {
+			return false
+		}
+		if filter.ParentID != "" && (item.ParentID == nil || *item.ParentID != parentID) {
+			return false
+		}
+		if len(filter.Statuses) > 0 && !hasStatus(filter.Statuses, item.Status) {
+			return false
+		}
+		for name, value := range filter.Attributes {
+			if !attributeMatches(item.Attributes[name], value) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Limit > 0 && len(items) > filter.Limit {
+		items = items[:filter.Limit]
+	}
+	return items, nil
+}
+
+func (r *MemoryItemRepository) RenameCategory(ctx context.Context, categoryID primitive.ObjectID, oldName, newName string) error {
+	items, err := r.find(ctx, func(item *model.Item) bool {
+		return (item.CategoryID != nil && *item.CategoryID == categoryID) || strings.EqualFold(item.Category, oldName)
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range items {
+		item.Category = newName
+		item.CategoryID = &categoryID
+		item.UpdatedAt = time.Now()
+		if err := r.put(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryItemRepository) AddAttachment(ctx context.Context, id string, attachment *model.Attachment) error {
+	return r.modify(ctx, id, func(item *model.Item) error {
+		item.Attachments = append(item.Attachments, *attachment)
+		return nil
+	})
+}
+
+func (r *MemoryItemRepository) RemoveAttachment(ctx context.Context, id, attachmentID string) error {
+	err := r.modify(ctx, id, func(item *model.Item) error {
+		kept := item.Attachments[:0]
+		for _, attachment := range item.Attachments {
+			if attachment.ID != attachmentID {
+				kept = append(kept, attachment)
+			}
+		}
+		item.Attachments = kept
+		return nil
+	})
+	if err == ErrNotFound {
+		// $pull on a missing document matches nothing and succeeds
+		return nil
+	}
+	return err
+}
+
+func (r *MemoryItemRepository) UpdateStatus(ctx context.Context, id string, from, to model.ItemStatus, userID uint32) error {
+	return r.modify(ctx, id, func(item *model.Item) error {
+		if item.CurrentStatus() != from {
+			return ErrNotFound
+		}
+		item.Status = to
+		item.UpdatedBy = userID
+		return nil
+	})
+}
+
+func (r *MemoryItemRepository) RecordTransition(ctx context.Context, transition *model.ItemTransition) error {
+	if err := mapError(ctx.Err()); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transition.ID = primitive.NewObjectID()
+	transition.CreatedAt = time.Now()
+	r.transitions = append(r.transitions, *transition)
+	return nil
+}
+
+func (r *MemoryItemRepository) GetTransitions(ctx context.Context, id string) ([]*model.ItemTransition, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	if err := mapError(ctx.Err()); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	transitions := []*model.ItemTransition{}
+	for i := range r.transitions {
+		if r.transitions[i].ItemID == objectID {
+			transition := r.transitions[i]
+			transitions = append(transitions, &transition)
+		}
+	}
+	return transitions, nil
+}
+
+// find returns copies of the items accepted by match, in creation order.
+func (r *MemoryItemRepository) find(ctx context.Context, match func(*model.Item) bool) ([]*model.Item, error) {
+	if err := mapError(ctx.Err()); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var items []*model.Item
+	for _, id := range r.order {
+		item, err := r.get(id)
+		if err != nil {
+			return nil, err
+		}
+		if match(item) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// modify applies fn to a stored item and saves the result. Like the
+// MongoDB implementation it also bumps updated_at.
+func (r *MemoryItemRepository) modify(ctx context.Context, id string, fn func(*model.Item) error) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	if err := mapError(ctx.Err()); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, err := r.get(objectID)
+	if err != nil {
+		return err
+	}
+	if err := fn(item); err != nil {
+		return err
+	}
+	item.UpdatedAt = time.Now()
+	return r.put(item)
+}
+
+func (r *MemoryItemRepository) get(id primitive.ObjectID) (*model.Item, error) {
+	data, ok := r.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	var item model.Item
+	if err := bson.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *MemoryItemRepository) put(item *model.Item) error {
+	data, err := bson.Marshal(item)
+	if err != nil {
+		return err
+	}
+	r.items[item.ID] = data
+	return nil
+}
+
+func (r *MemoryItemRepository) remove(id primitive.ObjectID) {
+	if _, ok := r.items[id]; !ok {
+		return
+	}
+	delete(r.items, id)
+	for i, existing := range r.order {
+		if existing == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// checkSKU enforces the unique sparse SKU index.
+func (r *MemoryItemRepository) checkSKU(item *model.Item) error {
+	if item.SKU == "" {
+		return nil
+	}
+	for id := range r.items {
+		if id == item.ID {
+			continue
+		}
+		existing, err := r.get(id)
+		if err != nil {
+			return err
+		}
+		if existing.SKU == item.SKU {
+			return ErrDuplicateKey
+		}
+	}
+	return nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasStatus treats items without a status as active, like Find does.
+func hasStatus(statuses []model.ItemStatus, status model.ItemStatus) bool {
+	if status == "" {
+		status = model.ItemStatusActive
+	}
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// attributeMatches compares a stored attribute with a query string value,
+// numerically if the value looks like a number.
+func attributeMatches(stored interface{}, value string) bool {
+	if str, ok := stored.(string); ok {
+		return str == value
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false
+	}
+	switch v := stored.(type) {
+	case float64:
+		return v == number
+	case int32:
+		return float64(v) == number
+	case int64:
+		return float64(v) == number
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+type MongoCategoryRepository struct {
+	collection *mongo.Collection
+	timeouts   Timeouts
+}
+
+func NewMongoCategoryRepository(db *mongo.Database, timeouts Timeouts) *MongoCategoryRepository {
+	return &MongoCategoryRepository{
+		collection: db.Collection("categories"),
+		timeouts:   timeouts,
+	}
+}
+
+// EnsureIndexes creates the unique slug index and the ancestors index used
+// for subtree queries.
+func (r *MongoCategoryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "ancestors", Value: 1}},
+		},
+	})
+	return err
+}
+
+func (r *MongoCategoryRepository) Create(ctx context.Context, category *model.Category) error {
+	ctx, cancel := r.timeouts.context(ctx, "categories.create")
+	defer cancel()
+
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, category)
+	if err != nil {
+		return mapError(err)
+	}
+
+	category.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoCategoryRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*model.Category, error) {
+	ctx, cancel := r.timeouts.context(ctx, "categories.get_by_id")
+	defer cancel()
+
+	var category model.Category
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&category)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &category, nil
+}
+
+func (r *MongoCategoryRepository) GetBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	ctx, cancel := r.timeouts.context(ctx, "categories.get_by_slug")
+	defer cancel()
+
+	var category model.Category
+	err := r.collection.FindOne(ctx, bson.M{"slug": slug}).Decode(&category)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &category, nil
+}
+
+func (r *MongoCategoryRepository) GetAll(ctx context.Context) ([]*model.Category, error) {
+	ctx, cancel := r.timeouts.context(ctx, "categories.get_all")
+	defer cancel()
+
+	return r.findCategories(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+}
+
+// GetDescendants returns every category below the given one, at any depth.
+func (r *MongoCategoryRepository) GetDescendants(ctx context.Context, id primitive.ObjectID) ([]*model.Category, error) {
+	ctx, cancel := r.timeouts.context(ctx, "categories.get_descendants")
+	defer cancel()
+
+	return r.findCategories(ctx, bson.M{"ancestors": id})
+}
+
+func (r *MongoCategoryRepository) findCategories(ctx context.Context, query bson.M, opts ...*options.FindOptions) ([]*model.Category, error) {
+	cursor, err := r.collection.Find(ctx, query, opts...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var categories []*model.Category
+	if err = cursor.All(ctx, &categories); err != nil {
+		return nil, mapError(err)
+	}
+
+	return categories, nil
+}
+
+func (r *MongoCategoryRepository) CountChildren(ctx context.Context, id primitive.ObjectID) (int64, error) {
+	ctx, cancel := r.timeouts.context(ctx, "categories.count_children")
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"parent_id": id})
+	return count, mapError(err)
+}
+
+func (r *MongoCategoryRepository) Update(ctx context.Context, category *model.Category) error {
+	ctx, cancel := r.timeouts.context(ctx, "categories.update")
+	defer cancel()
+
+	category.UpdatedAt = time.Now()
+
+	// Replace rather than $set, so that moving to the root drops parent_id
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": category.ID}, category)
+	return mapError(err)
+}
+
+func (r *MongoCategoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := r.timeouts.context(ctx, "categories.delete")
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return mapError(err)
+}
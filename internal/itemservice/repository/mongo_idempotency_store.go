@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"rancher-manager/idempotency"
+)
+
+// MongoIdempotencyStore keeps idempotency keys in MongoDB, shared by all
+// instances. MongoDB deletes expired keys itself.
+type MongoIdempotencyStore struct {
+	collection *mongo.Collection
+	timeouts   Timeouts
+}
+
+type idempotencyDocument struct {
+	Key         string    `bson:"_id"`
+	Fingerprint string    `bson:"fingerprint"`
+	Status      int       `bson:"status"`
+	ContentType string    `bson:"content_type,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+func NewMongoIdempotencyStore(db *mongo.Database, timeouts Timeouts) *MongoIdempotencyStore {
+	return &MongoIdempotencyStore{
+		collection: db.Collection("idempotency_keys"),
+		timeouts:   timeouts,
+	}
+}
+
+// EnsureIndexes creates the TTL index that deletes expired keys.
+func (s *MongoIdempotencyStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Begin claims key. The TTL monitor only runs once a minute, so keys that
+// have expired but are still stored are replaced here.
+func (s *MongoIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*idempotency.Record, error) {
+	ctx, cancel := s.timeouts.context(ctx, "idempotency_keys.begin")
+	defer cancel()
+
+	// The held key may be released between the insert and the read, so try
+	// to claim it once more before giving up
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := s.collection.DeleteOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$lte": time.Now()}})
+		if err != nil {
+			return nil, mapError(err)
+		}
+
+		_, err = s.collection.InsertOne(ctx, idempotencyDocument{
+			Key:         key,
+			Fingerprint: fingerprint,
+			ExpiresAt:   expiresAt,
+		})
+		if err == nil {
+			return nil, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, mapError(err)
+		}
+
+		var held idempotencyDocument
+		err = s.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&held)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return nil, mapError(err)
+		}
+		return &idempotency.Record{
+			Key:         held.Key,
+			Fingerprint: held.Fingerprint,
+			Status:      held.Status,
+			ContentType: held.ContentType,
+			Body:        held.Body,
+			ExpiresAt:   held.ExpiresAt,
+		}, nil
+	}
+	return nil, errors.New("idempotency key is being claimed and released concurrently")
+}
+
+func (s *MongoIdempotencyStore) Complete(ctx context.Context, key string, status int, contentType string, body []byte, expiresAt time.Time) error {
+	ctx, cancel := s.timeouts.context(ctx, "idempotency_keys.complete")
+	defer cancel()
+
+	_, err := s.collection.UpdateOne(ctx, bson.M{"_id": key}, bson.M{"$set": bson.M{
+		"status":       status,
+		"content_type": contentType,
+		"body":         body,
+		"expires_at":   expiresAt,
+	}})
+	return mapError(err)
+}
+
+func (s *MongoIdempotencyStore) Release(ctx context.Context, key string) error {
+	ctx, cancel := s.timeouts.context(ctx, "idempotency_keys.release")
+	defer cancel()
+
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return mapError(err)
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+// Server error codes for resume tokens the oplog no longer covers or that
+// were never valid.
+const (
+	codeChangeStreamHistoryLost = 286
+	codeInvalidResumeToken      = 260
+)
+
+// feedBufferSize is how many changes a watcher may fall behind before its
+// feed is closed.
+const feedBufferSize = 256
+
+// MongoItemFeed reports item changes from a MongoDB change stream, so that
+// writes made by every ItemService instance are seen. Change streams need a
+// replica set or sharded cluster.
+type MongoItemFeed struct {
+	collection *mongo.Collection
+}
+
+// NewMongoItemFeed opens and closes a change stream on the items collection
+// to check that the deployment supports them. It also asks MongoDB to keep
+// pre-images, so that deletions can report the item's last state.
+func NewMongoItemFeed(ctx context.Context, db *mongo.Database) (*MongoItemFeed, error) {
+	collection := db.Collection("items")
+
+	stream, err := collection.Watch(ctx, mongo.Pipeline{})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	stream.Close(ctx)
+
+	// Pre-images need MongoDB 6.0; without them deletions carry no item
+	enable := bson.D{
+		{Key: "collMod", Value: "items"},
+		{Key: "changeStreamPreAndPostImages", Value: bson.D{{Key: "enabled", Value: true}}},
+	}
+	if err := db.RunCommand(ctx, enable).Err(); err != nil {
+		log.Printf("Warning: Item change stream pre-images unavailable: %v", err)
+	}
+
+	return &MongoItemFeed{collection: collection}, nil
+}
+
+// changeEvent is the part of a change stream document the feed uses.
+type changeEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument             *model.Item         `bson:"fullDocument"`
+	FullDocumentBeforeChange *model.Item         `bson:"fullDocumentBeforeChange"`
+	ClusterTime              primitive.Timestamp `bson:"clusterTime"`
+}
+
+// Watch streams item changes made after resumeToken, or from now on when it
+// is empty. The channel is closed when ctx ends, the stream fails or the
+// watcher falls behind; the returned function stops the stream.
+func (f *MongoItemFeed) Watch(ctx context.Context, resumeToken string) (<-chan model.ItemEvent, func(), error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "operationType", Value: bson.D{{Key: "$in", Value: bson.A{"insert", "update", "replace", "delete"}}}},
+		}}},
+	}
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+	if resumeToken != "" {
+		opts.SetResumeAfter(bson.D{{Key: "_data", Value: resumeToken}})
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := f.collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		cancel()
+		return nil, nil, watchError(err)
+	}
+
+	events := make(chan model.ItemEvent, feedBufferSize)
+	go func() {
+		defer close(events)
+		defer stream.Close(context.Background())
+
+		for stream.Next(ctx) {
+			var change changeEvent
+			if err := stream.Decode(&change); err != nil {
+				log.Printf("Failed to decode item change: %v", err)
+				return
+			}
+
+			event := model.ItemEvent{
+				ItemID:     change.DocumentKey.ID.Hex(),
+				Item:       change.FullDocument,
+				OccurredAt: time.Unix(int64(change.ClusterTime.T), 0),
+			}
+			event.ResumeToken, _ = stream.ResumeToken().Lookup("_data").StringValueOK()
+			switch change.OperationType {
+			case "insert":
+				event.Type = model.ItemEventCreated
+			case "delete":
+				event.Type = model.ItemEventDeleted
+				event.Item = change.FullDocumentBeforeChange
+			default:
+				event.Type = model.ItemEventUpdated
+				if event.Item == nil {
+					// Deleted again before the lookup; the delete follows
+					continue
+				}
+			}
+
+			select {
+			case events <- event:
+			default:
+				log.Printf("Item watcher fell behind, closing its change stream")
+				return
+			}
+		}
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			log.Printf("Item change stream failed: %v", err)
+		}
+	}()
+
+	return events, cancel, nil
+}
+
+// watchError reports resume tokens the server rejects as
+// ErrResumeTokenExpired.
+func watchError(err error) error {
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) &&
+		(serverErr.HasErrorCode(codeChangeStreamHistoryLost) || serverErr.HasErrorCode(codeInvalidResumeToken)) {
+		return fmt.Errorf("%w: %v", ErrResumeTokenExpired, err)
+	}
+	return mapError(err)
+}
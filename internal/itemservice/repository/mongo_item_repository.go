@@ -0,0 +1,431 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+type MongoItemRepository struct {
+	collection  *mongo.Collection
+	transitions *mongo.Collection
+	timeouts    Timeouts
+}
+
+func NewMongoItemRepository(db *mongo.Database, timeouts Timeouts) *MongoItemRepository {
+	return &MongoItemRepository{
+		collection:  db.Collection("items"),
+		transitions: db.Collection("item_transitions"),
+		timeouts:    timeouts,
+	}
+}
+
+// EnsureIndexes creates the unique SKU index, the indexes used to look up
+// variants and filter by status, and the transition history index.
+func (r *MongoItemRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "sku", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
+		{
+			Keys: bson.D{{Key: "parent_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.transitions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "item_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	return err
+}
+
+func (r *MongoItemRepository) Create(ctx context.Context, item *model.Item) error {
+	ctx, cancel := r.timeouts.context(ctx, "items.create")
+	defer cancel()
+
+	item.CreatedAt = time.Now()
+	item.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, item)
+	if err != nil {
+		return mapError(err)
+	}
+
+	item.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoItemRepository) GetByID(ctx context.Context, id string) (*model.Item, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	ctx, cancel := r.timeouts.context(ctx, "items.get_by_id")
+	defer cancel()
+
+	var item model.Item
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&item)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &item, nil
+}
+
+func (r *MongoItemRepository) GetByIDs(ctx context.Context, ids []string) ([]*model.Item, error) {
+	objectIDs := validObjectIDs(ids)
+	if len(objectIDs) == 0 {
+		return []*model.Item{}, nil
+	}
+
+	ctx, cancel := r.timeouts.context(ctx, "items.get_by_ids")
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": objectIDs}})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer cursor.Close(ctx)
+
+	items := []*model.Item{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, mapError(err)
+	}
+	return items, nil
+}
+
+// validObjectIDs parses the well-formed IDs among ids, once each.
+func validObjectIDs(ids []string) []primitive.ObjectID {
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil || seen[objectID] {
+			continue
+		}
+		seen[objectID] = true
+		objectIDs = append(objectIDs, objectID)
+	}
+	return objectIDs
+}
+
+func (r *MongoItemRepository) GetAll(ctx context.Context) ([]*model.Item, error) {
+	ctx, cancel := r.timeouts.context(ctx, "items.get_all")
+	defer cancel()
+
+	return r.findItems(ctx, bson.M{})
+}
+
+func (r *MongoItemRepository) Update(ctx context.Context, id string, item *model.Item) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	ctx, cancel := r.timeouts.context(ctx, "items.update")
+	defer cancel()
+
+	item.UpdatedAt = time.Now()
+	item.ID = objectID
+
+	// Replace rather than $set, so that fields cleared on the item (an
+	// emptied price list, a dropped category ID) are removed as well
+	_, err = r.collection.ReplaceOne(ctx, bson.M{"_id": objectID}, item)
+	return mapError(err)
+}
+
+func (r *MongoItemRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	ctx, cancel := r.timeouts.context(ctx, "items.delete")
+	defer cancel()
+
+	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	return mapError(err)
+}
+
+// GetByCategory matches the category name case-insensitively.
+func (r *MongoItemRepository) GetByCategory(ctx context.Context, category string) ([]*model.Item, error) {
+	ctx, cancel := r.timeouts.context(ctx, "items.get_by_category")
+	defer cancel()
+
+	return r.findItems(ctx, bson.M{"category": categoryPattern(category)})
+}
+
+func (r *MongoItemRepository) SearchByName(ctx context.Context, name string) ([]*model.Item, error) {
+	ctx, cancel := r.timeouts.context(ctx, "items.search")
+	defer cancel()
+
+	return r.findItems(ctx, bson.M{"name": bson.M{"$regex": name, "$options": "i"}})
+}
+
+func (r *MongoItemRepository) GetBySKU(ctx context.Context, sku string) (*model.Item, error) {
+	ctx, cancel := r.timeouts.context(ctx, "items.get_by_sku")
+	defer cancel()
+
+	var item model.Item
+	err := r.collection.FindOne(ctx, bson.M{"sku": sku}).Decode(&item)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &item, nil
+}
+
+func (r *MongoItemRepository) GetVariants(ctx context.Context, parentID string) ([]*model.Item, error) {
+	objectID, err := primitive.ObjectIDFromHex(parentID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	ctx, cancel := r.timeouts.context(ctx, "items.get_variants")
+	defer cancel()
+
+	return r.findItems(ctx, bson.M{"parent_id": objectID})
+}
+
+func (r *MongoItemRepository) DeleteVariants(ctx context.Context, parentID string) error {
+	objectID, err := primitive.ObjectIDFromHex(parentID)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	ctx, cancel := r.timeouts.context(ctx, "items.delete_variants")
+	defer cancel()
+
+	_, err = r.collection.DeleteMany(ctx, bson.M{"parent_id": objectID})
+	return mapError(err)
+}
+
+// Find returns the items matching the filter. Attribute values arrive as
+// strings from the query string, so numeric-looking values also match
+// attributes stored as numbers.
+func (r *MongoItemRepository) Find(ctx context.Context, filter *model.ItemFilter) ([]*model.Item, error) {
+	query := bson.M{}
+	if len(filter.Categories) > 0 {
+		patterns := bson.A{}
+		for _, category := range filter.Categories {
+			patterns = append(patterns, categoryPattern(category))
+		}
+		query["category"] = bson.M{"$in": patterns}
+	} else if filter.Category != "" {
+		query["category"] = categoryPattern(filter.Category)
+	}
+	if filter.ParentID != "" {
+		objectID, err := primitive.ObjectIDFromHex(filter.ParentID)
+		if err != nil {
+			return nil, ErrInvalidID
+		}
+		query["parent_id"] = objectID
+	}
+	if len(filter.Statuses) > 0 {
+		statuses := bson.A{}
+		for _, status := range filter.Statuses {
+			statuses = append(statuses, status)
+			if status == model.ItemStatusActive {
+				// Items created before statuses existed have none and count as active
+				statuses = append(statuses, nil)
+			}
+		}
+		query["status"] = bson.M{"$in": statuses}
+	}
+	for name, value := range filter.Attributes {
+		candidates := bson.A{value}
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			candidates = append(candidates, number)
+		}
+		query["attributes."+name] = bson.M{"$in": candidates}
+	}
+
+	if filter.AfterID != "" {
+		afterID, err := primitive.ObjectIDFromHex(filter.AfterID)
+		if err != nil {
+			return nil, ErrInvalidID
+		}
+		query["_id"] = bson.M{"$gt": afterID}
+	}
+
+	ctx, cancel := r.timeouts.context(ctx, "items.find")
+	defer cancel()
+
+	return r.findItems(ctx, query, int64(filter.Limit))
+}
+
+// findItems returns the matching items in creation order, at most limit of
+// them if limit is positive.
+func (r *MongoItemRepository) findItems(ctx context.Context, query bson.M, limit ...int64) ([]*model.Item, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	if len(limit) > 0 && limit[0] > 0 {
+		opts.SetLimit(limit[0])
+	}
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var items []*model.Item
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, mapError(err)
+	}
+
+	return items, nil
+}
+
+// RenameCategory points every item of the category, including legacy items
+// that only carry the old name in a different letter case, at the new name.
+func (r *MongoItemRepository) RenameCategory(ctx context.Context, categoryID primitive.ObjectID, oldName, newName string) error {
+	ctx, cancel := r.timeouts.context(ctx, "items.rename_category")
+	defer cancel()
+
+	_, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"category_id": categoryID},
+			bson.M{"category": categoryPattern(oldName)},
+		}},
+		bson.M{"$set": bson.M{"category": newName, "category_id": categoryID, "updated_at": time.Now()}},
+	)
+	return mapError(err)
+}
+
+func categoryPattern(name string) primitive.Regex {
+	return primitive.Regex{Pattern: "^" + regexp.QuoteMeta(name) + "$", Options: "i"}
+}
+
+func (r *MongoItemRepository) AddAttachment(ctx context.Context, id string, attachment *model.Attachment) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	ctx, cancel := r.timeouts.context(ctx, "items.add_attachment")
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objectID},
+		bson.M{
+			"$push": bson.M{"attachments": attachment},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return mapError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoItemRepository) RemoveAttachment(ctx context.Context, id, attachmentID string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	ctx, cancel := r.timeouts.context(ctx, "items.remove_attachment")
+	defer cancel()
+
+	_, err = r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objectID},
+		bson.M{
+			"$pull": bson.M{"attachments": bson.M{"id": attachmentID}},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	return mapError(err)
+}
+
+// UpdateStatus changes an item's status only if it is still in the expected
+// one, so concurrent transitions cannot both succeed.
+func (r *MongoItemRepository) UpdateStatus(ctx context.Context, id string, from, to model.ItemStatus, userID uint32) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	current := bson.A{from}
+	if from == model.ItemStatusActive {
+		current = append(current, nil)
+	}
+
+	ctx, cancel := r.timeouts.context(ctx, "items.update_status")
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objectID, "status": bson.M{"$in": current}},
+		bson.M{"$set": bson.M{"status": to, "updated_by": userID, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return mapError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoItemRepository) RecordTransition(ctx context.Context, transition *model.ItemTransition) error {
+	ctx, cancel := r.timeouts.context(ctx, "items.record_transition")
+	defer cancel()
+
+	transition.CreatedAt = time.Now()
+
+	result, err := r.transitions.InsertOne(ctx, transition)
+	if err != nil {
+		return mapError(err)
+	}
+
+	transition.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoItemRepository) GetTransitions(ctx context.Context, id string) ([]*model.ItemTransition, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	ctx, cancel := r.timeouts.context(ctx, "items.get_transitions")
+	defer cancel()
+
+	cursor, err := r.transitions.Find(
+		ctx,
+		bson.M{"item_id": objectID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer cursor.Close(ctx)
+
+	transitions := []*model.ItemTransition{}
+	if err = cursor.All(ctx, &transitions); err != nil {
+		return nil, mapError(err)
+	}
+
+	return transitions, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds repository operations that have no timeout of
+// their own.
+const DefaultTimeout = 5 * time.Second
+
+// Timeouts holds the deadline applied to each repository operation, keyed
+// by names such as "items.find" or "categories.get_by_slug". The deadline
+// only shortens the caller's context, never extends it.
+type Timeouts struct {
+	Default    time.Duration
+	Operations map[string]time.Duration
+}
+
+// ParseTimeouts reads per-operation overrides in the form
+// "items.find=10s,items.search=2s".
+func ParseTimeouts(defaultTimeout time.Duration, overrides string) (Timeouts, error) {
+	timeouts := Timeouts{Default: defaultTimeout, Operations: map[string]time.Duration{}}
+
+	for _, entry := range strings.Split(overrides, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		op, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return Timeouts{}, fmt.Errorf("invalid timeout override %q", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			return Timeouts{}, fmt.Errorf("invalid timeout for %s: %q", op, value)
+		}
+		timeouts.Operations[strings.TrimSpace(op)] = d
+	}
+
+	return timeouts, nil
+}
+
+// For returns the timeout of an operation.
+func (t Timeouts) For(op string) time.Duration {
+	if d, ok := t.Operations[op]; ok {
+		return d
+	}
+	if t.Default > 0 {
+		return t.Default
+	}
+	return DefaultTimeout
+}
+
+func (t Timeouts) context(ctx context.Context, op string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, t.For(op))
+}
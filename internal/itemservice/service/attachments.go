@@ -0,0 +1,184 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"rancher-manager/internal/itemservice/model"
+	"rancher-manager/internal/itemservice/storage"
+)
+
+// MaxAttachmentSize is the largest attachment accepted, in bytes.
+var MaxAttachmentSize int64 = 10 << 20
+
+// allowedAttachmentTypes maps the sniffed content types we accept to whether
+// a thumbnail is generated for them.
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"application/pdf": false,
+	"text/plain":      false,
+}
+
+// UploadAttachment validates and stores a file for an item and records it in
+// the item document. The content type is sniffed from the data rather than
+// trusted from the client.
+func (s *ItemService) UploadAttachment(ctx context.Context, itemID, fileName string, r io.Reader, userID uint32) (*model.Attachment, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	if _, err := s.itemRepo.GetByID(ctx, itemID); err != nil {
+		return nil, lookupError(err, ErrItemNotFound)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxAttachmentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %v", err)
+	}
+	if int64(len(data)) > MaxAttachmentSize {
+		return nil, fmt.Errorf("attachment too large: limit is %d bytes", MaxAttachmentSize)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("attachment is empty")
+	}
+
+	contentType := strings.SplitN(http.DetectContentType(data), ";", 2)[0]
+	thumbnail, allowed := allowedAttachmentTypes[contentType]
+	if !allowed {
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+
+	attachment := &model.Attachment{
+		ID:          primitive.NewObjectID().Hex(),
+		FileName:    filepath.Base(fileName),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		UploadedBy:  userID,
+		UploadedAt:  time.Now(),
+	}
+	attachment.Key = fmt.Sprintf("items/%s/%s", itemID, attachment.ID)
+
+	if err := s.blobStore.Put(ctx, attachment.Key, contentType, bytes.NewReader(data), attachment.Size); err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %v", err)
+	}
+
+	if thumbnail {
+		if thumb, thumbType, err := makeThumbnail(data, contentType); err != nil {
+			// Keep the upload; a corrupt or exotic image just gets no preview
+			log.Printf("Failed to generate thumbnail for attachment %s: %v", attachment.ID, err)
+		} else {
+			thumbKey := attachment.Key + "-thumb"
+			if err := s.blobStore.Put(ctx, thumbKey, thumbType, bytes.NewReader(thumb), int64(len(thumb))); err != nil {
+				log.Printf("Failed to store thumbnail for attachment %s: %v", attachment.ID, err)
+			} else {
+				attachment.ThumbnailKey = thumbKey
+				attachment.ThumbnailType = thumbType
+				attachment.HasThumbnail = true
+			}
+		}
+	}
+
+	if err := s.itemRepo.AddAttachment(ctx, itemID, attachment); err != nil {
+		s.deleteBlobs(ctx, []model.Attachment{*attachment})
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// OpenAttachment returns an attachment's metadata and a reader for its
+// content, or for its thumbnail when thumbnail is true.
+func (s *ItemService) OpenAttachment(ctx context.Context, itemID, attachmentID string, thumbnail bool, userID uint32) (*model.Attachment, io.ReadCloser, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, nil, AuthError(err)
+	}
+
+	attachment, err := s.findAttachment(ctx, itemID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := attachment.Key
+	if thumbnail {
+		if !attachment.HasThumbnail {
+			return nil, nil, errors.New("thumbnail not found")
+		}
+		key = attachment.ThumbnailKey
+	}
+
+	reader, err := s.blobStore.Get(ctx, key)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		return nil, nil, errors.New("attachment content not found")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, reader, nil
+}
+
+func (s *ItemService) DeleteAttachment(ctx context.Context, itemID, attachmentID string, userID uint32) error {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return AuthError(err)
+	}
+
+	attachment, err := s.findAttachment(ctx, itemID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.itemRepo.RemoveAttachment(ctx, itemID, attachmentID); err != nil {
+		return err
+	}
+
+	s.deleteBlobs(ctx, []model.Attachment{*attachment})
+	return nil
+}
+
+func (s *ItemService) findAttachment(ctx context.Context, itemID, attachmentID string) (*model.Attachment, error) {
+	item, err := s.itemRepo.GetByID(ctx, itemID)
+	if err != nil {
+		return nil, lookupError(err, ErrItemNotFound)
+	}
+
+	for i := range item.Attachments {
+		if item.Attachments[i].ID == attachmentID {
+			return &item.Attachments[i], nil
+		}
+	}
+	return nil, errors.New("attachment not found")
+}
+
+// deleteBlobs removes attachment content and thumbnails. Failures are only
+// logged: the metadata is already gone, so an orphaned blob is harmless.
+func (s *ItemService) deleteBlobs(ctx context.Context, attachments []model.Attachment) {
+	for _, attachment := range attachments {
+		keys := []string{attachment.Key}
+		if attachment.ThumbnailKey != "" {
+			keys = append(keys, attachment.ThumbnailKey)
+		}
+		for _, key := range keys {
+			if err := s.blobStore.Delete(ctx, key); err != nil {
+				log.Printf("Failed to delete blob %s: %v", key, err)
+			}
+		}
+	}
+}
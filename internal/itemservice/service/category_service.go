@@ -0,0 +1,494 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"rancher-manager/internal/itemservice/model"
+	"rancher-manager/internal/itemservice/repository"
+)
+
+type CategoryService struct {
+	categoryRepo repository.CategoryRepository
+	itemRepo     repository.ItemRepository
+	authClient   AuthClientInterface
+}
+
+func NewCategoryService(categoryRepo repository.CategoryRepository, itemRepo repository.ItemRepository, authClient AuthClientInterface) *CategoryService {
+	return &CategoryService{
+		categoryRepo: categoryRepo,
+		itemRepo:     itemRepo,
+		authClient:   authClient,
+	}
+}
+
+func (s *CategoryService) CreateCategory(ctx context.Context, req *model.CreateCategoryRequest, userID uint32) (*model.Category, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	if err := validateSchema(req.Attributes); err != nil {
+		return nil, err
+	}
+	if err := validatePriceBounds(req.MinPrice, req.MaxPrice); err != nil {
+		return nil, err
+	}
+
+	slug := Slugify(req.Name)
+	if slug == "" {
+		return nil, errors.New("category name must contain letters or digits")
+	}
+	if _, err := s.categoryRepo.GetBySlug(ctx, slug); err == nil {
+		return nil, errors.New("category already exists")
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	category := &model.Category{
+		Name:        strings.TrimSpace(req.Name),
+		Slug:        slug,
+		Ancestors:   []primitive.ObjectID{},
+		Description: req.Description,
+		Attributes:  req.Attributes,
+		MinPrice:    req.MinPrice,
+		MaxPrice:    req.MaxPrice,
+		CreatedBy:   userID,
+		UpdatedBy:   userID,
+	}
+
+	if req.Parent != "" {
+		parent, err := s.categoryRepo.GetBySlug(ctx, Slugify(req.Parent))
+		if err != nil {
+			return nil, lookupError(err, errors.New("parent category not found"))
+		}
+		category.ParentID = &parent.ID
+		category.Ancestors = append(append([]primitive.ObjectID{}, parent.Ancestors...), parent.ID)
+	}
+
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return nil, errors.New("category already exists")
+		}
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (s *CategoryService) GetCategory(ctx context.Context, slug string, userID uint32) (*model.Category, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	category, err := s.categoryRepo.GetBySlug(ctx, Slugify(slug))
+	if err != nil {
+		return nil, lookupError(err, ErrCategoryNotFound)
+	}
+
+	return category, nil
+}
+
+// GetCategoryTree returns the root categories with their subcategories
+// nested below them, each level sorted by name.
+func (s *CategoryService) GetCategoryTree(ctx context.Context, userID uint32) ([]*model.CategoryNode, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	categories, err := s.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[primitive.ObjectID]*model.CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &model.CategoryNode{Category: category, Children: []*model.CategoryNode{}}
+	}
+
+	roots := []*model.CategoryNode{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		parent, ok := nodes[*category.ParentID]
+		if !ok {
+			// Orphaned by a concurrent delete; surface it at the root
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	return roots, nil
+}
+
+func (s *CategoryService) UpdateCategory(ctx context.Context, slug string, req *model.UpdateCategoryRequest, userID uint32) (*model.Category, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	category, err := s.categoryRepo.GetBySlug(ctx, Slugify(slug))
+	if err != nil {
+		return nil, lookupError(err, ErrCategoryNotFound)
+	}
+
+	if req.Description != "" {
+		category.Description = req.Description
+	}
+	if req.Attributes != nil {
+		if err := validateSchema(req.Attributes); err != nil {
+			return nil, err
+		}
+		category.Attributes = req.Attributes
+	}
+	if req.MinPrice != nil {
+		category.MinPrice = req.MinPrice
+	}
+	if req.MaxPrice != nil {
+		category.MaxPrice = req.MaxPrice
+	}
+	if err := validatePriceBounds(category.MinPrice, category.MaxPrice); err != nil {
+		return nil, err
+	}
+
+	category.UpdatedBy = userID
+
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// RenameCategory changes the name and slug of a category and cascades the
+// new name to its items.
+func (s *CategoryService) RenameCategory(ctx context.Context, slug string, req *model.RenameCategoryRequest, userID uint32) (*model.Category, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	category, err := s.categoryRepo.GetBySlug(ctx, Slugify(slug))
+	if err != nil {
+		return nil, lookupError(err, ErrCategoryNotFound)
+	}
+
+	newSlug := Slugify(req.Name)
+	if newSlug == "" {
+		return nil, errors.New("category name must contain letters or digits")
+	}
+	if existing, err := s.categoryRepo.GetBySlug(ctx, newSlug); err == nil && existing.ID != category.ID {
+		return nil, errors.New("category already exists")
+	} else if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	oldName := category.Name
+	category.Name = strings.TrimSpace(req.Name)
+	category.Slug = newSlug
+	category.UpdatedBy = userID
+
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
+		return nil, err
+	}
+
+	if err := s.itemRepo.RenameCategory(ctx, category.ID, oldName, category.Name); err != nil {
+		return nil, fmt.Errorf("category renamed but items were not updated: %v", err)
+	}
+
+	return category, nil
+}
+
+// MoveCategory re-parents a category and rewrites the ancestor paths of its
+// whole subtree. Items reference categories by name, so filters that include
+// descendants pick up the new shape of the tree immediately.
+func (s *CategoryService) MoveCategory(ctx context.Context, slug string, req *model.MoveCategoryRequest, userID uint32) (*model.Category, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	category, err := s.categoryRepo.GetBySlug(ctx, Slugify(slug))
+	if err != nil {
+		return nil, lookupError(err, ErrCategoryNotFound)
+	}
+
+	var parentID *primitive.ObjectID
+	ancestors := []primitive.ObjectID{}
+	if req.Parent != "" {
+		parent, err := s.categoryRepo.GetBySlug(ctx, Slugify(req.Parent))
+		if err != nil {
+			return nil, lookupError(err, errors.New("parent category not found"))
+		}
+		if parent.ID == category.ID || containsID(parent.Ancestors, category.ID) {
+			return nil, errors.New("cannot move a category below itself")
+		}
+		parentID = &parent.ID
+		ancestors = append(append(ancestors, parent.Ancestors...), parent.ID)
+	}
+
+	descendants, err := s.categoryRepo.GetDescendants(ctx, category.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	category.ParentID = parentID
+	category.Ancestors = ancestors
+	category.UpdatedBy = userID
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
+		return nil, err
+	}
+
+	for _, descendant := range descendants {
+		// Keep the part of the path below the moved category
+		idx := indexOfID(descendant.Ancestors, category.ID)
+		path := append(append([]primitive.ObjectID{}, ancestors...), category.ID)
+		descendant.Ancestors = append(path, descendant.Ancestors[idx+1:]...)
+		if err := s.categoryRepo.Update(ctx, descendant); err != nil {
+			return nil, err
+		}
+	}
+
+	return category, nil
+}
+
+func (s *CategoryService) DeleteCategory(ctx context.Context, slug string, userID uint32) error {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return AuthError(err)
+	}
+
+	category, err := s.categoryRepo.GetBySlug(ctx, Slugify(slug))
+	if err != nil {
+		return lookupError(err, ErrCategoryNotFound)
+	}
+
+	children, err := s.categoryRepo.CountChildren(ctx, category.ID)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return errors.New("category has subcategories; move or delete them first")
+	}
+
+	return s.categoryRepo.Delete(ctx, category.ID)
+}
+
+// Slugify normalises a category name so that "Home & Garden", "home-garden"
+// and "HOME  GARDEN" all resolve to the same category.
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// effectiveCategory returns a copy of the category whose attribute schema
+// includes the attributes inherited from its ancestors. A subcategory may
+// redefine an inherited attribute by name.
+func effectiveCategory(ctx context.Context, repo repository.CategoryRepository, category *model.Category) (*model.Category, error) {
+	if len(category.Ancestors) == 0 {
+		return category, nil
+	}
+
+	effective := *category
+	effective.Attributes = nil
+	seen := make(map[string]bool)
+	add := func(attrs []model.AttributeDefinition) {
+		for _, attr := range attrs {
+			if !seen[attr.Name] {
+				seen[attr.Name] = true
+				effective.Attributes = append(effective.Attributes, attr)
+			}
+		}
+	}
+
+	add(category.Attributes)
+	for i := len(category.Ancestors) - 1; i >= 0; i-- {
+		ancestor, err := repo.GetByID(ctx, category.Ancestors[i])
+		if err != nil {
+			return nil, err
+		}
+		add(ancestor.Attributes)
+
+		// Price bounds come from the nearest category that sets any
+		if effective.MinPrice == nil && effective.MaxPrice == nil {
+			effective.MinPrice = ancestor.MinPrice
+			effective.MaxPrice = ancestor.MaxPrice
+		}
+	}
+
+	return &effective, nil
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	return indexOfID(ids, id) >= 0
+}
+
+func indexOfID(ids []primitive.ObjectID, id primitive.ObjectID) int {
+	for i, v := range ids {
+		if v == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// validateSchema checks that attribute definitions are internally consistent.
+func validateSchema(attributes []model.AttributeDefinition) error {
+	seen := make(map[string]bool)
+	for _, attr := range attributes {
+		if strings.TrimSpace(attr.Name) == "" {
+			return errors.New("invalid schema: attribute name is required")
+		}
+		if seen[attr.Name] {
+			return fmt.Errorf("invalid schema: duplicate attribute %q", attr.Name)
+		}
+		seen[attr.Name] = true
+
+		switch attr.Type {
+		case model.AttributeTypeEnum:
+			if len(attr.Options) == 0 {
+				return fmt.Errorf("invalid schema: enum attribute %q needs options", attr.Name)
+			}
+		case model.AttributeTypeNumber, model.AttributeTypeText:
+			if len(attr.Options) > 0 {
+				return fmt.Errorf("invalid schema: only enum attributes take options, %q is %s", attr.Name, attr.Type)
+			}
+		default:
+			return fmt.Errorf("invalid schema: attribute %q has unknown type %q", attr.Name, attr.Type)
+		}
+	}
+	return nil
+}
+
+// validatePriceBounds checks that a category's price bounds are valid
+// amounts in one currency and do not contradict each other.
+func validatePriceBounds(lower, upper *model.Money) error {
+	for _, bound := range []*model.Money{lower, upper} {
+		if bound == nil {
+			continue
+		}
+		if err := bound.Validate(); err != nil {
+			return fmt.Errorf("invalid price bounds: %v", err)
+		}
+	}
+	if lower != nil && upper != nil {
+		if lower.Currency != upper.Currency {
+			return errors.New("invalid price bounds: min_price and max_price must use the same currency")
+		}
+		if lower.Amount > upper.Amount {
+			return errors.New("invalid price bounds: min_price must not exceed max_price")
+		}
+	}
+	return nil
+}
+
+// validateAttributes checks item attribute values against a category schema.
+// Unknown attributes are rejected and required ones must be present.
+func validateAttributes(category *model.Category, attributes map[string]interface{}) []model.FieldError {
+	var errs []model.FieldError
+	invalid := func(name, code, format string, args ...interface{}) {
+		errs = append(errs, model.FieldError{
+			Field:   "attributes." + name,
+			Code:    code,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, name := range sortedKeys(attributes) {
+		value := attributes[name]
+		def := category.Attribute(name)
+		if def == nil {
+			invalid(name, model.ValidationUndefined, "%q is not defined for category %q", name, category.Name)
+			continue
+		}
+
+		switch def.Type {
+		case model.AttributeTypeEnum:
+			str, ok := value.(string)
+			if !ok || !contains(def.Options, str) {
+				invalid(name, model.ValidationInvalidValue, "%q must be one of [%s]", name, strings.Join(def.Options, ", "))
+			}
+		case model.AttributeTypeNumber:
+			if _, ok := toFloat(value); !ok {
+				invalid(name, model.ValidationInvalidValue, "%q must be a number", name)
+			}
+		case model.AttributeTypeText:
+			str, ok := value.(string)
+			if !ok {
+				invalid(name, model.ValidationInvalidValue, "%q must be text", name)
+			} else if def.MaxLength > 0 && len([]rune(str)) > def.MaxLength {
+				invalid(name, model.ValidationTooLong, "%q exceeds %d characters", name, def.MaxLength)
+			}
+		}
+	}
+
+	for _, def := range category.Attributes {
+		if _, ok := attributes[def.Name]; def.Required && !ok {
+			invalid(def.Name, model.ValidationRequired, "%q is required for category %q", def.Name, category.Name)
+		}
+	}
+	return errs
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"rancher-manager/internal/itemservice/repository"
+)
+
+var (
+	ErrUnauthorized     = errors.New("unauthorized: invalid user")
+	ErrItemNotFound     = errors.New("item not found")
+	ErrCategoryNotFound = errors.New("category not found")
+)
+
+// Infrastructure errors are passed through unchanged, so that a slow or
+// unreachable dependency is not reported as a missing record or user.
+var (
+	ErrTimeout     = repository.ErrTimeout
+	ErrCanceled    = repository.ErrCanceled
+	ErrUnavailable = repository.ErrUnavailable
+)
+
+// ErrResumeTokenExpired means a watch cannot continue where it left off; the
+// watcher has to start over without a resume token.
+var ErrResumeTokenExpired = repository.ErrResumeTokenExpired
+
+// IsInfrastructure reports whether err is a timeout, cancellation or outage
+// rather than a problem with the request itself.
+func IsInfrastructure(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, ErrCanceled) || errors.Is(err, ErrUnavailable)
+}
+
+// lookupError returns notFound for a failed lookup unless the lookup failed
+// for infrastructure reasons.
+func lookupError(err, notFound error) error {
+	if IsInfrastructure(err) {
+		return err
+	}
+	return notFound
+}
+
+// AuthError classifies a failed auth service call. Only an answer from the
+// auth service means the user is invalid.
+func AuthError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), status.Code(err) == codes.DeadlineExceeded:
+		return fmt.Errorf("%w: auth service: %v", ErrTimeout, err)
+	case errors.Is(err, context.Canceled), status.Code(err) == codes.Canceled:
+		return fmt.Errorf("%w: auth service: %v", ErrCanceled, err)
+	case status.Code(err) == codes.Unavailable:
+		return fmt.Errorf("%w: auth service: %v", ErrUnavailable, err)
+	}
+	return ErrUnauthorized
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+const (
+	// eventBufferSize is how many events a subscriber may fall behind
+	// before it is disconnected.
+	eventBufferSize = 256
+
+	// eventHistorySize is how many past events the bus keeps for watchers
+	// that resume after a reconnect.
+	eventHistorySize = 1024
+)
+
+// ItemFeed is a source of item changes for WatchItems. Watch returns the
+// changes made after resumeToken, or from now on when it is empty, and a
+// function that stops the feed. The channel is closed when ctx ends, the
+// feed stops or the watcher falls too far behind.
+type ItemFeed interface {
+	Watch(ctx context.Context, resumeToken string) (<-chan model.ItemEvent, func(), error)
+}
+
+// EventBus fans out item changes made through this ItemService instance to
+// in-process subscribers. It is the ItemFeed used when MongoDB change
+// streams are unavailable; it only sees writes made by this process, and its
+// resume tokens do not survive a restart. A nil *EventBus discards events.
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers map[int]chan model.ItemEvent
+	nextID      int
+
+	// epoch tells tokens of this bus apart from those of an earlier process
+	epoch   string
+	seq     uint64
+	history []model.ItemEvent
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[int]chan model.ItemEvent),
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+	}
+}
+
+// Publish delivers an event to every subscriber without blocking. A
+// subscriber whose buffer is full is dropped and its channel closed, so that
+// a slow watcher cannot hold up writes.
+func (b *EventBus) Publish(eventType model.ItemEventType, itemID string, item *model.Item) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event := model.ItemEvent{
+		Type:        eventType,
+		ItemID:      itemID,
+		Item:        item,
+		OccurredAt:  time.Now(),
+		ResumeToken: b.epoch + "." + strconv.FormatUint(b.seq, 10),
+	}
+
+	b.history = append(b.history, event)
+	if len(b.history) > eventHistorySize {
+		b.history = b.history[len(b.history)-eventHistorySize:]
+	}
+
+	for id, ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, id)
+			close(ch)
+		}
+	}
+}
+
+// Watch subscribes to published events. With a resume token, the events
+// published after it that are still in the history are delivered first.
+func (b *EventBus) Watch(ctx context.Context, resumeToken string) (<-chan model.ItemEvent, func(), error) {
+	b.mu.Lock()
+
+	replay, err := b.replay(resumeToken)
+	if err != nil {
+		b.mu.Unlock()
+		return nil, nil, err
+	}
+
+	ch := make(chan model.ItemEvent, eventBufferSize+len(replay))
+	for _, event := range replay {
+		ch <- event
+	}
+
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = ch
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subscribers[id]; ok {
+				delete(b.subscribers, id)
+				close(ch)
+			}
+		})
+	}
+	stop := context.AfterFunc(ctx, cancel)
+
+	return ch, func() {
+		stop()
+		cancel()
+	}, nil
+}
+
+// replay returns the events after resumeToken. It must be called with b.mu
+// held.
+func (b *EventBus) replay(resumeToken string) ([]model.ItemEvent, error) {
+	if resumeToken == "" {
+		return nil, nil
+	}
+
+	epoch, seqText, _ := strings.Cut(resumeToken, ".")
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil || epoch != b.epoch || seq > b.seq {
+		return nil, fmt.Errorf("%w: %q was not issued by this server", ErrResumeTokenExpired, resumeToken)
+	}
+
+	// history holds the events numbered oldest..b.seq
+	oldest := b.seq - uint64(len(b.history)) + 1
+	if seq+1 < oldest {
+		return nil, fmt.Errorf("%w: events after %q are no longer kept", ErrResumeTokenExpired, resumeToken)
+	}
+
+	missed := b.history[len(b.history)-int(b.seq-seq):]
+	return append([]model.ItemEvent(nil), missed...), nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+// ExchangeRates is a locally loaded table of rates relative to a base
+// currency. Rates are kept as exact rationals so conversions do not pick up
+// float rounding errors.
+type ExchangeRates struct {
+	base  string
+	rates map[string]*big.Rat
+}
+
+// exchangeRatesFile is the on-disk format, e.g.
+//
+//	{"base": "USD", "rates": {"EUR": "0.9214", "JPY": "151.32"}}
+//
+// where each rate is the number of units of that currency per base unit.
+type exchangeRatesFile struct {
+	Base  string                 `json:"base"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+func NewExchangeRates(base string, rates map[string]string) (*ExchangeRates, error) {
+	table := &ExchangeRates{
+		base:  strings.ToUpper(base),
+		rates: map[string]*big.Rat{strings.ToUpper(base): big.NewRat(1, 1)},
+	}
+
+	for currency, rate := range rates {
+		r, ok := new(big.Rat).SetString(rate)
+		if !ok || r.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid exchange rate %q for %s", rate, currency)
+		}
+		table.rates[strings.ToUpper(currency)] = r
+	}
+
+	return table, nil
+}
+
+// LoadExchangeRates reads an exchange rate table from a JSON file.
+func LoadExchangeRates(path string) (*ExchangeRates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file exchangeRatesFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rates: %v", err)
+	}
+	if file.Base == "" {
+		return nil, fmt.Errorf("exchange rates file has no base currency")
+	}
+
+	rates := make(map[string]string, len(file.Rates))
+	for currency, rate := range file.Rates {
+		rates[currency] = rate.String()
+	}
+
+	return NewExchangeRates(file.Base, rates)
+}
+
+// Rate returns how many units of "to" one unit of "from" buys.
+func (e *ExchangeRates) Rate(from, to string) (*big.Rat, error) {
+	fromRate, ok := e.rates[strings.ToUpper(from)]
+	if !ok {
+		return nil, fmt.Errorf("no exchange rate for %s", from)
+	}
+	toRate, ok := e.rates[strings.ToUpper(to)]
+	if !ok {
+		return nil, fmt.Errorf("no exchange rate for %s", to)
+	}
+
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
+
+// Convert converts an amount into another currency, rounding half away from
+// zero to the target currency's minor unit.
+func (e *ExchangeRates) Convert(amount model.Money, to string) (model.Money, *big.Rat, error) {
+	to = strings.ToUpper(to)
+	if amount.Currency == to {
+		return amount, big.NewRat(1, 1), nil
+	}
+
+	exp, ok := model.CurrencyExponent(to)
+	if !ok {
+		return model.Money{}, nil, fmt.Errorf("unsupported currency %q", to)
+	}
+
+	rate, err := e.Rate(amount.Currency, to)
+	if err != nil {
+		return model.Money{}, nil, err
+	}
+
+	major := new(big.Rat).Mul(amount.Rat(), rate)
+	minor := major.Mul(major, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)))
+	rounded, err := model.RoundRat(minor)
+	if err != nil {
+		return model.Money{}, nil, err
+	}
+
+	return model.Money{Amount: rounded, Currency: to}, rate, nil
+}
@@ -1,76 +1,341 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"rancher-manager/internal/itemservice/model"
 	"rancher-manager/internal/itemservice/repository"
+	"rancher-manager/internal/itemservice/storage"
 )
 
 type AuthClientInterface interface {
-	GetUser(userID uint32) (interface{}, error)
-	ValidateToken(token string) (interface{}, error)
+	GetUser(ctx context.Context, userID uint32) (interface{}, error)
+	ValidateToken(ctx context.Context, token string) (interface{}, error)
 }
 
 type ItemService struct {
-	itemRepo   *repository.ItemRepository
-	authClient AuthClientInterface
+	itemRepo      repository.ItemRepository
+	categoryRepo  repository.CategoryRepository
+	authClient    AuthClientInterface
+	exchangeRates *ExchangeRates
+	blobStore     storage.BlobStore
+	events        *EventBus
+	feed          ItemFeed
 }
 
-func NewItemService(itemRepo *repository.ItemRepository, authClient AuthClientInterface) *ItemService {
+// NewItemService creates the item service. Writes are published on events;
+// WatchItems reads from feed, or from events when feed is nil.
+func NewItemService(
+	itemRepo repository.ItemRepository,
+	categoryRepo repository.CategoryRepository,
+	authClient AuthClientInterface,
+	exchangeRates *ExchangeRates,
+	blobStore storage.BlobStore,
+	events *EventBus,
+	feed ItemFeed,
+) *ItemService {
+	if feed == nil && events != nil {
+		feed = events
+	}
+
 	return &ItemService{
-		itemRepo:   itemRepo,
-		authClient: authClient,
+		itemRepo:      itemRepo,
+		categoryRepo:  categoryRepo,
+		authClient:    authClient,
+		exchangeRates: exchangeRates,
+		blobStore:     blobStore,
+		events:        events,
+		feed:          feed,
 	}
 }
 
-func (s *ItemService) CreateItem(req *model.CreateItemRequest, userID uint32) (*model.Item, error) {
+func (s *ItemService) CreateItem(ctx context.Context, req *model.CreateItemRequest, userID uint32) (*model.Item, error) {
 	// Validate user exists via gRPC
-	_, err := s.authClient.GetUser(userID)
+	_, err := s.authClient.GetUser(ctx, userID)
 	if err != nil {
-		return nil, errors.New("unauthorized: invalid user")
+		return nil, AuthError(err)
+	}
+
+	category, err := s.resolveCategory(ctx, req.Category)
+	if err != nil {
+		return nil, err
 	}
 
 	item := &model.Item{
-		Name:        req.Name,
-		Description: req.Description,
+		SKU:         req.SKU,
+		Name:        strings.TrimSpace(req.Name),
+		Description: SanitizeDescription(req.Description),
+		Status:      model.ItemStatusDraft,
 		Price:       req.Price,
+		PriceList:   req.PriceList,
 		Category:    req.Category,
+		Attributes:  req.Attributes,
 		Stock:       req.Stock,
 		CreatedBy:   userID,
 		UpdatedBy:   userID,
 	}
+	applyCategory(item, category)
 
-	if err := s.itemRepo.Create(item); err != nil {
+	if err := s.validateItem(ctx, item, category, nil); err != nil {
+		return nil, err
+	}
+	if err := s.checkSKU(ctx, item.SKU, ""); err != nil {
 		return nil, err
 	}
 
+	if err := s.itemRepo.Create(ctx, item); err != nil {
+		return nil, skuError(err, item.SKU)
+	}
+
+	s.events.Publish(model.ItemEventCreated, item.ID.Hex(), item)
 	return item, nil
 }
 
-func (s *ItemService) GetItem(id string, userID uint32) (*model.Item, error) {
+// CreateVariant adds a variant under an existing parent item. The variant
+// inherits the parent's category and description; its attributes are merged
+// over the parent's before being validated against the category schema.
+func (s *ItemService) CreateVariant(ctx context.Context, parentID string, req *model.CreateVariantRequest, userID uint32) (*model.Item, error) {
 	// Validate user exists via gRPC
-	_, err := s.authClient.GetUser(userID)
+	_, err := s.authClient.GetUser(ctx, userID)
 	if err != nil {
-		return nil, errors.New("unauthorized: invalid user")
+		return nil, AuthError(err)
 	}
 
-	item, err := s.itemRepo.GetByID(id)
+	parent, err := s.itemRepo.GetByID(ctx, parentID)
 	if err != nil {
-		return nil, errors.New("item not found")
+		return nil, lookupError(err, ErrItemNotFound)
+	}
+	if parent.IsVariant() {
+		return nil, errors.New("variants cannot have variants of their own")
+	}
+
+	category, err := s.resolveCategory(ctx, parent.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = parent.Name
+	}
+
+	variant := &model.Item{
+		ParentID:    &parent.ID,
+		SKU:         req.SKU,
+		Name:        name,
+		Description: parent.Description,
+		Status:      model.ItemStatusDraft,
+		Price:       req.Price,
+		PriceList:   req.PriceList,
+		Category:    parent.Category,
+		CategoryID:  parent.CategoryID,
+		Attributes:  mergeAttributes(parent.Attributes, req.Attributes),
+		Stock:       req.Stock,
+		CreatedBy:   userID,
+		UpdatedBy:   userID,
+	}
+
+	if err := s.validateItem(ctx, variant, category, nil); err != nil {
+		return nil, err
+	}
+	if err := s.checkSKU(ctx, variant.SKU, ""); err != nil {
+		return nil, err
+	}
+
+	if err := s.itemRepo.Create(ctx, variant); err != nil {
+		return nil, skuError(err, variant.SKU)
+	}
+
+	s.events.Publish(model.ItemEventCreated, variant.ID.Hex(), variant)
+	return variant, nil
+}
+
+func (s *ItemService) GetVariants(ctx context.Context, parentID string, userID uint32) ([]*model.Item, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	if _, err := s.itemRepo.GetByID(ctx, parentID); err != nil {
+		return nil, lookupError(err, ErrItemNotFound)
+	}
+
+	return s.itemRepo.GetVariants(ctx, parentID)
+}
+
+func (s *ItemService) GetItem(ctx context.Context, id string, userID uint32) (*model.Item, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	item, err := s.itemRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, lookupError(err, ErrItemNotFound)
 	}
 
 	return item, nil
 }
 
-func (s *ItemService) GetAllItems(userID uint32) ([]*model.Item, error) {
+// GetItems looks up several items at once. IDs that are malformed or do not
+// exist are returned as missing instead of failing the whole lookup.
+func (s *ItemService) GetItems(ctx context.Context, ids []string, userID uint32) ([]*model.Item, []string, error) {
 	// Validate user exists via gRPC
-	_, err := s.authClient.GetUser(userID)
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, nil, AuthError(err)
+	}
+
+	found, err := s.itemRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[primitive.ObjectID]*model.Item, len(found))
+	for _, item := range found {
+		byID[item.ID] = item
+	}
+
+	items := make([]*model.Item, 0, len(ids))
+	var missing []string
+	for _, id := range ids {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		item, ok := byID[objectID]
+		if err != nil || !ok {
+			missing = append(missing, id)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	return items, missing, nil
+}
+
+// WatchItems streams the item changes matching the filter. The returned
+// function ends the watch; the channel is closed when the watch ends, ctx is
+// done or the feed gives up on a watcher that fell too far behind.
+func (s *ItemService) WatchItems(ctx context.Context, filter *model.WatchFilter, userID uint32) (<-chan model.ItemEvent, func(), error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, nil, AuthError(err)
+	}
+
+	if s.feed == nil {
+		return nil, nil, errors.New("item events are not available")
+	}
+
+	match, err := s.watchMatcher(ctx, filter)
 	if err != nil {
-		return nil, errors.New("unauthorized: invalid user")
+		return nil, nil, err
 	}
 
-	items, err := s.itemRepo.GetAll()
+	ctx, cancel := context.WithCancel(ctx)
+	source, stop, err := s.feed.Watch(ctx, filter.ResumeToken)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	events := make(chan model.ItemEvent)
+	go func() {
+		defer close(events)
+		defer stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-source:
+				if !ok {
+					return
+				}
+				if !match.matches(event) {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, cancel, nil
+}
+
+// watchMatcher decides which events a watcher receives.
+type watchMatcher struct {
+	itemIDs    map[string]bool
+	categories map[string]bool
+
+	// inCategory holds the items last seen in one of the categories, so
+	// that the watcher also learns when they leave it or are deleted.
+	inCategory map[string]bool
+}
+
+func (s *ItemService) watchMatcher(ctx context.Context, filter *model.WatchFilter) (*watchMatcher, error) {
+	match := &watchMatcher{}
+
+	if len(filter.ItemIDs) > 0 {
+		match.itemIDs = make(map[string]bool, len(filter.ItemIDs))
+		for _, id := range filter.ItemIDs {
+			match.itemIDs[id] = true
+		}
+	}
+
+	if filter.Category != "" {
+		categoryFilter := &model.ItemFilter{
+			Category:           filter.Category,
+			IncludeDescendants: filter.IncludeDescendants,
+		}
+		if err := s.expandCategoryFilter(ctx, categoryFilter); err != nil {
+			return nil, err
+		}
+		match.categories = make(map[string]bool, len(categoryFilter.Categories))
+		for _, name := range categoryFilter.Categories {
+			match.categories[name] = true
+		}
+		match.inCategory = make(map[string]bool)
+	}
+
+	return match, nil
+}
+
+func (m *watchMatcher) matches(event model.ItemEvent) bool {
+	if m.itemIDs != nil && !m.itemIDs[event.ItemID] {
+		return false
+	}
+	if m.categories == nil {
+		return true
+	}
+
+	wasIn := m.inCategory[event.ItemID]
+	isIn := event.Item != nil && m.categories[event.Item.Category]
+	if isIn && event.Type != model.ItemEventDeleted {
+		m.inCategory[event.ItemID] = true
+	} else {
+		delete(m.inCategory, event.ItemID)
+	}
+	return isIn || wasIn
+}
+
+func (s *ItemService) GetAllItems(ctx context.Context, userID uint32) ([]*model.Item, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	items, err := s.itemRepo.GetAll(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -78,69 +343,165 @@ func (s *ItemService) GetAllItems(userID uint32) ([]*model.Item, error) {
 	return items, nil
 }
 
-func (s *ItemService) UpdateItem(id string, req *model.UpdateItemRequest, userID uint32) (*model.Item, error) {
+// ListItems returns the items matching the filter. When the filter names a
+// registered category it may include all of its subcategories, and attribute
+// filters must refer to attributes defined for it. Without a status filter
+// only active items are listed.
+func (s *ItemService) ListItems(ctx context.Context, filter *model.ItemFilter, userID uint32) ([]*model.Item, error) {
 	// Validate user exists via gRPC
-	_, err := s.authClient.GetUser(userID)
+	_, err := s.authClient.GetUser(ctx, userID)
 	if err != nil {
-		return nil, errors.New("unauthorized: invalid user")
+		return nil, AuthError(err)
+	}
+
+	if filter.Statuses == nil {
+		filter.Statuses = []model.ItemStatus{model.ItemStatusActive}
+	}
+
+	if filter.Category != "" {
+		if err := s.expandCategoryFilter(ctx, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.itemRepo.Find(ctx, filter)
+}
+
+func (s *ItemService) UpdateItem(ctx context.Context, id string, req *model.UpdateItemRequest, userID uint32) (*model.Item, error) {
+	return s.PatchItem(ctx, id, req.Patch(), userID)
+}
+
+// PatchItem applies the fields set in the patch to an item.
+func (s *ItemService) PatchItem(ctx context.Context, id string, patch *model.ItemPatch, userID uint32) (*model.Item, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
 	}
 
 	// Get existing item
-	existingItem, err := s.itemRepo.GetByID(id)
+	existingItem, err := s.itemRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("item not found")
+		return nil, lookupError(err, ErrItemNotFound)
+	}
+	if existingItem.CurrentStatus() == model.ItemStatusArchived {
+		return nil, errors.New("item is archived and can no longer be changed")
 	}
 
 	// Update fields if provided
-	if req.Name != "" {
-		existingItem.Name = req.Name
+	if patch.Name != nil {
+		existingItem.Name = strings.TrimSpace(*patch.Name)
+	}
+	if patch.SKU != nil {
+		existingItem.SKU = *patch.SKU
 	}
-	if req.Description != "" {
-		existingItem.Description = req.Description
+	if patch.Description != nil {
+		existingItem.Description = SanitizeDescription(*patch.Description)
 	}
-	if req.Price > 0 {
-		existingItem.Price = req.Price
+	if patch.Price != nil {
+		existingItem.Price = *patch.Price
 	}
-	if req.Category != "" {
-		existingItem.Category = req.Category
+	if patch.PriceList != nil {
+		existingItem.PriceList = *patch.PriceList
 	}
-	if req.Stock >= 0 {
-		existingItem.Stock = req.Stock
+	if patch.Category != nil {
+		if existingItem.IsVariant() && Slugify(*patch.Category) != Slugify(existingItem.Category) {
+			return nil, errors.New("variants inherit their category from the parent item")
+		}
+		existingItem.Category = *patch.Category
+	}
+	if patch.Attributes != nil {
+		existingItem.Attributes = *patch.Attributes
+	}
+	if patch.Stock != nil {
+		existingItem.Stock = *patch.Stock
 	}
 
-	existingItem.UpdatedBy = userID
+	category, err := s.resolveCategory(ctx, existingItem.Category)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Category != nil {
+		applyCategory(existingItem, category)
+	}
 
-	if err := s.itemRepo.Update(id, existingItem); err != nil {
+	if err := s.validateItem(ctx, existingItem, category, patchedFields(patch)); err != nil {
 		return nil, err
 	}
+	if patch.SKU != nil {
+		if err := s.checkSKU(ctx, existingItem.SKU, id); err != nil {
+			return nil, err
+		}
+	}
 
+	existingItem.UpdatedBy = userID
+
+	if err := s.itemRepo.Update(ctx, id, existingItem); err != nil {
+		return nil, skuError(err, existingItem.SKU)
+	}
+
+	s.events.Publish(model.ItemEventUpdated, existingItem.ID.Hex(), existingItem)
 	return existingItem, nil
 }
 
-func (s *ItemService) DeleteItem(id string, userID uint32) error {
+func (s *ItemService) DeleteItem(ctx context.Context, id string, userID uint32) error {
 	// Validate user exists via gRPC
-	_, err := s.authClient.GetUser(userID)
+	_, err := s.authClient.GetUser(ctx, userID)
 	if err != nil {
-		return errors.New("unauthorized: invalid user")
+		return AuthError(err)
 	}
 
 	// Check if item exists
-	_, err = s.itemRepo.GetByID(id)
+	item, err := s.itemRepo.GetByID(ctx, id)
 	if err != nil {
-		return errors.New("item not found")
+		return lookupError(err, ErrItemNotFound)
+	}
+
+	attachments := item.Attachments
+	var deletedVariants []*model.Item
+
+	// Variants cannot outlive their parent
+	if !item.IsVariant() {
+		variants, err := s.itemRepo.GetVariants(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, variant := range variants {
+			attachments = append(attachments, variant.Attachments...)
+			deletedVariants = append(deletedVariants, variant)
+		}
+		if err := s.itemRepo.DeleteVariants(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	if err := s.itemRepo.Delete(ctx, id); err != nil {
+		return err
 	}
 
-	return s.itemRepo.Delete(id)
+	for _, variant := range deletedVariants {
+		s.events.Publish(model.ItemEventDeleted, variant.ID.Hex(), variant)
+	}
+	s.events.Publish(model.ItemEventDeleted, id, item)
+	s.deleteBlobs(ctx, attachments)
+	return nil
 }
 
-func (s *ItemService) GetItemsByCategory(category string, userID uint32) ([]*model.Item, error) {
+func (s *ItemService) GetItemsByCategory(ctx context.Context, category string, includeDescendants bool, userID uint32) ([]*model.Item, error) {
+	return s.ListItems(ctx, &model.ItemFilter{
+		Category:           category,
+		IncludeDescendants: includeDescendants,
+	}, userID)
+}
+
+func (s *ItemService) SearchItems(ctx context.Context, name string, userID uint32) ([]*model.Item, error) {
 	// Validate user exists via gRPC
-	_, err := s.authClient.GetUser(userID)
+	_, err := s.authClient.GetUser(ctx, userID)
 	if err != nil {
-		return nil, errors.New("unauthorized: invalid user")
+		return nil, AuthError(err)
 	}
 
-	items, err := s.itemRepo.GetByCategory(category)
+	items, err := s.itemRepo.SearchByName(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -148,21 +509,158 @@ func (s *ItemService) GetItemsByCategory(category string, userID uint32) ([]*mod
 	return items, nil
 }
 
-func (s *ItemService) SearchItems(name string, userID uint32) ([]*model.Item, error) {
+// GetPrice resolves the price of an item in a currency and market: an active
+// price list entry if there is one, otherwise the base price converted with
+// the local exchange rate table.
+func (s *ItemService) GetPrice(ctx context.Context, id, currency, market string, userID uint32) (*model.PriceQuote, error) {
 	// Validate user exists via gRPC
-	_, err := s.authClient.GetUser(userID)
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	item, err := s.itemRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("unauthorized: invalid user")
+		return nil, lookupError(err, ErrItemNotFound)
+	}
+
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = item.Price.Currency
+	}
+	if _, ok := model.CurrencyExponent(currency); !ok {
+		return nil, fmt.Errorf("unsupported currency %q", currency)
 	}
 
-	items, err := s.itemRepo.SearchByName(name)
+	if entry := item.PriceListEntryFor(currency, market, time.Now()); entry != nil {
+		return &model.PriceQuote{ItemID: id, Price: entry.Price, Source: "price_list"}, nil
+	}
+	if item.Price.Currency == currency {
+		return &model.PriceQuote{ItemID: id, Price: item.Price, Source: "base"}, nil
+	}
+
+	if s.exchangeRates == nil {
+		return nil, fmt.Errorf("no price in %s and no exchange rates loaded", currency)
+	}
+	converted, rate, err := s.exchangeRates.Convert(item.Price, currency)
 	if err != nil {
 		return nil, err
 	}
 
-	return items, nil
+	return &model.PriceQuote{
+		ItemID: id,
+		Price:  converted,
+		Source: "converted",
+		Rate:   rate.FloatString(6),
+	}, nil
 }
 
 func (s *ItemService) GetAuthClient() AuthClientInterface {
 	return s.authClient
 }
+
+// resolveCategory looks up the registered category for a name (by slug). It
+// returns nil for free-text categories without a registered schema.
+func (s *ItemService) resolveCategory(ctx context.Context, categoryName string) (*model.Category, error) {
+	category, err := s.categoryRepo.GetBySlug(ctx, Slugify(categoryName))
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return category, nil
+}
+
+// expandCategoryFilter resolves the filter's category to its canonical name
+// and, if requested, the names of all of its subcategories.
+func (s *ItemService) expandCategoryFilter(ctx context.Context, filter *model.ItemFilter) error {
+	category, err := s.categoryRepo.GetBySlug(ctx, Slugify(filter.Category))
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		// Unregistered free-text category: match the name as given
+		filter.Categories = []string{filter.Category}
+		return nil
+	}
+
+	filter.Categories = []string{category.Name}
+	if filter.IncludeDescendants {
+		descendants, err := s.categoryRepo.GetDescendants(ctx, category.ID)
+		if err != nil {
+			return err
+		}
+		for _, descendant := range descendants {
+			filter.Categories = append(filter.Categories, descendant.Name)
+		}
+	}
+
+	if len(filter.Attributes) > 0 {
+		effective, err := effectiveCategory(ctx, s.categoryRepo, category)
+		if err != nil {
+			return err
+		}
+		for name := range filter.Attributes {
+			if effective.Attribute(name) == nil {
+				return fmt.Errorf("invalid filter: %q is not defined for category %q", name, category.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyCategory stores the canonical name and ID of a registered category on
+// the item, so that differently-cased spellings collapse into one category.
+func applyCategory(item *model.Item, category *model.Category) {
+	if category == nil {
+		item.CategoryID = nil
+		return
+	}
+	item.Category = category.Name
+	item.CategoryID = &category.ID
+}
+
+// checkSKU rejects a SKU that is already used by an item other than excludeID.
+func (s *ItemService) checkSKU(ctx context.Context, sku, excludeID string) error {
+	if sku == "" {
+		return nil
+	}
+
+	existing, err := s.itemRepo.GetBySKU(ctx, sku)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.ID.Hex() != excludeID {
+		return fmt.Errorf("sku %q already exists", sku)
+	}
+	return nil
+}
+
+// skuError reports a write rejected by the unique SKU index, which catches
+// duplicates that slip past checkSKU under concurrent writes.
+func skuError(err error, sku string) error {
+	if errors.Is(err, repository.ErrDuplicateKey) {
+		return fmt.Errorf("sku %q already exists", sku)
+	}
+	return err
+}
+
+func mergeAttributes(base, overrides map[string]interface{}) map[string]interface{} {
+	if len(base) == 0 && len(overrides) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]interface{}, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
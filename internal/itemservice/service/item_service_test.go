@@ -0,0 +1,518 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"rancher-manager/internal/itemservice/model"
+	"rancher-manager/internal/itemservice/repository"
+	"rancher-manager/internal/itemservice/storage"
+)
+
+const (
+	testUser     uint32 = 1
+	testApprover uint32 = 2
+)
+
+// fakeAuthClient knows a fixed set of users by role and answers like the
+// auth service would, including for canceled contexts.
+type fakeAuthClient struct {
+	roles map[uint32]string
+	err   error
+}
+
+func newFakeAuthClient() *fakeAuthClient {
+	return &fakeAuthClient{roles: map[uint32]string{testUser: "user", testApprover: "admin"}}
+}
+
+func (f *fakeAuthClient) GetUser(ctx context.Context, userID uint32) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	role, ok := f.roles[userID]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return map[string]interface{}{"user_id": userID, "role": role}, nil
+}
+
+func (f *fakeAuthClient) ValidateToken(ctx context.Context, token string) (interface{}, error) {
+	return map[string]interface{}{"valid": false}, nil
+}
+
+type testEnv struct {
+	items      *ItemService
+	categories *CategoryService
+	auth       *fakeAuthClient
+	itemRepo   *repository.MemoryItemRepository
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates, err := NewExchangeRates("USD", map[string]string{"EUR": "0.9", "JPY": "150"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := newFakeAuthClient()
+	itemRepo := repository.NewMemoryItemRepository()
+	categoryRepo := repository.NewMemoryCategoryRepository()
+
+	return &testEnv{
+		items:      NewItemService(itemRepo, categoryRepo, auth, rates, blobStore, NewEventBus(), nil),
+		categories: NewCategoryService(categoryRepo, itemRepo, auth),
+		auth:       auth,
+		itemRepo:   itemRepo,
+	}
+}
+
+func (e *testEnv) createItem(t *testing.T, req *model.CreateItemRequest) *model.Item {
+	t.Helper()
+
+	if req.Price.Currency == "" {
+		req.Price = model.Money{Amount: 1000, Currency: "USD"}
+	}
+	item, err := e.items.CreateItem(context.Background(), req, testUser)
+	if err != nil {
+		t.Fatalf("CreateItem(%s): %v", req.Name, err)
+	}
+	return item
+}
+
+// activate walks a draft item through review to active.
+func (e *testEnv) activate(t *testing.T, id string) {
+	t.Helper()
+
+	ctx := context.Background()
+	steps := []struct {
+		status model.ItemStatus
+		user   uint32
+	}{
+		{model.ItemStatusPendingReview, testUser},
+		{model.ItemStatusActive, testApprover},
+	}
+	for _, step := range steps {
+		if _, err := e.items.TransitionItem(ctx, id, &model.TransitionItemRequest{Status: step.status}, step.user); err != nil {
+			t.Fatalf("transition to %s: %v", step.status, err)
+		}
+	}
+}
+
+func itemNames(items []*model.Item) string {
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestCreateItemStartsAsDraft(t *testing.T) {
+	env := newTestEnv(t)
+
+	item := env.createItem(t, &model.CreateItemRequest{Name: "Lamp", SKU: "LAMP-1", Stock: 3})
+	if item.Status != model.ItemStatusDraft {
+		t.Errorf("status = %q, want draft", item.Status)
+	}
+
+	stored, err := env.items.GetItem(context.Background(), item.ID.Hex(), testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "Lamp" || stored.SKU != "LAMP-1" || stored.Stock != 3 || stored.CreatedBy != testUser {
+		t.Errorf("stored item = %+v", stored)
+	}
+}
+
+func TestCreateItemRejectsDuplicateSKU(t *testing.T) {
+	env := newTestEnv(t)
+	env.createItem(t, &model.CreateItemRequest{Name: "Lamp", SKU: "LAMP-1"})
+
+	_, err := env.items.CreateItem(context.Background(), &model.CreateItemRequest{
+		Name:  "Other lamp",
+		SKU:   "LAMP-1",
+		Price: model.Money{Amount: 500, Currency: "USD"},
+	}, testUser)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("err = %v, want duplicate SKU error", err)
+	}
+}
+
+func TestServiceErrors(t *testing.T) {
+	env := newTestEnv(t)
+	item := env.createItem(t, &model.CreateItemRequest{Name: "Lamp"})
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		id      string
+		user    uint32
+		authErr error
+		want    error
+	}{
+		{name: "unknown user", ctx: context.Background(), id: item.ID.Hex(), user: 99, want: ErrUnauthorized},
+		{name: "missing item", ctx: context.Background(), id: "64b7f0a1c2d3e4f5a6b7c8d9", user: testUser, want: ErrItemNotFound},
+		{name: "malformed id", ctx: context.Background(), id: "not-an-id", user: testUser, want: ErrItemNotFound},
+		{name: "canceled request", ctx: canceled, id: item.ID.Hex(), user: testUser, want: ErrCanceled},
+		{
+			name:    "auth service timeout",
+			ctx:     context.Background(),
+			id:      item.ID.Hex(),
+			user:    testUser,
+			authErr: status.Error(codes.DeadlineExceeded, "context deadline exceeded"),
+			want:    ErrTimeout,
+		},
+		{
+			name:    "auth service down",
+			ctx:     context.Background(),
+			id:      item.ID.Hex(),
+			user:    testUser,
+			authErr: status.Error(codes.Unavailable, "connection refused"),
+			want:    ErrUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env.auth.err = tt.authErr
+			defer func() { env.auth.err = nil }()
+
+			_, err := env.items.GetItem(tt.ctx, tt.id, tt.user)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryCancellationIsNotReportedAsNotFound(t *testing.T) {
+	env := newTestEnv(t)
+	item := env.createItem(t, &model.CreateItemRequest{Name: "Lamp"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := env.itemRepo.GetByID(ctx, item.ID.Hex())
+	if !errors.Is(err, repository.ErrCanceled) {
+		t.Fatalf("err = %v, want ErrCanceled", err)
+	}
+	if lookupError(err, ErrItemNotFound) == ErrItemNotFound {
+		t.Error("cancellation was reported as not found")
+	}
+}
+
+func TestListItemsDefaultsToActive(t *testing.T) {
+	env := newTestEnv(t)
+	active := env.createItem(t, &model.CreateItemRequest{Name: "Active"})
+	env.createItem(t, &model.CreateItemRequest{Name: "Draft"})
+	env.activate(t, active.ID.Hex())
+
+	tests := []struct {
+		name     string
+		statuses []model.ItemStatus
+		want     string
+	}{
+		{name: "default", statuses: nil, want: "Active"},
+		{name: "drafts", statuses: []model.ItemStatus{model.ItemStatusDraft}, want: "Draft"},
+		{name: "all", statuses: []model.ItemStatus{}, want: "Active,Draft"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := env.items.ListItems(context.Background(), &model.ItemFilter{Statuses: tt.statuses}, testUser)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := itemNames(items); got != tt.want {
+				t.Errorf("items = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListItemsByCategoryTree(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	if _, err := env.categories.CreateCategory(ctx, &model.CreateCategoryRequest{
+		Name: "Clothing",
+		Attributes: []model.AttributeDefinition{
+			{Name: "size", Type: model.AttributeTypeEnum, Options: []string{"S", "M", "L"}},
+		},
+	}, testUser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.categories.CreateCategory(ctx, &model.CreateCategoryRequest{Name: "Shirts", Parent: "clothing"}, testUser); err != nil {
+		t.Fatal(err)
+	}
+
+	items := []*model.CreateItemRequest{
+		{Name: "Coat", Category: "clothing", Attributes: map[string]interface{}{"size": "L"}},
+		{Name: "Tee", Category: "SHIRTS", Attributes: map[string]interface{}{"size": "M"}},
+		{Name: "Polo", Category: "Shirts", Attributes: map[string]interface{}{"size": "L"}},
+		{Name: "Mug", Category: "Kitchen"},
+	}
+	for _, req := range items {
+		item := env.createItem(t, req)
+		env.activate(t, item.ID.Hex())
+	}
+
+	tests := []struct {
+		name   string
+		filter model.ItemFilter
+		want   string
+	}{
+		{name: "category only", filter: model.ItemFilter{Category: "Clothing"}, want: "Coat"},
+		{name: "with subcategories", filter: model.ItemFilter{Category: "clothing", IncludeDescendants: true}, want: "Coat,Tee,Polo"},
+		{
+			name:   "inherited attribute",
+			filter: model.ItemFilter{Category: "clothing", IncludeDescendants: true, Attributes: map[string]string{"size": "L"}},
+			want:   "Coat,Polo",
+		},
+		{name: "free-text category", filter: model.ItemFilter{Category: "kitchen"}, want: "Mug"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			got, err := env.items.ListItems(ctx, &filter, testUser)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if names := itemNames(got); names != tt.want {
+				t.Errorf("items = %s, want %s", names, tt.want)
+			}
+		})
+	}
+
+	_, err := env.items.ListItems(ctx, &model.ItemFilter{Category: "clothing", Attributes: map[string]string{"color": "red"}}, testUser)
+	if err == nil || !strings.Contains(err.Error(), "invalid filter") {
+		t.Errorf("err = %v, want invalid filter", err)
+	}
+}
+
+func TestCreateItemValidatesAttributes(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	if _, err := env.categories.CreateCategory(ctx, &model.CreateCategoryRequest{
+		Name: "Cables",
+		Attributes: []model.AttributeDefinition{
+			{Name: "length", Type: model.AttributeTypeNumber, Required: true, Unit: "m"},
+		},
+	}, testUser); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		attributes map[string]interface{}
+		wantErr    string
+	}{
+		{name: "valid", attributes: map[string]interface{}{"length": 2.5}},
+		{name: "missing required", attributes: map[string]interface{}{}, wantErr: "is required"},
+		{name: "wrong type", attributes: map[string]interface{}{"length": "long"}, wantErr: "must be a number"},
+		{name: "unknown", attributes: map[string]interface{}{"length": 1.0, "color": "red"}, wantErr: "is not defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.items.CreateItem(ctx, &model.CreateItemRequest{
+				Name:       "Cable",
+				Category:   "cables",
+				Attributes: tt.attributes,
+				Price:      model.Money{Amount: 100, Currency: "USD"},
+			}, testUser)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateItem(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	item := env.createItem(t, &model.CreateItemRequest{
+		Name: "Lamp",
+		PriceList: []model.PriceListEntry{
+			{Price: model.Money{Amount: 900, Currency: "EUR"}},
+		},
+	})
+
+	price := model.Money{Amount: 1500, Currency: "USD"}
+	updated, err := env.items.UpdateItem(ctx, item.ID.Hex(), &model.UpdateItemRequest{
+		Name:      "Desk lamp",
+		Price:     &price,
+		PriceList: []model.PriceListEntry{},
+		Stock:     7,
+	}, testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "Desk lamp" || updated.Price != price || updated.Stock != 7 {
+		t.Errorf("updated = %+v", updated)
+	}
+
+	stored, err := env.items.GetItem(ctx, item.ID.Hex(), testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.PriceList) != 0 {
+		t.Errorf("price list = %+v, want it cleared", stored.PriceList)
+	}
+
+	if _, err := env.items.TransitionItem(ctx, item.ID.Hex(), &model.TransitionItemRequest{Status: model.ItemStatusArchived}, testUser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.items.UpdateItem(ctx, item.ID.Hex(), &model.UpdateItemRequest{Name: "Again", Stock: -1}, testUser); err == nil || !strings.Contains(err.Error(), "archived") {
+		t.Errorf("err = %v, want archived item to be rejected", err)
+	}
+}
+
+func TestDeleteItemRemovesVariants(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	parent := env.createItem(t, &model.CreateItemRequest{Name: "Shirt", SKU: "SHIRT"})
+	variant, err := env.items.CreateVariant(ctx, parent.ID.Hex(), &model.CreateVariantRequest{
+		SKU:   "SHIRT-M",
+		Price: model.Money{Amount: 1200, Currency: "USD"},
+	}, testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if variant.Name != "Shirt" || variant.ParentID == nil || *variant.ParentID != parent.ID {
+		t.Errorf("variant = %+v", variant)
+	}
+
+	if err := env.items.DeleteItem(ctx, parent.ID.Hex(), testUser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.items.GetItem(ctx, variant.ID.Hex(), testUser); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("variant lookup err = %v, want ErrItemNotFound", err)
+	}
+}
+
+func TestGetItemsReportsMissing(t *testing.T) {
+	env := newTestEnv(t)
+	first := env.createItem(t, &model.CreateItemRequest{Name: "First"})
+	second := env.createItem(t, &model.CreateItemRequest{Name: "Second"})
+	unknown := primitive.NewObjectID().Hex()
+
+	items, missing, err := env.items.GetItems(context.Background(), []string{second.ID.Hex(), unknown, first.ID.Hex(), "bogus"}, testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := itemNames(items); got != "Second,First" {
+		t.Errorf("items = %s, want Second,First", got)
+	}
+	if len(missing) != 2 || missing[0] != unknown || missing[1] != "bogus" {
+		t.Errorf("missing = %v", missing)
+	}
+}
+
+func TestWatchItemsReceivesChanges(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	events, cancel, err := env.items.WatchItems(ctx, &model.WatchFilter{}, testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	item := env.createItem(t, &model.CreateItemRequest{Name: "Lamp"})
+	if _, err := env.items.UpdateItem(ctx, item.ID.Hex(), &model.UpdateItemRequest{Name: "Desk lamp", Stock: -1}, testUser); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.items.DeleteItem(ctx, item.ID.Hex(), testUser); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []model.ItemEventType{model.ItemEventCreated, model.ItemEventUpdated, model.ItemEventDeleted}
+	for _, wantType := range want {
+		event := <-events
+		if event.Type != wantType || event.ItemID != item.ID.Hex() {
+			t.Errorf("event = %s %s, want %s %s", event.Type, event.ItemID, wantType, item.ID.Hex())
+		}
+	}
+}
+
+func TestTransitionItem(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	item := env.createItem(t, &model.CreateItemRequest{Name: "Lamp"})
+	id := item.ID.Hex()
+
+	if _, err := env.items.TransitionItem(ctx, id, &model.TransitionItemRequest{Status: model.ItemStatusActive}, testApprover); err == nil || !strings.Contains(err.Error(), "invalid transition") {
+		t.Errorf("draft -> active err = %v, want invalid transition", err)
+	}
+	if _, err := env.items.TransitionItem(ctx, id, &model.TransitionItemRequest{Status: model.ItemStatusPendingReview}, testUser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.items.TransitionItem(ctx, id, &model.TransitionItemRequest{Status: model.ItemStatusActive}, testUser); err == nil || !strings.Contains(err.Error(), "forbidden") {
+		t.Errorf("approval by plain user err = %v, want forbidden", err)
+	}
+	if _, err := env.items.TransitionItem(ctx, id, &model.TransitionItemRequest{Status: model.ItemStatusActive, Reason: "looks good"}, testApprover); err != nil {
+		t.Fatal(err)
+	}
+
+	transitions, err := env.items.GetTransitions(ctx, id, testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transitions) != 2 || transitions[1].To != model.ItemStatusActive || transitions[1].Reason != "looks good" {
+		t.Errorf("transitions = %+v", transitions)
+	}
+}
+
+func TestGetPrice(t *testing.T) {
+	env := newTestEnv(t)
+	item := env.createItem(t, &model.CreateItemRequest{
+		Name:  "Lamp",
+		Price: model.Money{Amount: 1999, Currency: "USD"},
+		PriceList: []model.PriceListEntry{
+			{Market: "DE", Price: model.Money{Amount: 1799, Currency: "EUR"}},
+		},
+	})
+
+	tests := []struct {
+		currency, market string
+		want             model.Money
+		source           string
+	}{
+		{currency: "", want: model.Money{Amount: 1999, Currency: "USD"}, source: "base"},
+		{currency: "eur", market: "DE", want: model.Money{Amount: 1799, Currency: "EUR"}, source: "price_list"},
+		{currency: "EUR", market: "FR", want: model.Money{Amount: 1799, Currency: "EUR"}, source: "converted"},
+		{currency: "JPY", want: model.Money{Amount: 2999, Currency: "JPY"}, source: "converted"},
+	}
+
+	for _, tt := range tests {
+		quote, err := env.items.GetPrice(context.Background(), item.ID.Hex(), tt.currency, tt.market, testUser)
+		if err != nil {
+			t.Fatalf("GetPrice(%s, %s): %v", tt.currency, tt.market, err)
+		}
+		if quote.Price != tt.want || quote.Source != tt.source {
+			t.Errorf("GetPrice(%s, %s) = %v (%s), want %v (%s)", tt.currency, tt.market, quote.Price, quote.Source, tt.want, tt.source)
+		}
+	}
+}
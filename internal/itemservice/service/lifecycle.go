@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+// itemTransitions lists the statuses each status may move to.
+var itemTransitions = map[model.ItemStatus][]model.ItemStatus{
+	model.ItemStatusDraft:         {model.ItemStatusPendingReview, model.ItemStatusArchived},
+	model.ItemStatusPendingReview: {model.ItemStatusActive, model.ItemStatusDraft},
+	model.ItemStatusActive:        {model.ItemStatusDiscontinued},
+	model.ItemStatusDiscontinued:  {model.ItemStatusActive, model.ItemStatusArchived},
+	model.ItemStatusArchived:      {},
+}
+
+// approvalTransitions need a user with one of the ApproverRoles: approving
+// or rejecting a review, and bringing a discontinued item back.
+var approvalTransitions = map[[2]model.ItemStatus]bool{
+	{model.ItemStatusPendingReview, model.ItemStatusActive}: true,
+	{model.ItemStatusPendingReview, model.ItemStatusDraft}:  true,
+	{model.ItemStatusDiscontinued, model.ItemStatusActive}:  true,
+}
+
+// ApproverRoles are the auth roles allowed to approve item transitions.
+var ApproverRoles = map[string]bool{
+	"admin":   true,
+	"manager": true,
+}
+
+// TransitionItem moves an item to another lifecycle status if the
+// transition is allowed and the user may perform it, and records the event.
+func (s *ItemService) TransitionItem(ctx context.Context, id string, req *model.TransitionItemRequest, userID uint32) (*model.Item, error) {
+	// Validate user exists via gRPC
+	user, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	item, err := s.itemRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, lookupError(err, ErrItemNotFound)
+	}
+
+	from := item.CurrentStatus()
+	to := req.Status
+	if _, known := itemTransitions[to]; !known {
+		return nil, fmt.Errorf("unknown status %q", to)
+	}
+	if !canTransition(from, to) {
+		return nil, fmt.Errorf("invalid transition from %s to %s", from, to)
+	}
+	if approvalTransitions[[2]model.ItemStatus{from, to}] && !ApproverRoles[userRole(user)] {
+		return nil, fmt.Errorf("forbidden: moving an item from %s to %s requires approval rights", from, to)
+	}
+
+	if err := s.itemRepo.UpdateStatus(ctx, id, from, to, userID); err != nil {
+		if IsInfrastructure(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid transition from %s to %s: item status changed concurrently", from, to)
+	}
+
+	transition := &model.ItemTransition{
+		ItemID: item.ID,
+		From:   from,
+		To:     to,
+		Reason: req.Reason,
+		UserID: userID,
+	}
+	if err := s.itemRepo.RecordTransition(ctx, transition); err != nil {
+		return nil, fmt.Errorf("status changed but transition was not recorded: %v", err)
+	}
+
+	item.Status = to
+	item.UpdatedBy = userID
+	s.events.Publish(model.ItemEventUpdated, item.ID.Hex(), item)
+	return item, nil
+}
+
+func (s *ItemService) GetTransitions(ctx context.Context, id string, userID uint32) ([]*model.ItemTransition, error) {
+	// Validate user exists via gRPC
+	_, err := s.authClient.GetUser(ctx, userID)
+	if err != nil {
+		return nil, AuthError(err)
+	}
+
+	if _, err := s.itemRepo.GetByID(ctx, id); err != nil {
+		return nil, lookupError(err, ErrItemNotFound)
+	}
+
+	return s.itemRepo.GetTransitions(ctx, id)
+}
+
+func canTransition(from, to model.ItemStatus) bool {
+	for _, allowed := range itemTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// userRole extracts the role from an AuthClient.GetUser result.
+func userRole(user interface{}) string {
+	if fields, ok := user.(map[string]interface{}); ok {
+		if role, ok := fields["role"].(string); ok {
+			return role
+		}
+	}
+	return ""
+}
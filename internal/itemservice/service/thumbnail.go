@@ -0,0 +1,142 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// thumbnailSize is the bounding box thumbnails are scaled into.
+	thumbnailSize = 256
+	// maxThumbnailPixels caps the images thumbnails are made of. Decoding
+	// allocates memory for every pixel an image declares, however small
+	// the file.
+	maxThumbnailPixels = 40 << 20
+	// maxThumbnailFrames caps the frames of GIFs thumbnails are made of.
+	maxThumbnailFrames = 1000
+)
+
+// errImageTooLarge is returned for images too large to make a thumbnail of.
+var errImageTooLarge = errors.New("image too large for a thumbnail")
+
+// makeThumbnail scales an image down to fit within thumbnailSize, keeping
+// the aspect ratio. PNG and GIF sources produce PNG thumbnails (to keep
+// transparency); everything else becomes JPEG. Images above
+// maxThumbnailPixels or maxThumbnailFrames fail with errImageTooLarge
+// before they are decoded.
+func makeThumbnail(data []byte, contentType string) ([]byte, string, error) {
+	decodeConfig, decode := jpeg.DecodeConfig, jpeg.Decode
+	switch contentType {
+	case "image/png":
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	case "image/gif":
+		decodeConfig, decode = gif.DecodeConfig, gif.Decode
+	}
+
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(config.Width)*int64(config.Height) > maxThumbnailPixels {
+		return nil, "", fmt.Errorf("%w: %dx%d pixels", errImageTooLarge, config.Width, config.Height)
+	}
+	if contentType == "image/gif" {
+		if frames := gifFrames(data, maxThumbnailFrames); frames > maxThumbnailFrames {
+			return nil, "", fmt.Errorf("%w: more than %d frames", errImageTooLarge, maxThumbnailFrames)
+		}
+	}
+
+	src, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > thumbnailSize || height > thumbnailSize {
+		if width >= height {
+			height = max(1, height*thumbnailSize/width)
+			width = thumbnailSize
+		} else {
+			width = max(1, width*thumbnailSize/height)
+			height = thumbnailSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if contentType == "image/png" || contentType == "image/gif" {
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// gifFrames counts the frames of a GIF by walking its blocks without
+// decoding them, stopping once there are more than limit. Malformed data
+// ends the count; decoding reports it.
+func gifFrames(data []byte, limit int) int {
+	const (
+		extension       = 0x21
+		imageDescriptor = 0x2c
+		colorTableFlag  = 0x80
+	)
+
+	// The header and logical screen descriptor, then the global color table
+	pos := 13
+	if len(data) < pos {
+		return 0
+	}
+	if flags := data[10]; flags&colorTableFlag != 0 {
+		pos += 3 << (flags&7 + 1)
+	}
+
+	frames := 0
+	for pos < len(data) && frames <= limit {
+		switch data[pos] {
+		case extension:
+			// The introducer and label
+			pos += 2
+		case imageDescriptor:
+			frames++
+			if pos+10 > len(data) {
+				return frames
+			}
+			flags := data[pos+9]
+			pos += 10
+			if flags&colorTableFlag != 0 {
+				pos += 3 << (flags&7 + 1)
+			}
+			// The LZW minimum code size
+			pos++
+		default:
+			// The trailer, or data that is not a GIF block
+			return frames
+		}
+
+		// Skip the data sub-blocks, ended by an empty one
+		for pos < len(data) {
+			size := int(data[pos])
+			pos += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+	}
+	return frames
+}
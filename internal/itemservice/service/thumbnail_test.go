@@ -0,0 +1,84 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+// hugePNG returns a small PNG whose header declares width x height pixels.
+func hugePNG(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// The IHDR chunk follows the 8-byte signature; its data starts with the
+	// width and height and is followed by its CRC
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func animatedGIF(t *testing.T, frames int) []byte {
+	t.Helper()
+
+	animation := &gif.GIF{}
+	palette := color.Palette{color.Black, color.White}
+	for i := 0; i < frames; i++ {
+		animation.Image = append(animation.Image, image.NewPaletted(image.Rect(0, 0, 2, 2), palette))
+		animation.Delay = append(animation.Delay, 1)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, animation); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadAttachmentSkipsThumbnailOfHugeImage(t *testing.T) {
+	env := newTestEnv(t)
+	item := env.createItem(t, &model.CreateItemRequest{Name: "Lamp", SKU: "LAMP-1"})
+
+	data := hugePNG(t, 50000, 50000)
+	attachment, err := env.items.UploadAttachment(context.Background(), item.ID.Hex(), "bomb.png", bytes.NewReader(data), testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attachment.ContentType != "image/png" || attachment.HasThumbnail {
+		t.Errorf("attachment = %+v, want a png without a thumbnail", attachment)
+	}
+}
+
+func TestMakeThumbnailRefusesHugeImages(t *testing.T) {
+	if _, _, err := makeThumbnail(hugePNG(t, 50000, 50000), "image/png"); !errors.Is(err, errImageTooLarge) {
+		t.Errorf("huge png: err = %v, want errImageTooLarge", err)
+	}
+	if _, _, err := makeThumbnail(animatedGIF(t, maxThumbnailFrames+1), "image/gif"); !errors.Is(err, errImageTooLarge) {
+		t.Errorf("gif with too many frames: err = %v, want errImageTooLarge", err)
+	}
+
+	thumb, thumbType, err := makeThumbnail(animatedGIF(t, 3), "image/gif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumbType != "image/png" || len(thumb) == 0 {
+		t.Errorf("thumbnail of an animated gif = %d bytes of %s, want a png", len(thumb), thumbType)
+	}
+	if frames := gifFrames(animatedGIF(t, 3), maxThumbnailFrames); frames != 3 {
+		t.Errorf("gifFrames = %d, want 3", frames)
+	}
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+// Limits enforced on every item write, whether it comes in over REST, gRPC
+// or Kafka.
+const (
+	MaxNameLength        = 200
+	MaxDescriptionLength = 5000
+)
+
+// skuPattern allows letters, digits and ., _ and - separators.
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// nameSymbols are the punctuation characters allowed in item names besides
+// letters, digits and spaces.
+const nameSymbols = "-_.,:;!?&'’\"()/+#%*@°"
+
+// itemCheck is an item being validated together with what the rules need to
+// know about it.
+type itemCheck struct {
+	item *model.Item
+	// category is the item's registered category with inherited attributes
+	// and price bounds, or nil for a free-text category.
+	category *model.Category
+	rates    *ExchangeRates
+}
+
+// itemRule is one declarative validation rule. Check returns the problems
+// it finds, keyed by field path relative to Field: "" for Field itself,
+// "[1].valid_to" for a nested field. A nil map means the item passes.
+type itemRule struct {
+	Field string
+	Code  string
+	Check func(c *itemCheck) map[string]string
+}
+
+// itemRules apply to every item write. Rules on the same field are checked
+// in order and only the first failing one is reported.
+var itemRules = []itemRule{
+	{Field: "name", Code: model.ValidationRequired, Check: func(c *itemCheck) map[string]string {
+		return violation(strings.TrimSpace(c.item.Name) == "", "is required")
+	}},
+	{Field: "name", Code: model.ValidationTooLong, Check: func(c *itemCheck) map[string]string {
+		return violation(utf8.RuneCountInString(c.item.Name) > MaxNameLength, fmt.Sprintf("must be at most %d characters", MaxNameLength))
+	}},
+	{Field: "name", Code: model.ValidationInvalidCharacters, Check: func(c *itemCheck) map[string]string {
+		return violation(strings.IndexFunc(c.item.Name, invalidNameRune) >= 0, "may only contain letters, digits, spaces and "+nameSymbols)
+	}},
+	{Field: "sku", Code: model.ValidationRequired, Check: func(c *itemCheck) map[string]string {
+		return violation(c.item.IsVariant() && c.item.SKU == "", "is required for variants")
+	}},
+	{Field: "sku", Code: model.ValidationInvalidFormat, Check: func(c *itemCheck) map[string]string {
+		return violation(c.item.SKU != "" && !skuPattern.MatchString(c.item.SKU), "must be 1-64 letters, digits, '.', '_' or '-' and start with a letter or digit")
+	}},
+	{Field: "description", Code: model.ValidationTooLong, Check: func(c *itemCheck) map[string]string {
+		return violation(utf8.RuneCountInString(c.item.Description) > MaxDescriptionLength, fmt.Sprintf("must be at most %d characters", MaxDescriptionLength))
+	}},
+	{Field: "price", Code: model.ValidationRequired, Check: func(c *itemCheck) map[string]string {
+		return violation(c.item.Price == model.Money{}, "is required")
+	}},
+	{Field: "price", Code: model.ValidationInvalidCurrency, Check: func(c *itemCheck) map[string]string {
+		_, ok := model.CurrencyExponent(c.item.Price.Currency)
+		return violation(!ok, fmt.Sprintf("unsupported currency %q", c.item.Price.Currency))
+	}},
+	{Field: "price", Code: model.ValidationOutOfRange, Check: func(c *itemCheck) map[string]string {
+		return violation(c.item.Price.Amount < 0, "amount must not be negative")
+	}},
+	{Field: "price", Code: model.ValidationOutOfRange, Check: func(c *itemCheck) map[string]string {
+		message := c.priceBoundsViolation(c.item.Price)
+		return violation(message != "", message)
+	}},
+	{Field: "price_list", Code: model.ValidationInvalidCurrency, Check: func(c *itemCheck) map[string]string {
+		return eachPriceListEntry(c, func(entry model.PriceListEntry) (string, string) {
+			if _, ok := model.CurrencyExponent(entry.Price.Currency); !ok {
+				return ".price", fmt.Sprintf("unsupported currency %q", entry.Price.Currency)
+			}
+			return "", ""
+		})
+	}},
+	{Field: "price_list", Code: model.ValidationOutOfRange, Check: func(c *itemCheck) map[string]string {
+		return eachPriceListEntry(c, func(entry model.PriceListEntry) (string, string) {
+			if entry.Price.Amount < 0 {
+				return ".price", "amount must not be negative"
+			}
+			if message := c.priceBoundsViolation(entry.Price); message != "" {
+				return ".price", message
+			}
+			if entry.ValidFrom != nil && entry.ValidTo != nil && !entry.ValidTo.After(*entry.ValidFrom) {
+				return ".valid_to", "must be after valid_from"
+			}
+			return "", ""
+		})
+	}},
+	{Field: "stock", Code: model.ValidationOutOfRange, Check: func(c *itemCheck) map[string]string {
+		return violation(c.item.Stock < 0, "must not be negative")
+	}},
+	{Field: "attributes", Code: model.ValidationUndefined, Check: func(c *itemCheck) map[string]string {
+		// Free-text categories have no schema to check attributes against
+		return violation(c.category == nil && len(c.item.Attributes) > 0,
+			fmt.Sprintf("category %q has no attribute schema", c.item.Category))
+	}},
+}
+
+// violation reports message for the rule's field when failed is true.
+func violation(failed bool, message string) map[string]string {
+	if !failed || message == "" {
+		return nil
+	}
+	return map[string]string{"": message}
+}
+
+// eachPriceListEntry applies check to every price list entry and reports
+// its findings under the entry's index.
+func eachPriceListEntry(c *itemCheck, check func(entry model.PriceListEntry) (string, string)) map[string]string {
+	var problems map[string]string
+	for i, entry := range c.item.PriceList {
+		if field, message := check(entry); message != "" {
+			if problems == nil {
+				problems = make(map[string]string)
+			}
+			problems[fmt.Sprintf("[%d]%s", i, field)] = message
+		}
+	}
+	return problems
+}
+
+func invalidNameRune(r rune) bool {
+	return !(unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || r == ' ' || strings.ContainsRune(nameSymbols, r))
+}
+
+// priceBoundsViolation checks a price against the category's bounds,
+// converting it into the bounds' currency if needed. It returns "" when the
+// price is within bounds.
+func (c *itemCheck) priceBoundsViolation(price model.Money) string {
+	if c.category == nil || (c.category.MinPrice == nil && c.category.MaxPrice == nil) {
+		return ""
+	}
+	if _, ok := model.CurrencyExponent(price.Currency); !ok {
+		return ""
+	}
+
+	boundsCurrency := c.category.MaxPrice
+	if boundsCurrency == nil {
+		boundsCurrency = c.category.MinPrice
+	}
+
+	compared := price
+	if price.Currency != boundsCurrency.Currency {
+		var converted model.Money
+		err := fmt.Errorf("no exchange rates configured")
+		if c.rates != nil {
+			converted, _, err = c.rates.Convert(price, boundsCurrency.Currency)
+		}
+		if err != nil {
+			return fmt.Sprintf("cannot be compared with the price bounds of category %q in %s", c.category.Name, boundsCurrency.Currency)
+		}
+		compared = converted
+	}
+
+	if lower := c.category.MinPrice; lower != nil && compared.Amount < lower.Amount {
+		return fmt.Sprintf("must be at least %s in category %q", lower, c.category.Name)
+	}
+	if upper := c.category.MaxPrice; upper != nil && compared.Amount > upper.Amount {
+		return fmt.Sprintf("must be at most %s in category %q", upper, c.category.Name)
+	}
+	return ""
+}
+
+// validateItem runs the validation rules and the category's attribute
+// schema against an item about to be saved. When fields is non-nil, only
+// problems with those top-level fields are reported, so that updating one
+// field of an item is not blocked by rules that changed since the rest was
+// written. The returned error is a *model.ValidationError unless looking up
+// the category failed.
+func (s *ItemService) validateItem(ctx context.Context, item *model.Item, category *model.Category, fields map[string]bool) error {
+	check := &itemCheck{item: item, rates: s.exchangeRates}
+	if category != nil {
+		effective, err := effectiveCategory(ctx, s.categoryRepo, category)
+		if err != nil {
+			return err
+		}
+		check.category = effective
+	}
+
+	var errs []model.FieldError
+	failed := make(map[string]bool)
+	for _, rule := range itemRules {
+		if failed[rule.Field] || (fields != nil && !fields[rule.Field]) {
+			continue
+		}
+		problems := rule.Check(check)
+		for _, suffix := range sortedProblemKeys(problems) {
+			errs = append(errs, model.FieldError{
+				Field:   rule.Field + suffix,
+				Code:    rule.Code,
+				Message: problems[suffix],
+			})
+		}
+		if len(problems) > 0 {
+			failed[rule.Field] = true
+		}
+	}
+
+	if check.category != nil && (fields == nil || fields["attributes"]) {
+		errs = append(errs, validateAttributes(check.category, item.Attributes)...)
+	}
+
+	if len(errs) > 0 {
+		return &model.ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+func sortedProblemKeys(problems map[string]string) []string {
+	keys := make([]string, 0, len(problems))
+	for key := range problems {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// patchedFields lists the item fields a patch may affect. Changing the
+// category also re-checks attributes and prices against the new category.
+func patchedFields(patch *model.ItemPatch) map[string]bool {
+	fields := map[string]bool{
+		"name":        patch.Name != nil,
+		"sku":         patch.SKU != nil,
+		"description": patch.Description != nil,
+		"price":       patch.Price != nil || patch.Category != nil,
+		"price_list":  patch.PriceList != nil || patch.Category != nil,
+		"attributes":  patch.Attributes != nil || patch.Category != nil,
+		"stock":       patch.Stock != nil,
+	}
+	return fields
+}
+
+// unsafeElements are dropped from descriptions together with their content.
+var unsafeElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true,
+	"embed": true, "noscript": true, "template": true, "svg": true,
+}
+
+// SanitizeDescription reduces a description to plain text so that clients
+// rendering it as HTML cannot be made to run scripts. Tags are removed,
+// scripts, styles and embedded content are dropped along with their text,
+// and line breaks stand in for block elements. Entities are kept as written.
+func SanitizeDescription(description string) string {
+	if !strings.ContainsAny(description, "<>") {
+		return strings.TrimSpace(stripControlCharacters(description))
+	}
+
+	var b strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(description))
+	skipDepth := 0
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(stripControlCharacters(b.String()))
+		case html.TextToken:
+			if skipDepth == 0 {
+				// Raw keeps entities encoded, so "&lt;script&gt;" stays inert
+				text := string(tokenizer.Raw())
+				b.WriteString(strings.NewReplacer("<", "", ">", "").Replace(text))
+			}
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			if unsafeElements[string(name)] {
+				skipDepth++
+			} else if string(name) == "br" && skipDepth == 0 {
+				b.WriteString("\n")
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch {
+			case unsafeElements[string(name)]:
+				if skipDepth > 0 {
+					skipDepth--
+				}
+			case skipDepth == 0 && isBlockElement(string(name)):
+				b.WriteString("\n")
+			}
+		case html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			if string(name) == "br" && skipDepth == 0 {
+				b.WriteString("\n")
+			}
+		}
+	}
+}
+
+func isBlockElement(name string) bool {
+	switch name {
+	case "p", "div", "li", "ul", "ol", "h1", "h2", "h3", "h4", "h5", "h6", "tr", "table", "blockquote", "pre":
+		return true
+	}
+	return false
+}
+
+// stripControlCharacters removes control characters other than newlines and
+// tabs.
+func stripControlCharacters(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+// fieldCodes returns the "field=code" pairs of a validation error.
+func fieldCodes(t *testing.T, err error) string {
+	t.Helper()
+
+	var validationErr *model.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("err = %v, want a validation error", err)
+	}
+	pairs := make([]string, len(validationErr.Errors))
+	for i, fieldErr := range validationErr.Errors {
+		pairs[i] = fieldErr.Field + "=" + fieldErr.Code
+	}
+	return strings.Join(pairs, ",")
+}
+
+func TestCreateItemValidation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	if _, err := env.categories.CreateCategory(ctx, &model.CreateCategoryRequest{
+		Name:     "Jewelry",
+		MinPrice: &model.Money{Amount: 5000, Currency: "USD"},
+		MaxPrice: &model.Money{Amount: 500000, Currency: "USD"},
+	}, testUser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.categories.CreateCategory(ctx, &model.CreateCategoryRequest{Name: "Rings", Parent: "jewelry"}, testUser); err != nil {
+		t.Fatal(err)
+	}
+
+	usd := func(amount int64) model.Money { return model.Money{Amount: amount, Currency: "USD"} }
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+
+	tests := []struct {
+		name string
+		req  model.CreateItemRequest
+		want string
+	}{
+		{name: "valid", req: model.CreateItemRequest{Name: "Gold ring (18k)", Category: "rings", Price: usd(25000)}},
+		{name: "converted price in bounds", req: model.CreateItemRequest{Name: "Ring", Category: "rings", Price: model.Money{Amount: 150000, Currency: "JPY"}}},
+		{name: "everything missing", req: model.CreateItemRequest{}, want: "name=required,price=required"},
+		{name: "name too long", req: model.CreateItemRequest{Name: strings.Repeat("a", MaxNameLength+1), Price: usd(100)}, want: "name=too_long"},
+		{name: "markup in name", req: model.CreateItemRequest{Name: "<b>Lamp</b>", Price: usd(100)}, want: "name=invalid_characters"},
+		{name: "bad sku", req: model.CreateItemRequest{Name: "Lamp", SKU: "LAMP 1", Price: usd(100)}, want: "sku=invalid_format"},
+		{name: "unknown currency", req: model.CreateItemRequest{Name: "Lamp", Price: model.Money{Amount: 100, Currency: "XXX"}}, want: "price=invalid_currency"},
+		{name: "negative stock", req: model.CreateItemRequest{Name: "Lamp", Price: usd(100), Stock: -1}, want: "stock=out_of_range"},
+		{name: "below inherited bound", req: model.CreateItemRequest{Name: "Ring", Category: "rings", Price: usd(100)}, want: "price=out_of_range"},
+		{name: "above bound", req: model.CreateItemRequest{Name: "Ring", Category: "jewelry", Price: usd(600000)}, want: "price=out_of_range"},
+		{
+			name: "price list entries",
+			req: model.CreateItemRequest{Name: "Ring", Category: "rings", Price: usd(10000), PriceList: []model.PriceListEntry{
+				{Price: usd(10)},
+				{Price: usd(10000), ValidFrom: &from, ValidTo: &to},
+			}},
+			want: "price_list[0].price=out_of_range,price_list[1].valid_to=out_of_range",
+		},
+		{
+			name: "attributes on free-text category",
+			req:  model.CreateItemRequest{Name: "Mug", Category: "Kitchen", Price: usd(100), Attributes: map[string]interface{}{"color": "red"}},
+			want: "attributes=undefined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			_, err := env.items.CreateItem(ctx, &req, testUser)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if got := fieldCodes(t, err); got != tt.want {
+				t.Errorf("errors = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateItemOnlyValidatesChangedFields(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	category, err := env.categories.CreateCategory(ctx, &model.CreateCategoryRequest{Name: "Lighting"}, testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := env.createItem(t, &model.CreateItemRequest{Name: "Lamp", Category: "lighting", Price: model.Money{Amount: 1000, Currency: "USD"}})
+
+	// Bounds added later do not block unrelated updates such as stock
+	if _, err := env.categories.UpdateCategory(ctx, category.Slug, &model.UpdateCategoryRequest{
+		MinPrice: &model.Money{Amount: 5000, Currency: "USD"},
+	}, testUser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.items.UpdateItem(ctx, item.ID.Hex(), &model.UpdateItemRequest{Stock: 3}, testUser); err != nil {
+		t.Fatalf("stock update: %v", err)
+	}
+
+	price := model.Money{Amount: 2000, Currency: "USD"}
+	_, err = env.items.UpdateItem(ctx, item.ID.Hex(), &model.UpdateItemRequest{Price: &price, Stock: -1}, testUser)
+	if got := fieldCodes(t, err); got != "price=out_of_range" {
+		t.Errorf("errors = %s, want price=out_of_range", got)
+	}
+}
+
+func TestCreateVariantRequiresSKU(t *testing.T) {
+	env := newTestEnv(t)
+	parent := env.createItem(t, &model.CreateItemRequest{Name: "Shirt"})
+
+	_, err := env.items.CreateVariant(context.Background(), parent.ID.Hex(), &model.CreateVariantRequest{
+		Price: model.Money{Amount: 1000, Currency: "USD"},
+	}, testUser)
+	if got := fieldCodes(t, err); got != "sku=required" {
+		t.Errorf("errors = %s, want sku=required", got)
+	}
+}
+
+func TestSanitizeDescription(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Plain text & more", want: "Plain text & more"},
+		{in: "<p>Warm <b>light</b></p><p>Dimmable</p>", want: "Warm light\nDimmable"},
+		{in: "Nice<script>alert('x')</script> lamp", want: "Nice lamp"},
+		{in: `<img src=x onerror="alert(1)">Lamp`, want: "Lamp"},
+		{in: "&lt;script&gt;alert(1)&lt;/script&gt;", want: "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{in: "<style>body{}</style><iframe src=evil></iframe>Safe", want: "Safe"},
+		{in: "Line\x00one\r\nLine two", want: "Lineone\nLine two"},
+	}
+
+	for _, tt := range tests {
+		if got := SanitizeDescription(tt.in); got != tt.want {
+			t.Errorf("SanitizeDescription(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCategoryPriceBoundsMustBeConsistent(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.categories.CreateCategory(context.Background(), &model.CreateCategoryRequest{
+		Name:     "Watches",
+		MinPrice: &model.Money{Amount: 500, Currency: "USD"},
+		MaxPrice: &model.Money{Amount: 100, Currency: "USD"},
+	}, testUser)
+	if err == nil || !strings.Contains(err.Error(), "min_price must not exceed max_price") {
+		t.Errorf("err = %v, want inconsistent bounds to be rejected", err)
+	}
+}
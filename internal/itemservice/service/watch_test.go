@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"rancher-manager/internal/itemservice/model"
+)
+
+// nextEvent waits for the next event of a watch.
+func nextEvent(t *testing.T, events <-chan model.ItemEvent) model.ItemEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("watch ended")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event within 1s")
+	}
+	return model.ItemEvent{}
+}
+
+func (e *testEnv) watch(t *testing.T, filter *model.WatchFilter) <-chan model.ItemEvent {
+	t.Helper()
+
+	events, cancel, err := e.items.WatchItems(context.Background(), filter, testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cancel)
+	return events
+}
+
+func (e *testEnv) rename(t *testing.T, item *model.Item, name string) {
+	t.Helper()
+
+	if _, err := e.items.UpdateItem(context.Background(), item.ID.Hex(), &model.UpdateItemRequest{Name: name, Stock: -1}, testUser); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchItemsFiltersByItemID(t *testing.T) {
+	env := newTestEnv(t)
+	watched := env.createItem(t, &model.CreateItemRequest{Name: "Lamp"})
+	other := env.createItem(t, &model.CreateItemRequest{Name: "Chair"})
+
+	events := env.watch(t, &model.WatchFilter{ItemIDs: []string{watched.ID.Hex()}})
+
+	env.rename(t, other, "Armchair")
+	env.rename(t, watched, "Desk lamp")
+
+	event := nextEvent(t, events)
+	if event.ItemID != watched.ID.Hex() || event.Item.Name != "Desk lamp" {
+		t.Errorf("event = %s %s, want update of the watched item", event.Type, event.ItemID)
+	}
+}
+
+func TestWatchItemsFiltersByCategory(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	if _, err := env.categories.CreateCategory(ctx, &model.CreateCategoryRequest{Name: "Clothing"}, testUser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.categories.CreateCategory(ctx, &model.CreateCategoryRequest{Name: "Shirts", Parent: "clothing"}, testUser); err != nil {
+		t.Fatal(err)
+	}
+
+	events := env.watch(t, &model.WatchFilter{Category: "clothing", IncludeDescendants: true})
+
+	env.createItem(t, &model.CreateItemRequest{Name: "Mug", Category: "Kitchen"})
+	tee := env.createItem(t, &model.CreateItemRequest{Name: "Tee", Category: "Shirts"})
+	if event := nextEvent(t, events); event.Type != model.ItemEventCreated || event.ItemID != tee.ID.Hex() {
+		t.Fatalf("event = %s %s, want creation of the shirt", event.Type, event.ItemID)
+	}
+
+	// Moving the item out of the category is reported once, later changes
+	// are not
+	if _, err := env.items.UpdateItem(ctx, tee.ID.Hex(), &model.UpdateItemRequest{Category: "Kitchen", Stock: -1}, testUser); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(t, events); event.Type != model.ItemEventUpdated || event.Item.Category != "Kitchen" {
+		t.Fatalf("event = %s in %q, want the move out of the category", event.Type, event.Item.Category)
+	}
+	env.rename(t, tee, "Kitchen towel")
+
+	coat := env.createItem(t, &model.CreateItemRequest{Name: "Coat", Category: "Clothing"})
+	if event := nextEvent(t, events); event.ItemID != coat.ID.Hex() {
+		t.Fatalf("event for %s, want the coat", event.ItemID)
+	}
+
+	if err := env.items.DeleteItem(ctx, coat.ID.Hex(), testUser); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(t, events); event.Type != model.ItemEventDeleted || event.ItemID != coat.ID.Hex() {
+		t.Fatalf("event = %s %s, want deletion of the coat", event.Type, event.ItemID)
+	}
+}
+
+func TestWatchItemsResumesAfterToken(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	events, cancel, err := env.items.WatchItems(ctx, &model.WatchFilter{}, testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := env.createItem(t, &model.CreateItemRequest{Name: "Lamp"})
+	created := nextEvent(t, events)
+	cancel()
+
+	// Changes made while disconnected are delivered after resuming
+	env.rename(t, item, "Desk lamp")
+	env.rename(t, item, "Floor lamp")
+
+	events = env.watch(t, &model.WatchFilter{ResumeToken: created.ResumeToken})
+	for _, want := range []string{"Desk lamp", "Floor lamp"} {
+		event := nextEvent(t, events)
+		if event.Type != model.ItemEventUpdated || event.Item.Name != want {
+			t.Errorf("event = %s %q, want update to %q", event.Type, event.Item.Name, want)
+		}
+	}
+}
+
+func TestWatchItemsRejectsUnknownResumeToken(t *testing.T) {
+	env := newTestEnv(t)
+	env.createItem(t, &model.CreateItemRequest{Name: "Lamp"})
+
+	for _, token := range []string{"bogus", "0.1", "x"} {
+		_, _, err := env.items.WatchItems(context.Background(), &model.WatchFilter{ResumeToken: token}, testUser)
+		if !errors.Is(err, ErrResumeTokenExpired) {
+			t.Errorf("token %q: err = %v, want ErrResumeTokenExpired", token, err)
+		}
+	}
+}
+
+func TestEventBusExpiresOldTokens(t *testing.T) {
+	bus := NewEventBus()
+	bus.Publish(model.ItemEventCreated, "first", nil)
+
+	events, cancel, err := bus.Watch(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	bus.Publish(model.ItemEventCreated, "second", nil)
+	token := nextEvent(t, events).ResumeToken
+	cancel()
+
+	for i := 0; i <= eventHistorySize; i++ {
+		bus.Publish(model.ItemEventUpdated, strconv.Itoa(i), nil)
+	}
+	if _, _, err := bus.Watch(context.Background(), token); !errors.Is(err, ErrResumeTokenExpired) {
+		t.Errorf("err = %v, want ErrResumeTokenExpired once the history has rolled over", err)
+	}
+}
+
+func TestEventBusDropsSlowWatcher(t *testing.T) {
+	bus := NewEventBus()
+	events, cancel, err := bus.Watch(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	for i := 0; i <= eventBufferSize; i++ {
+		bus.Publish(model.ItemEventUpdated, strconv.Itoa(i), nil)
+	}
+
+	received := 0
+	for range events {
+		received++
+	}
+	if received != eventBufferSize {
+		t.Errorf("received %d events before the watch ended, want %d", received, eventBufferSize)
+	}
+}
@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned when a key does not exist in the store.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores opaque binary objects such as item attachments under
+// slash-separated keys.
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -5,13 +5,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"time"
 
 	"github.com/Shopify/sarama"
+
+	"rancher-manager/idempotency"
 )
 
+// eventClaimTimeout is how long an event is claimed for while it is being
+// handled. Claims of consumers that crash mid-handle expire after it.
+const eventClaimTimeout = time.Minute
+
 type Consumer struct {
 	consumer sarama.ConsumerGroup
 	handler  EventHandler
+	events   idempotency.Store
+	eventTTL time.Duration
 }
 
 type EventHandler interface {
@@ -35,6 +45,15 @@ func NewConsumer(brokers []string, groupID string, handler EventHandler) (*Consu
 	}, nil
 }
 
+// DeduplicateEvents makes the consumer handle each event ID in the event_id
+// header once within ttl, remembering the IDs in store, so that events
+// delivered more than once are applied once. Events without an ID are
+// always handled.
+func (c *Consumer) DeduplicateEvents(store idempotency.Store, ttl time.Duration) {
+	c.events = store
+	c.eventTTL = ttl
+}
+
 func (c *Consumer) Start(ctx context.Context, topics []string) error {
 	for {
 		err := c.consumer.Consume(ctx, topics, c)
@@ -52,34 +71,90 @@ func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim saram
 	for message := range claim.Messages() {
 		log.Printf("Message topic:%q partition:%d offset:%d\n", message.Topic, message.Partition, message.Offset)
 
-		switch message.Topic {
-		case "stock_updates":
-			var event StockUpdateEvent
-			if err := json.Unmarshal(message.Value, &event); err != nil {
-				log.Printf("Failed to unmarshal stock update event: %v", err)
-				continue
-			}
-			if err := c.handler.HandleStockUpdate(&event); err != nil {
-				log.Printf("Failed to handle stock update event: %v", err)
-			}
+		c.consume(session.Context(), message)
+		session.MarkMessage(message, "")
+	}
 
-		case "item_deletes":
-			var event ItemDeleteEvent
-			if err := json.Unmarshal(message.Value, &event); err != nil {
-				log.Printf("Failed to unmarshal item delete event: %v", err)
-				continue
-			}
-			if err := c.handler.HandleItemDelete(&event); err != nil {
-				log.Printf("Failed to handle item delete event: %v", err)
+	return nil
+}
+
+// consume handles a message unless its event has been handled already.
+// The event is claimed while it is handled and only remembered for the
+// dedupe TTL once handled, so that an event whose consumer crashed is
+// handled again when it is delivered again.
+func (c *Consumer) consume(ctx context.Context, message *sarama.ConsumerMessage) {
+	key := ""
+	if id := eventID(message); id != "" && c.events != nil {
+		fingerprint := idempotency.Fingerprint([]byte(message.Topic), message.Value)
+		held, err := c.events.Begin(ctx, "event:"+id, fingerprint, time.Now().Add(eventClaimTimeout))
+		switch {
+		case err != nil:
+			// Rather apply the event twice than lose it
+			log.Printf("Failed to check event %s for duplicates: %v", id, err)
+		case held == nil:
+			key = "event:" + id
+		case held.Fingerprint != fingerprint:
+			log.Printf("Warning: Event ID %s reused for a different event; handling it", id)
+		case held.Completed():
+			log.Printf("Skipping event %s, already handled", id)
+			return
+		default:
+			// Its consumer may have crashed; rather apply it twice than lose it
+			log.Printf("Event %s is claimed but not yet handled; handling it", id)
+		}
+	}
+
+	if err := c.handle(message); err != nil {
+		log.Printf("Failed to handle %s event: %v", message.Topic, err)
+		// Let the event be handled if it is delivered again
+		if key != "" {
+			if err := c.events.Release(ctx, key); err != nil {
+				log.Printf("Failed to release event %s: %v", key, err)
 			}
 		}
+		return
+	}
 
-		session.MarkMessage(message, "")
+	if key != "" {
+		// Any status marks the record as handled
+		err := c.events.Complete(ctx, key, http.StatusOK, "", nil, time.Now().Add(c.eventTTL))
+		if err != nil {
+			log.Printf("Failed to record event %s as handled: %v", key, err)
+		}
 	}
+}
+
+func (c *Consumer) handle(message *sarama.ConsumerMessage) error {
+	switch message.Topic {
+	case TopicStockUpdates:
+		var event StockUpdateEvent
+		if err := json.Unmarshal(message.Value, &event); err != nil {
+			log.Printf("Failed to unmarshal stock update event: %v", err)
+			return nil
+		}
+		return c.handler.HandleStockUpdate(&event)
 
+	case TopicItemDeletes:
+		var event ItemDeleteEvent
+		if err := json.Unmarshal(message.Value, &event); err != nil {
+			log.Printf("Failed to unmarshal item delete event: %v", err)
+			return nil
+		}
+		return c.handler.HandleItemDelete(&event)
+	}
 	return nil
 }
 
+// eventID returns the ID the publisher sent in the event_id header.
+func eventID(message *sarama.ConsumerMessage) string {
+	for _, header := range message.Headers {
+		if header != nil && string(header.Key) == "event_id" {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
 func (c *Consumer) Setup(sarama.ConsumerGroupSession) error {
 	return nil
 }
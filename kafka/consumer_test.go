@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+
+	"rancher-manager/idempotency"
+)
+
+// fakeHandler counts the stock updates it handles and fails while failing
+// is set.
+type fakeHandler struct {
+	failing bool
+	updates int
+}
+
+func (h *fakeHandler) HandleStockUpdate(event *StockUpdateEvent) error {
+	if h.failing {
+		return errors.New("database is down")
+	}
+	h.updates++
+	return nil
+}
+
+func (h *fakeHandler) HandleItemDelete(event *ItemDeleteEvent) error {
+	return nil
+}
+
+func stockUpdateMessage(t *testing.T, id string) *sarama.ConsumerMessage {
+	t.Helper()
+
+	value, err := json.Marshal(&StockUpdateEvent{ItemID: "lamp", NewStock: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &sarama.ConsumerMessage{
+		Topic:   TopicStockUpdates,
+		Value:   value,
+		Headers: []*sarama.RecordHeader{{Key: []byte("event_id"), Value: []byte(id)}},
+	}
+}
+
+func TestConsumeHandlesRedeliveriesOnlyUntilHandled(t *testing.T) {
+	handler := &fakeHandler{}
+	consumer := &Consumer{handler: handler}
+	consumer.DeduplicateEvents(idempotency.NewMemoryStore(), time.Hour)
+	ctx := context.Background()
+	message := stockUpdateMessage(t, "1")
+
+	// A failed event is handled again when delivered again
+	handler.failing = true
+	consumer.consume(ctx, message)
+	handler.failing = false
+	consumer.consume(ctx, message)
+	if handler.updates != 1 {
+		t.Fatalf("handled %d times, want 1", handler.updates)
+	}
+
+	consumer.consume(ctx, message)
+	if handler.updates != 1 {
+		t.Errorf("handled %d times after a redelivery, want 1", handler.updates)
+	}
+}
+
+func TestConsumeHandlesEventsClaimedByCrashedConsumer(t *testing.T) {
+	handler := &fakeHandler{}
+	store := idempotency.NewMemoryStore()
+	consumer := &Consumer{handler: handler}
+	consumer.DeduplicateEvents(store, time.Hour)
+	ctx := context.Background()
+	message := stockUpdateMessage(t, "1")
+
+	// A consumer claimed the event and crashed before handling it
+	fingerprint := idempotency.Fingerprint([]byte(message.Topic), message.Value)
+	if _, err := store.Begin(ctx, "event:1", fingerprint, time.Now().Add(eventClaimTimeout)); err != nil {
+		t.Fatal(err)
+	}
+
+	consumer.consume(ctx, message)
+	if handler.updates != 1 {
+		t.Errorf("handled %d times, want the redelivery handled", handler.updates)
+	}
+}
@@ -8,6 +8,13 @@ import (
 	"github.com/Shopify/sarama"
 )
 
+// Topics events are published to.
+const (
+	TopicStockUpdates = "stock_updates"
+	TopicItemDeletes  = "item_deletes"
+	TopicStockLow     = "stock_low"
+)
+
 type Publisher struct {
 	producer sarama.SyncProducer
 }
@@ -25,6 +32,18 @@ type ItemDeleteEvent struct {
 	EventType string `json:"event_type"`
 }
 
+// StockLowEvent is published when the stock available of an item drops
+// below its minimum.
+type StockLowEvent struct {
+	ItemID                 string `json:"item_id"`
+	Stock                  int    `json:"stock"`
+	Available              int    `json:"available"`
+	MinStock               int    `json:"min_stock"`
+	ReorderPoint           int    `json:"reorder_point"`
+	SuggestedOrderQuantity int    `json:"suggested_order_quantity"`
+	EventType              string `json:"event_type"`
+}
+
 func NewPublisher(brokers []string) (*Publisher, error) {
 	config := sarama.NewConfig()
 	config.Producer.RequiredAcks = sarama.WaitForAll
@@ -39,39 +58,53 @@ func NewPublisher(brokers []string) (*Publisher, error) {
 	return &Publisher{producer: producer}, nil
 }
 
-func (p *Publisher) PublishStockUpdate(event *StockUpdateEvent) error {
-	event.EventType = "stock_update"
-
-	message, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to marshal event: %v", err)
-	}
-
-	msg := &sarama.ProducerMessage{
-		Topic: "stock_updates",
-		Value: sarama.StringEncoder(message),
-	}
+// Message is an event encoded for its topic. ID, if set, is sent in the
+// event_id header so that consumers can drop events delivered twice.
+type Message struct {
+	Topic string
+	Key   string
+	Value []byte
+	ID    string
+}
 
-	partition, offset, err := p.producer.SendMessage(msg)
-	if err != nil {
-		return fmt.Errorf("failed to send message: %v", err)
-	}
+// Message encodes the event for TopicStockUpdates.
+func (e *StockUpdateEvent) Message() (*Message, error) {
+	e.EventType = "stock_update"
+	return newMessage(TopicStockUpdates, "", e)
+}
 
-	log.Printf("Stock update event published to partition %d at offset %d", partition, offset)
-	return nil
+// Message encodes the event for TopicItemDeletes.
+func (e *ItemDeleteEvent) Message() (*Message, error) {
+	e.EventType = "item_delete"
+	return newMessage(TopicItemDeletes, "", e)
 }
 
-func (p *Publisher) PublishItemDelete(event *ItemDeleteEvent) error {
-	event.EventType = "item_delete"
+// Message encodes the event for TopicStockLow, keyed by item.
+func (e *StockLowEvent) Message() (*Message, error) {
+	e.EventType = "stock_low"
+	return newMessage(TopicStockLow, e.ItemID, e)
+}
 
-	message, err := json.Marshal(event)
+func newMessage(topic, key string, event interface{}) (*Message, error) {
+	value, err := json.Marshal(event)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %v", err)
+		return nil, fmt.Errorf("failed to marshal event: %v", err)
 	}
+	return &Message{Topic: topic, Key: key, Value: value}, nil
+}
 
+// Publish sends an encoded event. Services publish through their outbox
+// relay, so that events are written with the changes they report.
+func (p *Publisher) Publish(message *Message) error {
 	msg := &sarama.ProducerMessage{
-		Topic: "item_deletes",
-		Value: sarama.StringEncoder(message),
+		Topic: message.Topic,
+		Value: sarama.ByteEncoder(message.Value),
+	}
+	if message.Key != "" {
+		msg.Key = sarama.StringEncoder(message.Key)
+	}
+	if message.ID != "" {
+		msg.Headers = []sarama.RecordHeader{{Key: []byte("event_id"), Value: []byte(message.ID)}}
 	}
 
 	partition, offset, err := p.producer.SendMessage(msg)
@@ -79,7 +112,7 @@ func (p *Publisher) PublishItemDelete(event *ItemDeleteEvent) error {
 		return fmt.Errorf("failed to send message: %v", err)
 	}
 
-	log.Printf("Item delete event published to partition %d at offset %d", partition, offset)
+	log.Printf("%s event published to partition %d at offset %d", message.Topic, partition, offset)
 	return nil
 }
 